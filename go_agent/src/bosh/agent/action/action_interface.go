@@ -19,3 +19,10 @@ type Action interface {
 	Resume() (interface{}, error)
 	Cancel() error
 }
+
+// ProgressReporter is optionally implemented by asynchronous actions
+// that can describe how far along they are while running.
+// Progress is included in get_task responses for running tasks.
+type ProgressReporter interface {
+	Progress() interface{}
+}
@@ -10,10 +10,10 @@ import (
 
 type ApplyAction struct {
 	applier     boshappl.Applier
-	specService boshas.V1Service
+	specService boshas.V2Service
 }
 
-func NewApply(applier boshappl.Applier, specService boshas.V1Service) (action ApplyAction) {
+func NewApply(applier boshappl.Applier, specService boshas.V2Service) (action ApplyAction) {
 	action.applier = applier
 	action.specService = specService
 	return
@@ -27,7 +27,7 @@ func (a ApplyAction) IsPersistent() bool {
 	return false
 }
 
-func (a ApplyAction) Run(desiredSpec boshas.V1ApplySpec) (interface{}, error) {
+func (a ApplyAction) Run(desiredSpec boshas.V2ApplySpec) (interface{}, error) {
 	if desiredSpec.ConfigurationHash != "" {
 		currentSpec, err := a.specService.Get()
 		if err != nil {
@@ -16,13 +16,13 @@ func init() {
 	Describe("ApplyAction", func() {
 		var (
 			applier     *fakeappl.FakeApplier
-			specService *fakeas.FakeV1Service
+			specService *fakeas.FakeV2Service
 			action      ApplyAction
 		)
 
 		BeforeEach(func() {
 			applier = fakeappl.NewFakeApplier()
-			specService = fakeas.NewFakeV1Service()
+			specService = fakeas.NewFakeV2Service()
 			action = NewApply(applier, specService)
 		})
 
@@ -36,8 +36,8 @@ func init() {
 
 		Describe("Run", func() {
 			Context("when desired spec has configuration hash", func() {
-				currentApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}
-				desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}
+				currentApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-current-config-hash"}.ToV2()
+				desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}.ToV2()
 
 				Context("when current spec can be retrieved", func() {
 					BeforeEach(func() {
@@ -124,7 +124,7 @@ func init() {
 					JobSpec: boshas.JobSpec{
 						Template: "fake-job-template",
 					},
-				}
+				}.ToV2()
 
 				Context("when saving desires spec as current spec succeeds", func() {
 					It("returns 'applied' after setting desired spec as current spec", func() {
@@ -8,6 +8,17 @@ import (
 	bosherr "bosh/errors"
 )
 
+// CompilePackageError is returned when package fails to compile;
+// build log (if it was uploaded) is available under LogBlobstoreID.
+type CompilePackageError struct {
+	Err            error
+	LogBlobstoreID string
+}
+
+func (e CompilePackageError) Error() string {
+	return e.Err.Error()
+}
+
 type CompilePackageAction struct {
 	compiler boshcomp.Compiler
 }
@@ -46,15 +57,19 @@ func (a CompilePackageAction) Run(blobID, sha1, name, version string, deps boshc
 		})
 	}
 
-	uploadedBlobID, uploadedSha1, err := a.compiler.Compile(pkg, modelsDeps)
+	uploadedBlobID, uploadedSha1, logBlobID, err := a.compiler.Compile(pkg, modelsDeps)
 	if err != nil {
-		err = bosherr.WrapError(err, "Compiling package %s", pkg.Name)
+		err = CompilePackageError{
+			Err:            bosherr.WrapError(err, "Compiling package %s", pkg.Name),
+			LogBlobstoreID: logBlobID,
+		}
 		return
 	}
 
 	result := map[string]string{
-		"blobstore_id": uploadedBlobID,
-		"sha1":         uploadedSha1,
+		"blobstore_id":     uploadedBlobID,
+		"sha1":             uploadedSha1,
+		"log_blobstore_id": logBlobID,
 	}
 
 	val = map[string]interface{}{
@@ -57,6 +57,7 @@ func init() {
 			compiler, action := buildCompilePackageAction()
 			compiler.CompileBlobID = "my-blob-id"
 			compiler.CompileSha1 = "some sha1"
+			compiler.CompileLogBlobID = "my-log-blob-id"
 
 			blobID, sha1, name, version, deps := getCompileActionArguments()
 
@@ -68,8 +69,9 @@ func init() {
 			}
 			expectedJSON := map[string]interface{}{
 				"result": map[string]string{
-					"blobstore_id": "my-blob-id",
-					"sha1":         "some sha1",
+					"blobstore_id":     "my-blob-id",
+					"sha1":             "some sha1",
+					"log_blobstore_id": "my-log-blob-id",
 				},
 			}
 			expectedDeps := []boshmodels.Package{
@@ -111,5 +113,20 @@ func init() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
 		})
+
+		It("returns build log blob id when compile fails", func() {
+			compiler, action := buildCompilePackageAction()
+			compiler.CompileErr = errors.New("fake-compile-error")
+			compiler.CompileLogBlobID = "fake-log-blob-id"
+
+			blobID, sha1, name, version, deps := getCompileActionArguments()
+
+			_, err := action.Run(blobID, sha1, name, version, deps)
+			Expect(err).To(HaveOccurred())
+
+			compileErr, ok := err.(CompilePackageError)
+			Expect(ok).To(BeTrue())
+			Expect(compileErr.LogBlobstoreID).To(Equal("fake-log-blob-id"))
+		})
 	})
 }
@@ -0,0 +1,51 @@
+package action
+
+import (
+	"errors"
+
+	boshcomp "bosh/agent/compiler"
+	bosherr "bosh/errors"
+)
+
+// CompilePackagesAction compiles a set of packages that may depend
+// on each other; packages are compiled in dependency order and
+// independent packages are compiled in parallel.
+type CompilePackagesAction struct {
+	compiler boshcomp.Compiler
+}
+
+func NewCompilePackages(compiler boshcomp.Compiler) (compilePackages CompilePackagesAction) {
+	compilePackages.compiler = compiler
+	return
+}
+
+func (a CompilePackagesAction) IsAsynchronous() bool {
+	return true
+}
+
+func (a CompilePackagesAction) IsPersistent() bool {
+	return false
+}
+
+// Run returns a result for every package; failure of a single package
+// is reported in its result instead of failing the whole task.
+func (a CompilePackagesAction) Run(pkgs []boshcomp.BatchPackage) (val map[string]interface{}, err error) {
+	results, err := a.compiler.CompileBatch(pkgs)
+	if err != nil {
+		err = bosherr.WrapError(err, "Compiling packages")
+		return
+	}
+
+	val = map[string]interface{}{
+		"result": results,
+	}
+	return
+}
+
+func (a CompilePackagesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a CompilePackagesAction) Cancel() error {
+	return errors.New("not supported")
+}
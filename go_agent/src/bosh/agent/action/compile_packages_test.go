@@ -0,0 +1,68 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshcomp "bosh/agent/compiler"
+	fakecomp "bosh/agent/compiler/fakes"
+)
+
+var _ = Describe("CompilePackagesAction", func() {
+	var (
+		compiler *fakecomp.FakeCompiler
+		action   CompilePackagesAction
+	)
+
+	BeforeEach(func() {
+		compiler = fakecomp.NewFakeCompiler()
+		action = NewCompilePackages(compiler)
+	})
+
+	It("is asynchronous", func() {
+		Expect(action.IsAsynchronous()).To(BeTrue())
+	})
+
+	It("is not persistent", func() {
+		Expect(action.IsPersistent()).To(BeFalse())
+	})
+
+	Describe("Run", func() {
+		pkgs := []boshcomp.BatchPackage{
+			{
+				Package: boshcomp.Package{Name: "fake-pkg-1", BlobstoreID: "fake-blob-1"},
+			},
+			{
+				Package: boshcomp.Package{Name: "fake-pkg-2", BlobstoreID: "fake-blob-2"},
+				Dependencies: boshcomp.Dependencies{
+					"fake-pkg-1": boshcomp.Package{Name: "fake-pkg-1"},
+				},
+			},
+		}
+
+		It("compiles packages as a batch and returns results by package name", func() {
+			compiler.CompileBatchResults = map[string]boshcomp.BatchResult{
+				"fake-pkg-1": boshcomp.BatchResult{BlobstoreID: "fake-compiled-blob-1", Sha1: "fake-sha1-1"},
+				"fake-pkg-2": boshcomp.BatchResult{Error: "fake-compile-error"},
+			}
+
+			val, err := action.Run(pkgs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(compiler.CompileBatchPkgs).To(Equal(pkgs))
+			Expect(val).To(Equal(map[string]interface{}{
+				"result": compiler.CompileBatchResults,
+			}))
+		})
+
+		It("returns error when batch cannot be compiled", func() {
+			compiler.CompileBatchErr = errors.New("fake-batch-error")
+
+			_, err := action.Run(pkgs)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-batch-error"))
+		})
+	})
+})
@@ -1,10 +1,13 @@
 package action
 
 import (
+	"path/filepath"
+
 	boshappl "bosh/agent/applier"
 	boshas "bosh/agent/applier/applyspec"
 	boshcomp "bosh/agent/compiler"
 	boshdrain "bosh/agent/drain"
+	boshscript "bosh/agent/script"
 	boshtask "bosh/agent/task"
 	boshblob "bosh/blobstore"
 	bosherr "bosh/errors"
@@ -14,6 +17,7 @@ import (
 	boshnotif "bosh/notification"
 	boshplatform "bosh/platform"
 	boshntp "bosh/platform/ntp"
+	boshvitals "bosh/platform/vitals"
 	boshsettings "bosh/settings"
 )
 
@@ -25,14 +29,20 @@ func NewFactory(
 	settings boshsettings.Service,
 	platform boshplatform.Platform,
 	infrastructure boshinfrastructure.Infrastructure,
-	blobstore boshblob.Blobstore,
+	blobstore boshblob.Cache,
 	taskService boshtask.Service,
 	notifier boshnotif.Notifier,
 	applier boshappl.Applier,
+	planner boshappl.Planner,
+	verifier boshappl.Verifier,
 	compiler boshcomp.Compiler,
 	jobSupervisor boshjobsuper.JobSupervisor,
-	specService boshas.V1Service,
+	specService boshas.V2Service,
 	drainScriptProvider boshdrain.DrainScriptProvider,
+	drainOptions boshdrain.Options,
+	startOptions StartOptions,
+	scriptProvider boshscript.ScriptProvider,
+	vitalsHistory boshvitals.History,
 	logger boshlog.Logger,
 ) (factory Factory) {
 	compressor := platform.GetCompressor()
@@ -40,6 +50,7 @@ func NewFactory(
 	dirProvider := platform.GetDirProvider()
 	vitalsService := platform.GetVitalsService()
 	ntpService := boshntp.NewConcreteService(platform.GetFs(), dirProvider)
+	errandsDir := filepath.Join(dirProvider.DataDir(), "errands")
 
 	factory = concreteFactory{
 		availableActions: map[string]Action{
@@ -52,17 +63,28 @@ func NewFactory(
 			"ssh":        NewSsh(settings, platform, dirProvider),
 			"fetch_logs": NewLogs(compressor, copier, blobstore, dirProvider),
 
+			// Monitoring
+			"get_vitals_history": NewGetVitalsHistory(vitalsHistory),
+
 			// Job management
-			"prepare":    NewPrepare(applier),
-			"apply":      NewApply(applier, specService),
-			"start":      NewStart(jobSupervisor),
-			"stop":       NewStop(jobSupervisor),
-			"drain":      NewDrain(notifier, specService, drainScriptProvider, jobSupervisor),
-			"get_state":  NewGetState(settings, specService, jobSupervisor, vitalsService, ntpService),
-			"run_errand": NewRunErrand(specService, dirProvider.JobsDir(), platform.GetRunner()),
+			"prepare":        NewPrepare(applier),
+			"apply":          NewApply(applier, specService),
+			"plan_apply":     NewPlanApply(planner, specService),
+			"verify_bundles": NewVerifyBundles(verifier, specService),
+			"start":          NewStart(jobSupervisor, specService, scriptProvider, startOptions.Timeout()),
+			"stop":           NewStop(jobSupervisor),
+			"drain":          NewDrain(notifier, specService, drainScriptProvider, jobSupervisor, drainOptions.Timeout()),
+			"get_state":      NewGetState(settings, specService, jobSupervisor, vitalsService, ntpService, blobstore, logger),
+			"run_errand":     NewRunErrand(specService, dirProvider.JobsDir(), errandsDir, platform.GetRunner(), platform.GetFs(), compressor, blobstore, logger),
+
+			// Process management
+			"start_process":   NewStartProcess(jobSupervisor, specService, platform.GetFs(), dirProvider.JobsDir(), DefaultProcessTimeout),
+			"stop_process":    NewStopProcess(jobSupervisor, specService, platform.GetFs(), dirProvider.JobsDir(), DefaultProcessTimeout),
+			"restart_process": NewRestartProcess(jobSupervisor, specService, platform.GetFs(), dirProvider.JobsDir(), DefaultProcessTimeout),
 
 			// Compilation
 			"compile_package":    NewCompilePackage(compiler),
+			"compile_packages":   NewCompilePackages(compiler),
 			"release_apply_spec": NewReleaseApplySpec(platform),
 
 			// Disk management
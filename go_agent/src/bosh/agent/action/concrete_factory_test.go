@@ -9,6 +9,7 @@ import (
 	fakeappl "bosh/agent/applier/fakes"
 	fakecomp "bosh/agent/compiler/fakes"
 	boshdrain "bosh/agent/drain"
+	fakescript "bosh/agent/script/fakes"
 	faketask "bosh/agent/task/fakes"
 	fakeblobstore "bosh/blobstore/fakes"
 	fakeinfrastructure "bosh/infrastructure/fakes"
@@ -17,6 +18,7 @@ import (
 	fakenotif "bosh/notification/fakes"
 	fakeplatform "bosh/platform/fakes"
 	boshntp "bosh/platform/ntp"
+	fakevitals "bosh/platform/vitals/fakes"
 	fakesettings "bosh/settings/fakes"
 )
 
@@ -26,14 +28,18 @@ func init() {
 			settings            *fakesettings.FakeSettingsService
 			platform            *fakeplatform.FakePlatform
 			infrastructure      *fakeinfrastructure.FakeInfrastructure
-			blobstore           *fakeblobstore.FakeBlobstore
+			blobstore           *fakeblobstore.FakeCache
 			taskService         *faketask.FakeService
 			notifier            *fakenotif.FakeNotifier
 			applier             *fakeappl.FakeApplier
+			planner             *fakeappl.FakePlanner
+			verifier            *fakeappl.FakeVerifier
 			compiler            *fakecomp.FakeCompiler
 			jobSupervisor       *fakejobsuper.FakeJobSupervisor
-			specService         *fakeas.FakeV1Service
+			specService         *fakeas.FakeV2Service
 			drainScriptProvider boshdrain.DrainScriptProvider
+			scriptProvider      *fakescript.FakeScriptProvider
+			vitalsHistory       *fakevitals.FakeHistory
 			factory             Factory
 			logger              boshlog.Logger
 		)
@@ -42,14 +48,18 @@ func init() {
 			settings = &fakesettings.FakeSettingsService{}
 			platform = fakeplatform.NewFakePlatform()
 			infrastructure = fakeinfrastructure.NewFakeInfrastructure()
-			blobstore = &fakeblobstore.FakeBlobstore{}
+			blobstore = fakeblobstore.NewFakeCache()
 			taskService = &faketask.FakeService{}
 			notifier = fakenotif.NewFakeNotifier()
 			applier = fakeappl.NewFakeApplier()
+			planner = fakeappl.NewFakePlanner()
+			verifier = fakeappl.NewFakeVerifier()
 			compiler = fakecomp.NewFakeCompiler()
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-			specService = fakeas.NewFakeV1Service()
+			specService = fakeas.NewFakeV2Service()
 			drainScriptProvider = boshdrain.NewConcreteDrainScriptProvider(nil, nil, platform.GetDirProvider())
+			scriptProvider = fakescript.NewFakeScriptProvider()
+			vitalsHistory = fakevitals.NewFakeHistory()
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 
 			factory = NewFactory(
@@ -60,10 +70,16 @@ func init() {
 				taskService,
 				notifier,
 				applier,
+				planner,
+				verifier,
 				compiler,
 				jobSupervisor,
 				specService,
 				drainScriptProvider,
+				boshdrain.Options{},
+				StartOptions{},
+				scriptProvider,
+				vitalsHistory,
 				logger,
 			)
 		})
@@ -80,10 +96,24 @@ func init() {
 			Expect(action).To(Equal(NewApply(applier, specService)))
 		})
 
+		It("plan_apply", func() {
+			action, err := factory.Create("plan_apply")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action).To(Equal(NewPlanApply(planner, specService)))
+		})
+
+		It("verify_bundles", func() {
+			action, err := factory.Create("verify_bundles")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action).To(Equal(NewVerifyBundles(verifier, specService)))
+		})
+
 		It("drain", func() {
 			action, err := factory.Create("drain")
 			Expect(err).ToNot(HaveOccurred())
-			Expect(action).To(Equal(NewDrain(notifier, specService, drainScriptProvider, jobSupervisor)))
+
+			// Cannot do equality check since channel is used in initializer
+			Expect(action).To(BeAssignableToTypeOf(DrainAction{}))
 		})
 
 		It("fetch_logs", func() {
@@ -108,7 +138,13 @@ func init() {
 			ntpService := boshntp.NewConcreteService(platform.GetFs(), platform.GetDirProvider())
 			action, err := factory.Create("get_state")
 			Expect(err).ToNot(HaveOccurred())
-			Expect(action).To(Equal(NewGetState(settings, specService, jobSupervisor, platform.GetVitalsService(), ntpService)))
+			Expect(action).To(Equal(NewGetState(settings, specService, jobSupervisor, platform.GetVitalsService(), ntpService, blobstore, logger)))
+		})
+
+		It("get_vitals_history", func() {
+			action, err := factory.Create("get_vitals_history")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action).To(Equal(NewGetVitalsHistory(vitalsHistory)))
 		})
 
 		It("list_disk", func() {
@@ -162,13 +198,31 @@ func init() {
 		It("start", func() {
 			action, err := factory.Create("start")
 			Expect(err).ToNot(HaveOccurred())
-			Expect(action).To(Equal(NewStart(jobSupervisor)))
+			Expect(action).To(Equal(NewStart(jobSupervisor, specService, scriptProvider, DefaultStartTimeout)))
 		})
 
 		It("stop", func() {
 			action, err := factory.Create("start")
 			Expect(err).ToNot(HaveOccurred())
-			Expect(action).To(Equal(NewStart(jobSupervisor)))
+			Expect(action).To(Equal(NewStart(jobSupervisor, specService, scriptProvider, DefaultStartTimeout)))
+		})
+
+		It("start_process", func() {
+			action, err := factory.Create("start_process")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action).To(Equal(NewStartProcess(jobSupervisor, specService, platform.GetFs(), platform.GetDirProvider().JobsDir(), DefaultProcessTimeout)))
+		})
+
+		It("stop_process", func() {
+			action, err := factory.Create("stop_process")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action).To(Equal(NewStopProcess(jobSupervisor, specService, platform.GetFs(), platform.GetDirProvider().JobsDir(), DefaultProcessTimeout)))
+		})
+
+		It("restart_process", func() {
+			action, err := factory.Create("restart_process")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action).To(Equal(NewRestartProcess(jobSupervisor, specService, platform.GetFs(), platform.GetDirProvider().JobsDir(), DefaultProcessTimeout)))
 		})
 
 		It("unmount_disk", func() {
@@ -183,6 +237,12 @@ func init() {
 			Expect(action).To(Equal(NewCompilePackage(compiler)))
 		})
 
+		It("compile_packages", func() {
+			action, err := factory.Create("compile_packages")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(action).To(Equal(NewCompilePackages(compiler)))
+		})
+
 		It("run_errand", func() {
 			action, err := factory.Create("run_errand")
 			Expect(err).ToNot(HaveOccurred())
@@ -2,6 +2,11 @@ package action
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	boshas "bosh/agent/applier/applyspec"
 	boshdrain "bosh/agent/drain"
@@ -13,20 +18,33 @@ import (
 type DrainAction struct {
 	drainScriptProvider boshdrain.DrainScriptProvider
 	notifier            boshnotif.Notifier
-	specService         boshas.V1Service
+	specService         boshas.V2Service
 	jobSupervisor       boshjobsuper.JobSupervisor
+
+	// Dynamic drain fails when it takes longer than timeout
+	timeout time.Duration
+
+	cancelCh chan struct{}
+	progress *drainProgress
 }
 
 func NewDrain(
 	notifier boshnotif.Notifier,
-	specService boshas.V1Service,
+	specService boshas.V2Service,
 	drainScriptProvider boshdrain.DrainScriptProvider,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	timeout time.Duration,
 ) (drain DrainAction) {
 	drain.notifier = notifier
 	drain.specService = specService
 	drain.drainScriptProvider = drainScriptProvider
 	drain.jobSupervisor = jobSupervisor
+	drain.timeout = timeout
+
+	// Initialize in a constructor to avoid race
+	// between initializing in Run()/Cancel()/Progress()
+	drain.cancelCh = make(chan struct{}, 1)
+	drain.progress = &drainProgress{}
 	return
 }
 
@@ -46,13 +64,14 @@ const (
 	DrainTypeShutdown DrainType = "shutdown"
 )
 
-func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (int, error) {
+func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V2ApplySpec) (int, error) {
 	currentSpec, err := a.specService.Get()
 	if err != nil {
 		return 0, bosherr.WrapError(err, "Getting current spec")
 	}
 
-	if len(currentSpec.JobSpec.Template) == 0 {
+	jobNames := currentSpec.JobNames()
+	if len(jobNames) == 0 {
 		if drainType == DrainTypeStatus {
 			return 0, bosherr.New("Check Status on Drain action requires job spec")
 		}
@@ -64,49 +83,230 @@ func (a DrainAction) Run(drainType DrainType, newSpecs ...boshas.V1ApplySpec) (i
 		return 0, bosherr.WrapError(err, "Unmonitoring services")
 	}
 
-	drainScript := a.drainScriptProvider.NewDrainScript(currentSpec.JobSpec.Template)
-
-	var params boshdrain.DrainScriptParams
-
-	switch drainType {
-	case DrainTypeUpdate:
-		if len(newSpecs) == 0 {
-			return 0, bosherr.New("Drain update requires new spec")
-		}
-
-		params = boshdrain.NewUpdateDrainParams(currentSpec, newSpecs[0])
+	if drainType == DrainTypeUpdate && len(newSpecs) == 0 {
+		return 0, bosherr.New("Drain update requires new spec")
+	}
 
-	case DrainTypeShutdown:
+	if drainType == DrainTypeShutdown {
 		err = a.notifier.NotifyShutdown()
 		if err != nil {
 			return 0, bosherr.WrapError(err, "Notifying shutdown")
 		}
+	}
 
-		params = boshdrain.NewShutdownDrainParams()
+	drainScripts := map[string]boshdrain.DrainScript{}
 
-	case DrainTypeStatus:
-		params = boshdrain.NewStatusDrainParams()
+	for _, jobName := range jobNames {
+		drainScript := a.drainScriptProvider.NewDrainScript(jobName)
+		if drainScript.Exists() {
+			drainScripts[jobName] = drainScript
+		}
 	}
 
-	if !drainScript.Exists() {
+	if len(drainScripts) == 0 {
 		if drainType == DrainTypeStatus {
 			return 0, bosherr.New("Check Status on Drain action requires a valid drain script")
 		}
 		return 0, nil
 	}
 
-	value, err := drainScript.Run(params)
-	if err != nil {
-		return 0, bosherr.WrapError(err, "Running Drain Script")
+	// Ignore cancels requested before this drain started
+	select {
+	case <-a.cancelCh:
+	default:
 	}
 
-	return value, nil
+	canceledCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	// Single cancel must stop all drain scripts that are waiting
+	go func() {
+		select {
+		case <-a.cancelCh:
+			close(canceledCh)
+		case <-doneCh:
+		}
+	}()
+
+	a.progress.start()
+	defer a.progress.finish()
+
+	results := make(chan drainResult, len(drainScripts))
+
+	for jobName, drainScript := range drainScripts {
+		var params boshdrain.DrainScriptParams
+
+		switch drainType {
+		case DrainTypeUpdate:
+			params = boshdrain.NewUpdateDrainParams(currentSpec, newSpecs[0], jobName)
+		case DrainTypeShutdown:
+			params = boshdrain.NewShutdownDrainParams()
+		case DrainTypeStatus:
+			params = boshdrain.NewStatusDrainParams()
+		}
+
+		go func(jobName string, drainScript boshdrain.DrainScript, params boshdrain.DrainScriptParams) {
+			value, err := a.runDynamically(jobName, drainScript, params, canceledCh)
+			results <- drainResult{jobName: jobName, value: value, err: err}
+		}(jobName, drainScript, params)
+	}
+
+	// Director waits for the slowest drain script
+	var maxValue int
+	var errMsgs []string
+
+	for i := 0; i < len(drainScripts); i++ {
+		result := <-results
+
+		if result.err != nil {
+			errMsgs = append(errMsgs, fmt.Sprintf("job %s: %s", result.jobName, result.err.Error()))
+			continue
+		}
+
+		if result.value > maxValue {
+			maxValue = result.value
+		}
+	}
+
+	if len(errMsgs) > 0 {
+		sort.Strings(errMsgs)
+		return 0, bosherr.New("Running drain scripts failed: %s", strings.Join(errMsgs, "; "))
+	}
+
+	return maxValue, nil
+}
+
+type drainResult struct {
+	jobName string
+	value   int
+	err     error
+}
+
+// runDynamically keeps checking drain status while drain script
+// returns negative values (number of seconds to wait before next check).
+// Positive values are returned for the director to wait on.
+func (a DrainAction) runDynamically(
+	jobName string,
+	drainScript boshdrain.DrainScript,
+	params boshdrain.DrainScriptParams,
+	canceledCh chan struct{},
+) (int, error) {
+	deadline := time.Now().Add(a.timeout)
+
+	for {
+		value, err := drainScript.Run(params)
+		if err != nil {
+			return 0, bosherr.WrapError(err, "Running Drain Script")
+		}
+
+		if value >= 0 {
+			return value, nil
+		}
+
+		wait := time.Duration(-value) * time.Second
+
+		if time.Now().Add(wait).After(deadline) {
+			return 0, bosherr.New("Drain script did not finish within %s", a.timeout)
+		}
+
+		a.progress.waiting(jobName, -value)
+
+		select {
+		case <-time.After(wait):
+		case <-canceledCh:
+			return 0, bosherr.New("Drain was canceled")
+		}
+
+		params = boshdrain.NewStatusDrainParams()
+	}
 }
 
 func (a DrainAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
 
+// Cancel stops waiting for dynamic drain to finish;
+// drain script that is currently running is not interrupted.
 func (a DrainAction) Cancel() error {
-	return errors.New("not supported")
+	select {
+	case a.cancelCh <- struct{}{}:
+	default:
+		// Cancel is already queued up
+	}
+	return nil
+}
+
+// DrainProgress describes dynamic drain that is in progress
+type DrainProgress struct {
+	ElapsedSeconds int `json:"elapsed_seconds"`
+
+	// Only includes jobs that asked to check drain status again
+	Jobs map[string]DrainJobProgress `json:"jobs"`
+}
+
+type DrainJobProgress struct {
+	// Number of times drain script asked to check status again
+	Checks int `json:"checks"`
+
+	// Seconds that drain script asked to wait before next check
+	WaitSeconds int `json:"wait_seconds"`
+}
+
+// Progress returns nil when drain is not running
+func (a DrainAction) Progress() interface{} {
+	return a.progress.get()
+}
+
+type drainProgress struct {
+	lock sync.Mutex
+
+	running   bool
+	startedAt time.Time
+	jobs      map[string]DrainJobProgress
+}
+
+func (p *drainProgress) start() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.running = true
+	p.startedAt = time.Now()
+	p.jobs = map[string]DrainJobProgress{}
+}
+
+func (p *drainProgress) waiting(jobName string, waitSeconds int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	jobProgress := p.jobs[jobName]
+	jobProgress.Checks++
+	jobProgress.WaitSeconds = waitSeconds
+	p.jobs[jobName] = jobProgress
+}
+
+func (p *drainProgress) finish() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.running = false
+}
+
+func (p *drainProgress) get() interface{} {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if !p.running {
+		return nil
+	}
+
+	jobs := map[string]DrainJobProgress{}
+	for jobName, jobProgress := range p.jobs {
+		jobs[jobName] = jobProgress
+	}
+
+	return DrainProgress{
+		ElapsedSeconds: int(time.Since(p.startedAt).Seconds()),
+		Jobs:           jobs,
+	}
 }
@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -18,7 +19,7 @@ func init() {
 	Describe("DrainAction", func() {
 		var (
 			notifier            *fakenotif.FakeNotifier
-			specService         *fakeas.FakeV1Service
+			specService         *fakeas.FakeV2Service
 			drainScriptProvider *fakedrain.FakeDrainScriptProvider
 			jobSupervisor       *fakejobsuper.FakeJobSupervisor
 			action              DrainAction
@@ -26,16 +27,18 @@ func init() {
 
 		BeforeEach(func() {
 			notifier = fakenotif.NewFakeNotifier()
-			specService = fakeas.NewFakeV1Service()
+			specService = fakeas.NewFakeV2Service()
 			drainScriptProvider = fakedrain.NewFakeDrainScriptProvider()
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-			action = NewDrain(notifier, specService, drainScriptProvider, jobSupervisor)
+			action = NewDrain(notifier, specService, drainScriptProvider, jobSupervisor, 1*time.Minute)
 		})
 
 		BeforeEach(func() {
-			currentSpec := boshas.V1ApplySpec{}
-			currentSpec.JobSpec.Template = "foo"
-			specService.Spec = currentSpec
+			specService.Spec = boshas.V2ApplySpec{
+				JobSpecs: []boshas.V2JobSpec{
+					{Name: "foo", Sha1: "foo-job-sha1-old"},
+				},
+			}
 
 			drainScriptProvider.NewDrainScriptDrainScript.ExistsBool = true
 		})
@@ -49,7 +52,7 @@ func init() {
 		})
 
 		Context("when drain update is requested", func() {
-			act := func() (int, error) { return action.Run(DrainTypeUpdate, boshas.V1ApplySpec{}) }
+			act := func() (int, error) { return action.Run(DrainTypeUpdate, boshas.V2ApplySpec{}) }
 
 			Context("when current agent has a job spec template", func() {
 				It("unmonitors services so that drain scripts can kill processes on their own", func() {
@@ -70,11 +73,17 @@ func init() {
 					})
 
 					Context("when new apply spec is provided", func() {
-						newSpec := boshas.V1ApplySpec{
-							PackageSpecs: map[string]boshas.PackageSpec{
-								"foo": boshas.PackageSpec{
+						newSpec := boshas.V2ApplySpec{
+							JobSpecs: []boshas.V2JobSpec{
+								{
 									Name: "foo",
-									Sha1: "foo-sha1-new",
+									Sha1: "foo-job-sha1-new",
+									PackageSpecs: map[string]boshas.PackageSpec{
+										"foo": boshas.PackageSpec{
+											Name: "foo",
+											Sha1: "foo-sha1-new",
+										},
+									},
 								},
 							},
 						}
@@ -87,7 +96,7 @@ func init() {
 
 								Expect(drainScriptProvider.NewDrainScriptTemplateName).To(Equal("foo"))
 								Expect(drainScriptProvider.NewDrainScriptDrainScript.DidRun).To(BeTrue())
-								Expect(drainScriptProvider.NewDrainScriptDrainScript.RunParams.JobChange()).To(Equal("job_new"))
+								Expect(drainScriptProvider.NewDrainScriptDrainScript.RunParams.JobChange()).To(Equal("job_changed"))
 								Expect(drainScriptProvider.NewDrainScriptDrainScript.RunParams.HashChange()).To(Equal("hash_new"))
 								Expect(drainScriptProvider.NewDrainScriptDrainScript.RunParams.UpdatedPackages()).To(Equal([]string{"foo"}))
 							})
@@ -141,7 +150,7 @@ func init() {
 
 			Context("when current agent spec does not have a job spec template", func() {
 				It("returns 0 and does not run drain script", func() {
-					specService.Spec = boshas.V1ApplySpec{}
+					specService.Spec = boshas.V2ApplySpec{}
 
 					value, err := act()
 					Expect(err).ToNot(HaveOccurred())
@@ -238,7 +247,7 @@ func init() {
 
 			Context("when current agent spec does not have a job spec template", func() {
 				It("returns 0 and does not run drain script", func() {
-					specService.Spec = boshas.V1ApplySpec{}
+					specService.Spec = boshas.V2ApplySpec{}
 
 					value, err := act()
 					Expect(err).ToNot(HaveOccurred())
@@ -249,6 +258,166 @@ func init() {
 			})
 		})
 
+		Context("when drain script asks to check status again (dynamic drain)", func() {
+			var drainScript *fakedrain.FakeDrainScript
+
+			BeforeEach(func() {
+				drainScript = drainScriptProvider.NewDrainScriptDrainScript
+			})
+
+			It("waits requested number of seconds and re-runs drain script with job_check_status until it returns non-negative value", func() {
+				drainScript.RunExitStatuses = []int{-1, 5}
+
+				value, err := action.Run(DrainTypeShutdown)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal(5))
+
+				Expect(len(drainScript.RunParamsList)).To(Equal(2))
+				Expect(drainScript.RunParamsList[0].JobChange()).To(Equal("job_shutdown"))
+				Expect(drainScript.RunParamsList[1].JobChange()).To(Equal("job_check_status"))
+			})
+
+			It("returns error without waiting when drain would not finish before timeout", func() {
+				action = NewDrain(notifier, specService, drainScriptProvider, jobSupervisor, 10*time.Second)
+				drainScript.RunExitStatuses = []int{-60}
+
+				value, err := action.Run(DrainTypeShutdown)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Drain script did not finish within 10s"))
+				Expect(value).To(Equal(0))
+
+				Expect(len(drainScript.RunParamsList)).To(Equal(1))
+			})
+
+			It("stops waiting when canceled", func() {
+				drainScript.RunExitStatuses = []int{-30}
+
+				errCh := make(chan error)
+				go func() {
+					_, err := action.Run(DrainTypeShutdown)
+					errCh <- err
+				}()
+
+				Eventually(action.Progress).ShouldNot(BeNil())
+
+				err := action.Cancel()
+				Expect(err).ToNot(HaveOccurred())
+
+				err = <-errCh
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Drain was canceled"))
+				Expect(len(drainScript.RunParamsList)).To(Equal(1))
+			})
+
+			It("reports progress while waiting and no progress after finishing", func() {
+				drainScript.RunExitStatuses = []int{-30}
+
+				go action.Run(DrainTypeShutdown)
+
+				Eventually(action.Progress).Should(Equal(DrainProgress{
+					ElapsedSeconds: 0,
+					Jobs: map[string]DrainJobProgress{
+						"foo": DrainJobProgress{Checks: 1, WaitSeconds: 30},
+					},
+				}))
+
+				action.Cancel()
+
+				Eventually(action.Progress).Should(BeNil())
+			})
+		})
+
+		Context("when there are multiple colocated jobs", func() {
+			var (
+				fooScript *fakedrain.FakeDrainScript
+				barScript *fakedrain.FakeDrainScript
+				bazScript *fakedrain.FakeDrainScript
+			)
+
+			BeforeEach(func() {
+				specService.Spec = boshas.V2ApplySpec{
+					JobSpecs: []boshas.V2JobSpec{
+						{Name: "foo", Sha1: "foo-job-sha1"},
+						{Name: "bar", Sha1: "bar-job-sha1-old"},
+						{Name: "baz", Sha1: "baz-job-sha1"},
+					},
+				}
+
+				fooScript = fakedrain.NewFakeDrainScript()
+				fooScript.ExistsBool = true
+				fooScript.RunExitStatus = 10
+				drainScriptProvider.DrainScripts["foo"] = fooScript
+
+				barScript = fakedrain.NewFakeDrainScript()
+				barScript.ExistsBool = true
+				barScript.RunExitStatus = 20
+				drainScriptProvider.DrainScripts["bar"] = barScript
+
+				// Job without drain script
+				bazScript = fakedrain.NewFakeDrainScript()
+				bazScript.ExistsBool = false
+				drainScriptProvider.DrainScripts["baz"] = bazScript
+			})
+
+			It("runs drain scripts of all jobs that have them and returns the longest wait time", func() {
+				value, err := action.Run(DrainTypeShutdown)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal(20))
+
+				Expect(fooScript.DidRun).To(BeTrue())
+				Expect(barScript.DidRun).To(BeTrue())
+				Expect(bazScript.DidRun).To(BeFalse())
+			})
+
+			It("runs each drain script with params for its own job", func() {
+				newSpec := boshas.V2ApplySpec{
+					JobSpecs: []boshas.V2JobSpec{
+						{Name: "foo", Sha1: "foo-job-sha1"},
+						{Name: "bar", Sha1: "bar-job-sha1-new"},
+						{Name: "baz", Sha1: "baz-job-sha1"},
+					},
+				}
+
+				_, err := action.Run(DrainTypeUpdate, newSpec)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fooScript.RunParams.JobChange()).To(Equal("job_unchanged"))
+				Expect(barScript.RunParams.JobChange()).To(Equal("job_changed"))
+			})
+
+			It("waits for dynamic drain scripts of all jobs", func() {
+				fooScript.RunExitStatuses = []int{-1}
+				fooScript.RunExitStatus = 0
+
+				value, err := action.Run(DrainTypeShutdown)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(value).To(Equal(20))
+
+				Expect(len(fooScript.RunParamsList)).To(Equal(2))
+				Expect(len(barScript.RunParamsList)).To(Equal(1))
+			})
+
+			It("reports error of each failed drain script", func() {
+				fooScript.RunError = errors.New("fake-foo-drain-error")
+				barScript.RunError = errors.New("fake-bar-drain-error")
+
+				value, err := action.Run(DrainTypeShutdown)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("job bar: Running Drain Script: fake-bar-drain-error"))
+				Expect(err.Error()).To(ContainSubstring("job foo: Running Drain Script: fake-foo-drain-error"))
+				Expect(value).To(Equal(0))
+			})
+
+			It("returns error when one of drain scripts fails after other drain scripts finish", func() {
+				fooScript.RunError = errors.New("fake-foo-drain-error")
+
+				_, err := action.Run(DrainTypeShutdown)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).ToNot(ContainSubstring("job bar"))
+				Expect(barScript.DidRun).To(BeTrue())
+			})
+		})
+
 		Context("when drain status is requested", func() {
 			act := func() (int, error) { return action.Run(DrainTypeStatus) }
 
@@ -321,7 +490,7 @@ func init() {
 
 			Context("when current agent spec does not have a job spec template", func() {
 				It("returns error because drain status should only be called after starting draining", func() {
-					specService.Spec = boshas.V1ApplySpec{}
+					specService.Spec = boshas.V2ApplySpec{}
 
 					value, err := action.Run(DrainTypeStatus)
 					Expect(err).To(HaveOccurred())
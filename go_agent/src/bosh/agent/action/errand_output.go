@@ -0,0 +1,75 @@
+package action
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// Inline errand output must fit into a single NATS message (1MB)
+	// together with the rest of the response
+	errandOutputHeadBytes = 32 * 1024
+	errandOutputTailBytes = 32 * 1024
+)
+
+// errandOutput streams output into a file and only keeps
+// beginning and end of the output in memory
+type errandOutput struct {
+	file io.Writer
+
+	head  []byte
+	tail  []byte
+	total int
+}
+
+func newErrandOutput(file io.Writer) *errandOutput {
+	return &errandOutput{file: file}
+}
+
+func (o *errandOutput) Write(p []byte) (int, error) {
+	n, err := o.file.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	o.total += len(p)
+
+	if len(o.head) < errandOutputHeadBytes {
+		headLeft := errandOutputHeadBytes - len(o.head)
+		if headLeft > len(p) {
+			headLeft = len(p)
+		}
+		o.head = append(o.head, p[:headLeft]...)
+	}
+
+	o.tail = append(o.tail, p...)
+
+	// Trim occasionally instead of on every write
+	if len(o.tail) > 2*errandOutputTailBytes {
+		o.tail = append([]byte{}, o.tail[len(o.tail)-errandOutputTailBytes:]...)
+	}
+
+	return n, nil
+}
+
+// String returns whole output if it is small enough;
+// otherwise middle of the output is replaced with a note
+func (o *errandOutput) String() string {
+	if o.total <= len(o.head) {
+		return string(o.head)
+	}
+
+	tail := o.tail
+	if len(tail) > errandOutputTailBytes {
+		tail = tail[len(tail)-errandOutputTailBytes:]
+	}
+
+	// Tail overlaps with head
+	if o.total <= len(o.head)+len(tail) {
+		return string(o.head) + string(tail[len(o.head)+len(tail)-o.total:])
+	}
+
+	truncated := o.total - len(o.head) - len(tail)
+
+	return fmt.Sprintf("%s\n...truncated %d bytes...\n%s", o.head, truncated, tail)
+}
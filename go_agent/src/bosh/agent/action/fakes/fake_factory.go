@@ -53,6 +53,8 @@ type TestAction struct {
 
 	Canceled  bool
 	CancelErr error
+
+	ProgressValue interface{}
 }
 
 func (a *TestAction) IsAsynchronous() bool {
@@ -76,3 +78,7 @@ func (a *TestAction) Cancel() error {
 	a.Canceled = true
 	return a.CancelErr
 }
+
+func (a *TestAction) Progress() interface{} {
+	return a.ProgressValue
+}
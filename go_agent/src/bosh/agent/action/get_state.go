@@ -4,33 +4,43 @@ import (
 	"errors"
 
 	boshas "bosh/agent/applier/applyspec"
+	boshblob "bosh/blobstore"
 	bosherr "bosh/errors"
 	boshjobsuper "bosh/jobsupervisor"
+	boshlog "bosh/logger"
 	boshntp "bosh/platform/ntp"
 	boshvitals "bosh/platform/vitals"
 	boshsettings "bosh/settings"
 )
 
+const getStateLogTag = "GetStateAction"
+
 type GetStateAction struct {
 	settings      boshsettings.Service
-	specService   boshas.V1Service
+	specService   boshas.V2Service
 	jobSupervisor boshjobsuper.JobSupervisor
 	vitalsService boshvitals.Service
 	ntpService    boshntp.Service
+	blobCache     boshblob.Cache
+	logger        boshlog.Logger
 }
 
 func NewGetState(
 	settings boshsettings.Service,
-	specService boshas.V1Service,
+	specService boshas.V2Service,
 	jobSupervisor boshjobsuper.JobSupervisor,
 	vitalsService boshvitals.Service,
 	ntpService boshntp.Service,
+	blobCache boshblob.Cache,
+	logger boshlog.Logger,
 ) (action GetStateAction) {
 	action.settings = settings
 	action.specService = specService
 	action.jobSupervisor = jobSupervisor
 	action.vitalsService = vitalsService
 	action.ntpService = ntpService
+	action.blobCache = blobCache
+	action.logger = logger
 	return
 }
 
@@ -42,8 +52,8 @@ func (a GetStateAction) IsPersistent() bool {
 	return false
 }
 
-type GetStateV1ApplySpec struct {
-	boshas.V1ApplySpec
+type GetStateV2ApplySpec struct {
+	boshas.V2ApplySpec
 
 	AgentID      string             `json:"agent_id"`
 	BoshProtocol string             `json:"bosh_protocol"`
@@ -51,26 +61,43 @@ type GetStateV1ApplySpec struct {
 	Vitals       *boshvitals.Vitals `json:"vitals,omitempty"`
 	VM           boshsettings.VM    `json:"vm"`
 	Ntp          boshntp.NTPInfo    `json:"ntp"`
+
+	BlobCache *boshblob.CacheStats `json:"blob_cache,omitempty"`
+
+	Processes []boshjobsuper.Process `json:"processes,omitempty"`
 }
 
-func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
+func (a GetStateAction) Run(filters ...string) (GetStateV2ApplySpec, error) {
 	spec, err := a.specService.Get()
 	if err != nil {
-		return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Getting current spec")
+		return GetStateV2ApplySpec{}, bosherr.WrapError(err, "Getting current spec")
 	}
 
 	var vitals boshvitals.Vitals
 	var vitalsReference *boshvitals.Vitals
+	var blobCacheStatsReference *boshblob.CacheStats
+	var processes []boshjobsuper.Process
 
 	if len(filters) > 0 && filters[0] == "full" {
 		vitals, err = a.vitalsService.Get()
 		if err != nil {
-			return GetStateV1ApplySpec{}, bosherr.WrapError(err, "Building full vitals")
+			return GetStateV2ApplySpec{}, bosherr.WrapError(err, "Building full vitals")
 		}
 		vitalsReference = &vitals
+
+		blobCacheStats := a.blobCache.Stats()
+		blobCacheStatsReference = &blobCacheStats
+
+		// Like heartbeats, state is still reported when
+		// job supervisor is not reachable (e.g. monit is restarting)
+		processes, err = a.jobSupervisor.Processes()
+		if err != nil {
+			a.logger.Error(getStateLogTag, "Failed to get processes: %s", err.Error())
+			processes = nil
+		}
 	}
 
-	value := GetStateV1ApplySpec{
+	value := GetStateV2ApplySpec{
 		spec,
 		a.settings.GetAgentID(),
 		"1",
@@ -78,6 +105,8 @@ func (a GetStateAction) Run(filters ...string) (GetStateV1ApplySpec, error) {
 		vitalsReference,
 		a.settings.GetVM(),
 		a.ntpService.GetInfo(),
+		blobCacheStatsReference,
+		processes,
 	}
 
 	return value, nil
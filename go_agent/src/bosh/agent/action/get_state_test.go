@@ -10,7 +10,11 @@ import (
 	boshas "bosh/agent/applier/applyspec"
 	fakeas "bosh/agent/applier/applyspec/fakes"
 	boshassert "bosh/assert"
+	boshblob "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshjobsuper "bosh/jobsupervisor"
 	fakejobsuper "bosh/jobsupervisor/fakes"
+	boshlog "bosh/logger"
 	boshntp "bosh/platform/ntp"
 	fakentp "bosh/platform/ntp/fakes"
 	boshvitals "bosh/platform/vitals"
@@ -20,13 +24,13 @@ import (
 )
 
 func buildGetStateAction(settings boshsettings.Service) (
-	specService *fakeas.FakeV1Service,
+	specService *fakeas.FakeV2Service,
 	jobSupervisor *fakejobsuper.FakeJobSupervisor,
 	vitalsService *fakevitals.FakeService,
 	action GetStateAction,
 ) {
 	jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-	specService = fakeas.NewFakeV1Service()
+	specService = fakeas.NewFakeV2Service()
 	vitalsService = fakevitals.NewFakeService()
 	fakeNTPService := &fakentp.FakeService{
 		GetOffsetNTPOffset: boshntp.NTPInfo{
@@ -34,7 +38,9 @@ func buildGetStateAction(settings boshsettings.Service) (
 			Timestamp: "12 Oct 17:37:58",
 		},
 	}
-	action = NewGetState(settings, specService, jobSupervisor, vitalsService, fakeNTPService)
+	blobCache := fakeblob.NewFakeCache()
+	blobCache.StatsStats = boshblob.CacheStats{Hits: 1, Misses: 2, Size: 3, MaxSize: 4}
+	action = NewGetState(settings, specService, jobSupervisor, vitalsService, fakeNTPService, blobCache, boshlog.NewLogger(boshlog.LevelNone))
 	return
 }
 func init() {
@@ -60,11 +66,11 @@ func init() {
 				specService, jobSupervisor, _, action := buildGetStateAction(settings)
 				jobSupervisor.StatusStatus = "running"
 
-				specService.Spec = boshas.V1ApplySpec{
-					Deployment: "fake-deployment",
+				specService.Spec = boshas.V2ApplySpec{
+					V1ApplySpec: boshas.V1ApplySpec{Deployment: "fake-deployment"},
 				}
 
-				expectedSpec := GetStateV1ApplySpec{
+				expectedSpec := GetStateV2ApplySpec{
 					AgentID:      "my-agent-id",
 					JobState:     "running",
 					BoshProtocol: "1",
@@ -83,6 +89,8 @@ func init() {
 				Expect(state.JobState).To(Equal(expectedSpec.JobState))
 				Expect(state.Deployment).To(Equal(expectedSpec.Deployment))
 				boshassert.LacksJSONKey(GinkgoT(), state, "vitals")
+				boshassert.LacksJSONKey(GinkgoT(), state, "blob_cache")
+				boshassert.LacksJSONKey(GinkgoT(), state, "processes")
 
 				Expect(state).To(Equal(expectedSpec))
 			})
@@ -95,8 +103,8 @@ func init() {
 				specService, jobSupervisor, fakeVitals, action := buildGetStateAction(settings)
 				jobSupervisor.StatusStatus = "running"
 
-				specService.Spec = boshas.V1ApplySpec{
-					Deployment: "fake-deployment",
+				specService.Spec = boshas.V2ApplySpec{
+					V1ApplySpec: boshas.V1ApplySpec{Deployment: "fake-deployment"},
 				}
 
 				expectedVitals := boshvitals.Vitals{
@@ -105,6 +113,11 @@ func init() {
 				fakeVitals.GetVitals = expectedVitals
 				expectedVM := map[string]interface{}{"name": "vm-abc-def"}
 
+				expectedProcesses := []boshjobsuper.Process{
+					{Name: "fake-process", State: "running", Pid: 1234},
+				}
+				jobSupervisor.ProcessesProcesses = expectedProcesses
+
 				state, err := action.Run("full")
 				Expect(err).ToNot(HaveOccurred())
 
@@ -113,6 +126,8 @@ func init() {
 				boshassert.MatchesJSONString(GinkgoT(), state.Deployment, `"fake-deployment"`)
 				Expect(*state.Vitals).To(Equal(expectedVitals))
 				boshassert.MatchesJSONMap(GinkgoT(), state.VM, expectedVM)
+				Expect(*state.BlobCache).To(Equal(boshblob.CacheStats{Hits: 1, Misses: 2, Size: 3, MaxSize: 4}))
+				Expect(state.Processes).To(Equal(expectedProcesses))
 			})
 
 			Context("when current cannot be retrieved", func() {
@@ -142,6 +157,23 @@ func init() {
 				})
 			})
 
+			Context("when processes cannot be retrieved", func() {
+				It("returns state without processes", func() {
+					settings := &fakesettings.FakeSettingsService{}
+					_, jobSupervisor, _, action := buildGetStateAction(settings)
+
+					jobSupervisor.StatusStatus = "unknown"
+					jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{{Name: "fake-process"}}
+					jobSupervisor.ProcessesErr = errors.New("fake-processes-error")
+
+					state, err := action.Run("full")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(state.JobState).To(Equal("unknown"))
+					Expect(state.Vitals).ToNot(BeNil())
+					Expect(state.Processes).To(BeNil())
+				})
+			})
+
 		})
 
 	})
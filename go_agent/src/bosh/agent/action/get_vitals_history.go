@@ -0,0 +1,41 @@
+package action
+
+import (
+	"errors"
+
+	boshvitals "bosh/platform/vitals"
+)
+
+type GetVitalsHistoryAction struct {
+	vitalsHistory boshvitals.History
+}
+
+func NewGetVitalsHistory(vitalsHistory boshvitals.History) (action GetVitalsHistoryAction) {
+	action.vitalsHistory = vitalsHistory
+	return
+}
+
+func (a GetVitalsHistoryAction) IsAsynchronous() bool {
+	return false
+}
+
+func (a GetVitalsHistoryAction) IsPersistent() bool {
+	return false
+}
+
+func (a GetVitalsHistoryAction) Run(queries ...boshvitals.HistoryQuery) (boshvitals.HistoryResult, error) {
+	var query boshvitals.HistoryQuery
+	if len(queries) > 0 {
+		query = queries[0]
+	}
+
+	return a.vitalsHistory.Query(query), nil
+}
+
+func (a GetVitalsHistoryAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a GetVitalsHistoryAction) Cancel() error {
+	return errors.New("not supported")
+}
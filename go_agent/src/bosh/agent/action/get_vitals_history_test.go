@@ -0,0 +1,64 @@
+package action_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshvitals "bosh/platform/vitals"
+	fakevitals "bosh/platform/vitals/fakes"
+)
+
+var _ = Describe("GetVitalsHistoryAction", func() {
+	var (
+		vitalsHistory *fakevitals.FakeHistory
+		action        GetVitalsHistoryAction
+	)
+
+	BeforeEach(func() {
+		vitalsHistory = fakevitals.NewFakeHistory()
+		action = NewGetVitalsHistory(vitalsHistory)
+	})
+
+	It("is synchronous", func() {
+		Expect(action.IsAsynchronous()).To(BeFalse())
+	})
+
+	It("is not persistent", func() {
+		Expect(action.IsPersistent()).To(BeFalse())
+	})
+
+	Describe("Run", func() {
+		BeforeEach(func() {
+			vitalsHistory.QueryResult = boshvitals.HistoryResult{
+				From: 1000,
+				To:   1010,
+				Step: 10,
+				Series: map[string][]boshvitals.HistoryPoint{
+					"mem.percent": []boshvitals.HistoryPoint{{Time: 1000, Value: 10}},
+				},
+			}
+		})
+
+		It("returns history for requested query", func() {
+			query := boshvitals.HistoryQuery{
+				From:      1000,
+				To:        1010,
+				Metrics:   []string{"mem.percent"},
+				MaxPoints: 10,
+			}
+
+			result, err := action.Run(query)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(vitalsHistory.QueryResult))
+			Expect(vitalsHistory.QueryQuery).To(Equal(query))
+		})
+
+		It("returns whole history when query is not given", func() {
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(vitalsHistory.QueryResult))
+			Expect(vitalsHistory.QueryQuery).To(Equal(boshvitals.HistoryQuery{}))
+		})
+	})
+})
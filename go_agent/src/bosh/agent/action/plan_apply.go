@@ -0,0 +1,81 @@
+package action
+
+import (
+	"errors"
+
+	boshappl "bosh/agent/applier"
+	boshas "bosh/agent/applier/applyspec"
+	boshdrain "bosh/agent/drain"
+	bosherr "bosh/errors"
+)
+
+type PlanApplyAction struct {
+	planner     boshappl.Planner
+	specService boshas.V2Service
+}
+
+func NewPlanApply(planner boshappl.Planner, specService boshas.V2Service) (action PlanApplyAction) {
+	action.planner = planner
+	action.specService = specService
+	return
+}
+
+func (a PlanApplyAction) IsAsynchronous() bool {
+	return false
+}
+
+func (a PlanApplyAction) IsPersistent() bool {
+	return false
+}
+
+type PlanApplyResult struct {
+	boshappl.Plan
+
+	// Drain is keyed by job name since colocated jobs might change independently
+	Drain map[string]PlanApplyDrain `json:"drain"`
+}
+
+// PlanApplyDrain contains arguments drain script of a job would receive
+// when updating to desired spec.
+type PlanApplyDrain struct {
+	JobChange       string   `json:"job_change"`
+	HashChange      string   `json:"hash_change"`
+	UpdatedPackages []string `json:"updated_packages"`
+}
+
+func (a PlanApplyAction) Run(desiredSpec boshas.V2ApplySpec) (PlanApplyResult, error) {
+	var result PlanApplyResult
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return result, bosherr.WrapError(err, "Getting current spec")
+	}
+
+	result.Plan, err = a.planner.Plan(currentSpec, desiredSpec)
+	if err != nil {
+		return result, bosherr.WrapError(err, "Planning apply")
+	}
+
+	result.Drain = map[string]PlanApplyDrain{}
+
+	// Drain scripts that would run are the ones of currently running jobs
+	for _, jobName := range currentSpec.JobNames() {
+		params := boshdrain.NewUpdateDrainParams(currentSpec, desiredSpec, jobName)
+
+		result.Drain[jobName] = PlanApplyDrain{
+			JobChange:       params.JobChange(),
+			HashChange:      params.HashChange(),
+			UpdatedPackages: append([]string{}, params.UpdatedPackages()...),
+		}
+	}
+
+	return result, nil
+}
+
+func (a PlanApplyAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a PlanApplyAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,142 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshappl "bosh/agent/applier"
+	boshas "bosh/agent/applier/applyspec"
+	fakeas "bosh/agent/applier/applyspec/fakes"
+	fakeappl "bosh/agent/applier/fakes"
+)
+
+var _ = Describe("PlanApplyAction", func() {
+	var (
+		planner     *fakeappl.FakePlanner
+		specService *fakeas.FakeV2Service
+		action      PlanApplyAction
+	)
+
+	BeforeEach(func() {
+		planner = fakeappl.NewFakePlanner()
+		specService = fakeas.NewFakeV2Service()
+		action = NewPlanApply(planner, specService)
+	})
+
+	It("is synchronous", func() {
+		Expect(action.IsAsynchronous()).To(BeFalse())
+	})
+
+	It("is not persistent", func() {
+		Expect(action.IsPersistent()).To(BeFalse())
+	})
+
+	Describe("Run", func() {
+		var (
+			currentSpec boshas.V2ApplySpec
+			desiredSpec boshas.V2ApplySpec
+		)
+
+		BeforeEach(func() {
+			currentSpec = boshas.V1ApplySpec{
+				JobSpec: boshas.JobSpec{
+					Sha1: "fake-current-job-sha1",
+					JobTemplateSpecs: []boshas.JobTemplateSpec{
+						{Name: "fake-job"},
+					},
+				},
+				PackageSpecs: map[string]boshas.PackageSpec{
+					"fake-pkg-1": {Name: "fake-pkg-1", Sha1: "fake-sha1"},
+				},
+				ConfigurationHash: "fake-current-hash",
+			}.ToV2()
+
+			desiredSpec = boshas.V1ApplySpec{
+				JobSpec: boshas.JobSpec{
+					Sha1: "fake-desired-job-sha1",
+					JobTemplateSpecs: []boshas.JobTemplateSpec{
+						{Name: "fake-job"},
+					},
+				},
+				PackageSpecs: map[string]boshas.PackageSpec{
+					"fake-pkg-1": {Name: "fake-pkg-1", Sha1: "fake-sha1"},
+					"fake-pkg-3": {Name: "fake-pkg-3", Sha1: "fake-sha1"},
+					"fake-pkg-2": {Name: "fake-pkg-2", Sha1: "fake-sha1"},
+				},
+				ConfigurationHash: "fake-current-hash",
+			}.ToV2()
+
+			specService.Spec = currentSpec
+		})
+
+		It("returns plan for current and desired specs", func() {
+			plan := boshappl.Plan{
+				Jobs: boshappl.BundlePlan{
+					Install: []boshappl.PlannedBundle{{Name: "fake-job"}},
+				},
+			}
+			planner.PlanPlan = plan
+
+			result, err := action.Run(desiredSpec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Plan).To(Equal(plan))
+
+			Expect(planner.PlanCurrentApplySpec).To(Equal(currentSpec))
+			Expect(planner.PlanDesiredApplySpec).To(Equal(desiredSpec))
+		})
+
+		It("returns arguments that drain scripts would receive", func() {
+			result, err := action.Run(desiredSpec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Drain).To(Equal(map[string]PlanApplyDrain{
+				"fake-job": PlanApplyDrain{
+					JobChange:       "job_changed",
+					HashChange:      "hash_unchanged",
+					UpdatedPackages: []string{"fake-pkg-2", "fake-pkg-3"},
+				},
+			}))
+		})
+
+		It("returns drain arguments only for currently running jobs", func() {
+			desiredSpec.JobSpecs = append(desiredSpec.JobSpecs, boshas.V2JobSpec{Name: "fake-new-job"})
+
+			result, err := action.Run(desiredSpec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Drain).To(HaveLen(1))
+			Expect(result.Drain["fake-job"].JobChange).To(Equal("job_changed"))
+		})
+
+		It("returns no drain arguments when no jobs are running", func() {
+			specService.Spec = boshas.V1ApplySpec{}.ToV2()
+
+			result, err := action.Run(desiredSpec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result.Drain).To(BeEmpty())
+		})
+
+		It("does not change current spec", func() {
+			_, err := action.Run(desiredSpec)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(specService.Spec).To(Equal(currentSpec))
+		})
+
+		It("returns error when getting current spec fails", func() {
+			specService.GetErr = errors.New("fake-get-error")
+
+			_, err := action.Run(desiredSpec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		})
+
+		It("returns error when planning fails", func() {
+			planner.PlanErr = errors.New("fake-plan-error")
+
+			_, err := action.Run(desiredSpec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-plan-error"))
+		})
+	})
+})
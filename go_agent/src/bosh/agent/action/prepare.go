@@ -25,7 +25,7 @@ func (a PrepareAction) IsPersistent() bool {
 	return false
 }
 
-func (a PrepareAction) Run(desiredSpec boshas.V1ApplySpec) (string, error) {
+func (a PrepareAction) Run(desiredSpec boshas.V2ApplySpec) (string, error) {
 	err := a.applier.Prepare(desiredSpec)
 	if err != nil {
 		return "", bosherr.WrapError(err, "Preparing apply spec")
@@ -31,7 +31,7 @@ var _ = Describe("PrepareAction", func() {
 	})
 
 	Describe("Run", func() {
-		desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}
+		desiredApplySpec := boshas.V1ApplySpec{ConfigurationHash: "fake-desired-config-hash"}.ToV2()
 
 		It("runs applier to prepare vm for future configuration with desired apply spec", func() {
 			_, err := action.Run(desiredApplySpec)
@@ -0,0 +1,143 @@
+package action
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	boshas "bosh/agent/applier/applyspec"
+	bosherr "bosh/errors"
+	boshjobsuper "bosh/jobsupervisor"
+	boshsys "bosh/system"
+)
+
+// DefaultProcessTimeout is how long process actions wait for
+// job supervisor to report that processes reached their target state
+const DefaultProcessTimeout = 5 * time.Minute
+
+// processManager starts and stops individual processes
+// for start_process, stop_process and restart_process actions
+type processManager struct {
+	jobSupervisor boshjobsuper.JobSupervisor
+	specService   boshas.V2Service
+	fs            boshsys.FileSystem
+
+	// Processes are declared by jobs in <jobsDir>/<job>
+	jobsDir string
+
+	timeout       time.Duration
+	checkInterval time.Duration
+}
+
+func newProcessManager(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V2Service,
+	fs boshsys.FileSystem,
+	jobsDir string,
+	timeout time.Duration,
+) processManager {
+	return processManager{
+		jobSupervisor: jobSupervisor,
+		specService:   specService,
+		fs:            fs,
+		jobsDir:       jobsDir,
+		timeout:       timeout,
+		checkInterval: 1 * time.Second,
+	}
+}
+
+// validate makes sure that processes belong to jobs from current spec
+func (m processManager) validate(names []string) error {
+	if len(names) == 0 {
+		return bosherr.New("At least one process name is required")
+	}
+
+	currentSpec, err := m.specService.Get()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting current spec")
+	}
+
+	jobNames := currentSpec.JobNames()
+	if len(jobNames) == 0 {
+		return bosherr.New("Current spec does not have jobs")
+	}
+
+	// Job supervisor might still know about processes
+	// of jobs that are no longer part of current spec
+	knownNames := map[string]bool{}
+	for _, jobName := range jobNames {
+		processNames, err := boshjobsuper.JobProcessNames(m.fs, filepath.Join(m.jobsDir, jobName))
+		if err != nil {
+			return bosherr.WrapError(err, "Listing processes of job %s", jobName)
+		}
+
+		for _, name := range processNames {
+			knownNames[name] = true
+		}
+	}
+
+	for _, name := range names {
+		if !knownNames[name] {
+			return bosherr.New("Process %s is not part of current spec", name)
+		}
+	}
+
+	return nil
+}
+
+func (m processManager) start(names []string) error {
+	for _, name := range names {
+		err := m.jobSupervisor.StartProcess(name)
+		if err != nil {
+			return bosherr.WrapError(err, "Starting process %s", name)
+		}
+	}
+
+	return m.waitFor(names, "running")
+}
+
+func (m processManager) stop(names []string) error {
+	for _, name := range names {
+		err := m.jobSupervisor.StopProcess(name)
+		if err != nil {
+			return bosherr.WrapError(err, "Stopping process %s", name)
+		}
+	}
+
+	return m.waitFor(names, "stopped")
+}
+
+func (m processManager) waitFor(names []string, targetState string) error {
+	deadline := time.Now().Add(m.timeout)
+
+	for {
+		processes, err := m.jobSupervisor.Processes()
+		if err != nil {
+			return bosherr.WrapError(err, "Listing processes")
+		}
+
+		states := map[string]string{}
+		for _, process := range processes {
+			states[process.Name] = process.State
+		}
+
+		var pending []string
+
+		for _, name := range names {
+			if states[name] != targetState {
+				pending = append(pending, fmt.Sprintf("%s (%s)", name, states[name]))
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return bosherr.New("Processes are not %s after %s: %s", targetState, m.timeout, strings.Join(pending, ", "))
+		}
+
+		time.Sleep(m.checkInterval)
+	}
+}
@@ -0,0 +1,61 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	boshas "bosh/agent/applier/applyspec"
+	bosherr "bosh/errors"
+	boshjobsuper "bosh/jobsupervisor"
+	boshsys "bosh/system"
+)
+
+type RestartProcessAction struct {
+	processManager processManager
+}
+
+func NewRestartProcess(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V2Service,
+	fs boshsys.FileSystem,
+	jobsDir string,
+	timeout time.Duration,
+) (action RestartProcessAction) {
+	action.processManager = newProcessManager(jobSupervisor, specService, fs, jobsDir, timeout)
+	return
+}
+
+func (a RestartProcessAction) IsAsynchronous() bool {
+	return true
+}
+
+func (a RestartProcessAction) IsPersistent() bool {
+	return false
+}
+
+func (a RestartProcessAction) Run(names ...string) (string, error) {
+	err := a.processManager.validate(names)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Validating processes")
+	}
+
+	err = a.processManager.stop(names)
+	if err != nil {
+		return "", err
+	}
+
+	err = a.processManager.start(names)
+	if err != nil {
+		return "", err
+	}
+
+	return "restarted", nil
+}
+
+func (a RestartProcessAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a RestartProcessAction) Cancel() error {
+	return errors.New("not supported")
+}
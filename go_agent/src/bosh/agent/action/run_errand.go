@@ -6,27 +6,50 @@ import (
 	"time"
 
 	boshas "bosh/agent/applier/applyspec"
+	boshblob "bosh/blobstore"
 	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshcmd "bosh/platform/commands"
 	boshsys "bosh/system"
 )
 
+const runErrandLogTag = "RunErrandAction"
+
 type RunErrandAction struct {
-	specService boshas.V1Service
+	specService boshas.V2Service
 	jobsDir     string
-	cmdRunner   boshsys.CmdRunner
+
+	// Errand output is kept in <errandsDir>/<job> until errand runs again
+	errandsDir string
+
+	cmdRunner  boshsys.CmdRunner
+	fs         boshsys.FileSystem
+	compressor boshcmd.Compressor
+	blobstore  boshblob.Blobstore
+	logger     boshlog.Logger
 
 	cancelCh chan struct{}
 }
 
 func NewRunErrand(
-	specService boshas.V1Service,
+	specService boshas.V2Service,
 	jobsDir string,
+	errandsDir string,
 	cmdRunner boshsys.CmdRunner,
+	fs boshsys.FileSystem,
+	compressor boshcmd.Compressor,
+	blobstore boshblob.Blobstore,
+	logger boshlog.Logger,
 ) RunErrandAction {
 	return RunErrandAction{
 		specService: specService,
 		jobsDir:     jobsDir,
+		errandsDir:  errandsDir,
 		cmdRunner:   cmdRunner,
+		fs:          fs,
+		compressor:  compressor,
+		blobstore:   blobstore,
+		logger:      logger,
 
 		// Initialize channel in a constructor to avoid race
 		// between initializing in Run()/Cancel()
@@ -42,27 +65,91 @@ func (a RunErrandAction) IsPersistent() bool {
 	return false
 }
 
+// ErrandResult includes beginning and end of long output;
+// full output can be downloaded from the blobstore as a tarball.
 type ErrandResult struct {
 	Stdout     string `json:"stdout"`
 	Stderr     string `json:"stderr"`
 	ExitStatus int    `json:"exit_code"`
+
+	// Empty if output could not be uploaded
+	LogsBlobstoreID string `json:"logs_blobstore_id"`
+}
+
+type RunErrandOptions struct {
+	// Name of a colocated job whose bin/run is executed;
+	// first job is used when name is not specified
+	Name string `json:"name"`
+
+	Args []string `json:"args"`
+
+	// Added to (or overrides) default errand environment
+	Env map[string]string `json:"env"`
 }
 
-func (a RunErrandAction) Run() (ErrandResult, error) {
+func (a RunErrandAction) Run(options ...RunErrandOptions) (ErrandResult, error) {
+	var opts RunErrandOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
 	currentSpec, err := a.specService.Get()
 	if err != nil {
 		return ErrandResult{}, bosherr.WrapError(err, "Getting current spec")
 	}
 
-	if len(currentSpec.JobSpec.Template) == 0 {
+	jobNames := currentSpec.JobNames()
+	if len(jobNames) == 0 {
 		return ErrandResult{}, bosherr.New("At least one job template is required to run an errand")
 	}
 
+	jobName := jobNames[0]
+
+	if opts.Name != "" {
+		_, found := currentSpec.JobSpecByName(opts.Name)
+		if !found {
+			return ErrandResult{}, bosherr.New("Errand job %s is not in current spec", opts.Name)
+		}
+
+		jobName = opts.Name
+	}
+
+	outputDir := filepath.Join(a.errandsDir, jobName)
+
+	err = a.fs.RemoveAll(outputDir)
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Removing previous errand output")
+	}
+
+	stdoutFile, err := a.fs.CreateFile(filepath.Join(outputDir, "stdout.log"))
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Creating errand stdout file")
+	}
+
+	defer stdoutFile.Close()
+
+	stderrFile, err := a.fs.CreateFile(filepath.Join(outputDir, "stderr.log"))
+	if err != nil {
+		return ErrandResult{}, bosherr.WrapError(err, "Creating errand stderr file")
+	}
+
+	defer stderrFile.Close()
+
+	stdout := newErrandOutput(stdoutFile)
+	stderr := newErrandOutput(stderrFile)
+
 	command := boshsys.Command{
-		Name: filepath.Join(a.jobsDir, currentSpec.JobSpec.Template, "bin", "run"),
+		Name: filepath.Join(a.jobsDir, jobName, "bin", "run"),
+		Args: opts.Args,
 		Env: map[string]string{
 			"PATH": "/usr/sbin:/usr/bin:/sbin:/bin",
 		},
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+
+	for name, value := range opts.Env {
+		command.Env[name] = value
 	}
 
 	process, err := a.cmdRunner.RunComplexCommandAsync(command)
@@ -88,12 +175,33 @@ func (a RunErrandAction) Run() (ErrandResult, error) {
 	}
 
 	return ErrandResult{
-		Stdout:     result.Stdout,
-		Stderr:     result.Stderr,
-		ExitStatus: result.ExitStatus,
+		Stdout:          stdout.String(),
+		Stderr:          stderr.String(),
+		ExitStatus:      result.ExitStatus,
+		LogsBlobstoreID: a.uploadOutput(outputDir),
 	}, nil
 }
 
+// uploadOutput returns empty blob ID when output cannot be uploaded
+// so that failing to upload it does not hide errand result
+func (a RunErrandAction) uploadOutput(outputDir string) string {
+	tarball, err := a.compressor.CompressFilesInDir(outputDir)
+	if err != nil {
+		a.logger.Error(runErrandLogTag, "Failed to compress errand output: %s", err.Error())
+		return ""
+	}
+
+	defer a.fs.RemoveAll(tarball)
+
+	blobID, _, err := a.blobstore.Create(tarball)
+	if err != nil {
+		a.logger.Error(runErrandLogTag, "Failed to upload errand output: %s", err.Error())
+		return ""
+	}
+
+	return blobID
+}
+
 func (a RunErrandAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -10,21 +11,34 @@ import (
 	. "bosh/agent/action"
 	boshas "bosh/agent/applier/applyspec"
 	fakeas "bosh/agent/applier/applyspec/fakes"
+	fakeblobstore "bosh/blobstore/fakes"
+	boshlog "bosh/logger"
+	fakecmd "bosh/platform/commands/fakes"
 	boshsys "bosh/system"
 	fakesys "bosh/system/fakes"
 )
 
 var _ = Describe("RunErrand", func() {
 	var (
-		specService *fakeas.FakeV1Service
+		specService *fakeas.FakeV2Service
 		cmdRunner   *fakesys.FakeCmdRunner
+		fs          *fakesys.FakeFileSystem
+		compressor  *fakecmd.FakeCompressor
+		blobstore   *fakeblobstore.FakeBlobstore
 		action      RunErrandAction
 	)
 
 	BeforeEach(func() {
-		specService = fakeas.NewFakeV1Service()
+		specService = fakeas.NewFakeV2Service()
 		cmdRunner = fakesys.NewFakeCmdRunner()
-		action = NewRunErrand(specService, "/fake-jobs-dir", cmdRunner)
+		fs = fakesys.NewFakeFileSystem()
+		compressor = fakecmd.NewFakeCompressor()
+		blobstore = &fakeblobstore.FakeBlobstore{}
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		action = NewRunErrand(specService, "/fake-jobs-dir", "/fake-errands-dir", cmdRunner, fs, compressor, blobstore, logger)
+
+		compressor.CompressFilesInDirTarballPath = "/fake-errand-output.tgz"
+		blobstore.CreateBlobID = "fake-logs-blob-id"
 	})
 
 	It("is asynchronous", func() {
@@ -39,9 +53,9 @@ var _ = Describe("RunErrand", func() {
 		Context("when apply spec is successfully retrieved", func() {
 			Context("when current agent has a job spec template", func() {
 				BeforeEach(func() {
-					currentSpec := boshas.V1ApplySpec{}
-					currentSpec.JobSpec.Template = "fake-job-name"
-					specService.Spec = currentSpec
+					specService.Spec = boshas.V2ApplySpec{
+						JobSpecs: []boshas.V2JobSpec{{Name: "fake-job-name"}},
+					}
 				})
 
 				Context("when errand script exits with non-0 exit code (execution of script is ok)", func() {
@@ -63,6 +77,8 @@ var _ = Describe("RunErrand", func() {
 								Stdout:     "fake-stdout",
 								Stderr:     "fake-stderr",
 								ExitStatus: 0,
+
+								LogsBlobstoreID: "fake-logs-blob-id",
 							},
 						))
 					})
@@ -70,15 +86,92 @@ var _ = Describe("RunErrand", func() {
 					It("runs errand script with properly configured environment", func() {
 						_, err := action.Run()
 						Expect(err).ToNot(HaveOccurred())
-						Expect(cmdRunner.RunComplexCommands).To(Equal([]boshsys.Command{
-							boshsys.Command{
-								Name: "/fake-jobs-dir/fake-job-name/bin/run",
-								Env: map[string]string{
-									"PATH": "/usr/sbin:/usr/bin:/sbin:/bin",
-								},
-							},
+						Expect(len(cmdRunner.RunComplexCommands)).To(Equal(1))
+
+						cmd := cmdRunner.RunComplexCommands[0]
+						Expect(cmd.Name).To(Equal("/fake-jobs-dir/fake-job-name/bin/run"))
+						Expect(cmd.Env).To(Equal(map[string]string{
+							"PATH": "/usr/sbin:/usr/bin:/sbin:/bin",
 						}))
 					})
+
+					It("streams errand output to files in errand dir", func() {
+						_, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+
+						stdout := fs.GetFileTestStat("/fake-errands-dir/fake-job-name/stdout.log")
+						Expect(stdout.StringContents()).To(Equal("fake-stdout"))
+
+						stderr := fs.GetFileTestStat("/fake-errands-dir/fake-job-name/stderr.log")
+						Expect(stderr.StringContents()).To(Equal("fake-stderr"))
+					})
+
+					It("uploads tarball of errand output and removes tarball afterwards", func() {
+						fs.WriteFileString("/fake-errand-output.tgz", "fake-tarball")
+
+						_, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(compressor.CompressFilesInDirDir).To(Equal("/fake-errands-dir/fake-job-name"))
+						Expect(blobstore.CreateFileName).To(Equal("/fake-errand-output.tgz"))
+						Expect(fs.FileExists("/fake-errand-output.tgz")).To(BeFalse())
+					})
+
+					It("returns errand result without logs blob id when uploading output fails", func() {
+						blobstore.CreateErr = errors.New("fake-create-error")
+
+						result, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(result.ExitStatus).To(Equal(0))
+						Expect(result.LogsBlobstoreID).To(BeEmpty())
+					})
+
+					It("removes output of previous errand run", func() {
+						fs.WriteFileString("/fake-errands-dir/fake-job-name/old.log", "fake-old-output")
+
+						_, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+						Expect(fs.FileExists("/fake-errands-dir/fake-job-name/old.log")).To(BeFalse())
+					})
+
+					It("returns error when errand output file cannot be created", func() {
+						fs.CreateFileError = errors.New("fake-create-file-error")
+
+						_, err := action.Run()
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-create-file-error"))
+					})
+				})
+
+				Context("when errand output is too long to be included in the result", func() {
+					var longStdout string
+
+					BeforeEach(func() {
+						longStdout = "fake-head" + strings.Repeat("x", 100*1024) + "fake-tail"
+
+						cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{
+							WaitResult: boshsys.Result{Stdout: longStdout, Stderr: "fake-stderr"},
+						})
+					})
+
+					It("returns only beginning and end of the output", func() {
+						result, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(len(result.Stdout)).To(BeNumerically("<", 70*1024))
+						Expect(strings.HasPrefix(result.Stdout, "fake-head")).To(BeTrue())
+						Expect(strings.HasSuffix(result.Stdout, "fake-tail")).To(BeTrue())
+						Expect(result.Stdout).To(ContainSubstring("...truncated"))
+						Expect(result.Stderr).To(Equal("fake-stderr"))
+					})
+
+					It("keeps full output in errand output file", func() {
+						_, err := action.Run()
+						Expect(err).ToNot(HaveOccurred())
+
+						stdout := fs.GetFileTestStat("/fake-errands-dir/fake-job-name/stdout.log")
+						Expect(stdout.StringContents()).To(Equal(longStdout))
+					})
 				})
 
 				Context("when errand script fails with non-0 exit code (execution of script is ok)", func() {
@@ -101,6 +194,8 @@ var _ = Describe("RunErrand", func() {
 								Stdout:     "fake-stdout",
 								Stderr:     "fake-stderr",
 								ExitStatus: 123,
+
+								LogsBlobstoreID: "fake-logs-blob-id",
 							},
 						))
 					})
@@ -125,9 +220,60 @@ var _ = Describe("RunErrand", func() {
 				})
 			})
 
+			Context("when errand options are given", func() {
+				BeforeEach(func() {
+					specService.Spec = boshas.V2ApplySpec{
+						JobSpecs: []boshas.V2JobSpec{
+							{Name: "fake-job-name"},
+							{Name: "fake-other-job-name"},
+						},
+					}
+				})
+
+				It("runs bin/run of named job with given arguments and environment", func() {
+					cmdRunner.AddProcess("/fake-jobs-dir/fake-other-job-name/bin/run fake-arg1 fake-arg2", &fakesys.FakeProcess{
+						WaitResult: boshsys.Result{Stdout: "fake-other-stdout", ExitStatus: 0},
+					})
+
+					result, err := action.Run(RunErrandOptions{
+						Name: "fake-other-job-name",
+						Args: []string{"fake-arg1", "fake-arg2"},
+						Env:  map[string]string{"FAKE_VAR": "fake-value"},
+					})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(result.Stdout).To(Equal("fake-other-stdout"))
+
+					cmd := cmdRunner.RunComplexCommands[0]
+					Expect(cmd.Name).To(Equal("/fake-jobs-dir/fake-other-job-name/bin/run"))
+					Expect(cmd.Args).To(Equal([]string{"fake-arg1", "fake-arg2"}))
+					Expect(cmd.Env).To(Equal(map[string]string{
+						"PATH":     "/usr/sbin:/usr/bin:/sbin:/bin",
+						"FAKE_VAR": "fake-value",
+					}))
+
+					stdout := fs.GetFileTestStat("/fake-errands-dir/fake-other-job-name/stdout.log")
+					Expect(stdout.StringContents()).To(Equal("fake-other-stdout"))
+				})
+
+				It("runs bin/run of the first job when name is not given", func() {
+					cmdRunner.AddProcess("/fake-jobs-dir/fake-job-name/bin/run", &fakesys.FakeProcess{})
+
+					_, err := action.Run(RunErrandOptions{})
+					Expect(err).ToNot(HaveOccurred())
+					Expect(cmdRunner.RunComplexCommands[0].Name).To(Equal("/fake-jobs-dir/fake-job-name/bin/run"))
+				})
+
+				It("returns error and does not run anything when named job is not in current spec", func() {
+					_, err := action.Run(RunErrandOptions{Name: "fake-unknown-job-name"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("Errand job fake-unknown-job-name is not in current spec"))
+					Expect(len(cmdRunner.RunComplexCommands)).To(Equal(0))
+				})
+			})
+
 			Context("when current agent spec does not have a job spec template", func() {
 				BeforeEach(func() {
-					specService.Spec = boshas.V1ApplySpec{}
+					specService.Spec = boshas.V2ApplySpec{}
 				})
 
 				It("returns error stating that job template is required", func() {
@@ -165,9 +311,9 @@ var _ = Describe("RunErrand", func() {
 
 	Describe("Cancel", func() {
 		BeforeEach(func() {
-			currentSpec := boshas.V1ApplySpec{}
-			currentSpec.JobSpec.Template = "fake-job-name"
-			specService.Spec = currentSpec
+			specService.Spec = boshas.V2ApplySpec{
+				JobSpecs: []boshas.V2JobSpec{{Name: "fake-job-name"}},
+			}
 		})
 
 		Context("when action was not cancelled yet", func() {
@@ -217,6 +363,8 @@ var _ = Describe("RunErrand", func() {
 							Stdout:     "fake-stdout",
 							Stderr:     "fake-stderr",
 							ExitStatus: 0,
+
+							LogsBlobstoreID: "fake-logs-blob-id",
 						},
 					))
 				})
@@ -247,6 +395,8 @@ var _ = Describe("RunErrand", func() {
 							Stdout:     "fake-stdout",
 							Stderr:     "fake-stderr",
 							ExitStatus: 123,
+
+							LogsBlobstoreID: "fake-logs-blob-id",
 						},
 					))
 				})
@@ -144,8 +144,9 @@ func (r concreteRunner) getMethodArgType(methodType reflect.Type, index int) (ar
 func (r concreteRunner) extractReturns(values []reflect.Value) (value interface{}, err error) {
 	errValue := values[1]
 	if !errValue.IsNil() {
-		errorValues := errValue.MethodByName("Error").Call([]reflect.Value{})
-		err = bosherr.New(errorValues[0].String())
+		// Error is returned as is so that error message is not
+		// interpreted as a format string and error type is kept
+		err = errValue.Interface().(error)
 	}
 
 	value = values[0].Interface()
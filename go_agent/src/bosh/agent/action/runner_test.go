@@ -180,6 +180,19 @@ func init() {
 			Expect(action.SliceArgs).To(Equal([]string{"a", "b", "c"}))
 		})
 
+		It("runner run returns action error as is", func() {
+			runner := NewRunner()
+
+			expectedErr := CompilePackageError{Err: errors.New("fake-run-error 100%s"), LogBlobstoreID: "fake-log-blob-id"}
+
+			action := &actionWithGoodRunMethod{Err: expectedErr}
+			payload := `{"arguments":["setup", 123, {}, [], 456]}`
+
+			_, err := runner.Run(action, []byte(payload))
+			Expect(err).To(Equal(expectedErr))
+			Expect(err.Error()).To(Equal("fake-run-error 100%s"))
+		})
+
 		It("runner run errs when actions not enough arguments", func() {
 			runner := NewRunner()
 
@@ -2,24 +2,47 @@ package action
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	boshas "bosh/agent/applier/applyspec"
+	boshscript "bosh/agent/script"
 	bosherr "bosh/errors"
 	boshjobsuper "bosh/jobsupervisor"
 )
 
+// DefaultStartTimeout is how long start waits for
+// job supervisor to report that all services are running
+const DefaultStartTimeout = 5 * time.Minute
+
 type StartAction struct {
-	jobSupervisor boshjobsuper.JobSupervisor
+	jobSupervisor  boshjobsuper.JobSupervisor
+	specService    boshas.V2Service
+	scriptProvider boshscript.ScriptProvider
+
+	runningTimeout       time.Duration
+	runningCheckInterval time.Duration
 }
 
-func NewStart(jobSupervisor boshjobsuper.JobSupervisor) (start StartAction) {
+func NewStart(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V2Service,
+	scriptProvider boshscript.ScriptProvider,
+	runningTimeout time.Duration,
+) (start StartAction) {
 	start = StartAction{
-		jobSupervisor: jobSupervisor,
+		jobSupervisor:        jobSupervisor,
+		specService:          specService,
+		scriptProvider:       scriptProvider,
+		runningTimeout:       runningTimeout,
+		runningCheckInterval: 1 * time.Second,
 	}
 	return
 }
 
 func (a StartAction) IsAsynchronous() bool {
-	return false
+	return true
 }
 
 func (a StartAction) IsPersistent() bool {
@@ -27,16 +50,88 @@ func (a StartAction) IsPersistent() bool {
 }
 
 func (a StartAction) Run() (value interface{}, err error) {
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting current spec")
+		return
+	}
+
 	err = a.jobSupervisor.Start()
 	if err != nil {
 		err = bosherr.WrapError(err, "Starting Monitored Services")
 		return
 	}
 
+	jobs := currentSpec.Jobs()
+	if len(jobs) == 0 {
+		value = "started"
+		return
+	}
+
+	err = a.waitForRunning()
+	if err != nil {
+		err = bosherr.WrapError(err, "Waiting for Monitored Services")
+		return
+	}
+
+	for _, job := range jobs {
+		script := a.scriptProvider.NewScript(job.Name, "post-start")
+		if !script.Exists() {
+			continue
+		}
+
+		err = script.Run()
+		if err != nil {
+			err = bosherr.WrapError(err, "Running post-start script for job %s", job.Name)
+			return
+		}
+	}
+
 	value = "started"
 	return
 }
 
+func (a StartAction) waitForRunning() error {
+	deadline := time.Now().Add(a.runningTimeout)
+
+	for {
+		status := a.jobSupervisor.Status()
+		if status == "running" {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			notRunning := a.notRunningProcesses()
+			if len(notRunning) == 0 {
+				return bosherr.New("Services are %s after %s", status, a.runningTimeout)
+			}
+
+			return bosherr.New("Services are %s after %s: %s", status, a.runningTimeout, strings.Join(notRunning, ", "))
+		}
+
+		time.Sleep(a.runningCheckInterval)
+	}
+}
+
+// notRunningProcesses describes processes with their last known state;
+// failing to get processes should not hide the timeout
+func (a StartAction) notRunningProcesses() []string {
+	processes, err := a.jobSupervisor.Processes()
+	if err != nil {
+		return nil
+	}
+
+	var notRunning []string
+
+	for _, process := range processes {
+		if process.State != "running" {
+			notRunning = append(notRunning, fmt.Sprintf("%s (%s)", process.Name, process.State))
+		}
+	}
+
+	return notRunning
+}
+
 func (a StartAction) Resume() (interface{}, error) {
 	return nil, errors.New("not supported")
 }
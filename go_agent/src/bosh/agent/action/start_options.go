@@ -0,0 +1,17 @@
+package action
+
+import (
+	"time"
+)
+
+type StartOptions struct {
+	// Start fails if services are not running within this many seconds
+	TimeoutSeconds int
+}
+
+func (o StartOptions) Timeout() time.Duration {
+	if o.TimeoutSeconds <= 0 {
+		return DefaultStartTimeout
+	}
+	return time.Duration(o.TimeoutSeconds) * time.Second
+}
@@ -0,0 +1,56 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	boshas "bosh/agent/applier/applyspec"
+	bosherr "bosh/errors"
+	boshjobsuper "bosh/jobsupervisor"
+	boshsys "bosh/system"
+)
+
+type StartProcessAction struct {
+	processManager processManager
+}
+
+func NewStartProcess(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V2Service,
+	fs boshsys.FileSystem,
+	jobsDir string,
+	timeout time.Duration,
+) (action StartProcessAction) {
+	action.processManager = newProcessManager(jobSupervisor, specService, fs, jobsDir, timeout)
+	return
+}
+
+func (a StartProcessAction) IsAsynchronous() bool {
+	return true
+}
+
+func (a StartProcessAction) IsPersistent() bool {
+	return false
+}
+
+func (a StartProcessAction) Run(names ...string) (string, error) {
+	err := a.processManager.validate(names)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Validating processes")
+	}
+
+	err = a.processManager.start(names)
+	if err != nil {
+		return "", err
+	}
+
+	return "started", nil
+}
+
+func (a StartProcessAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a StartProcessAction) Cancel() error {
+	return errors.New("not supported")
+}
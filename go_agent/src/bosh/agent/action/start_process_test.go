@@ -0,0 +1,132 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshas "bosh/agent/applier/applyspec"
+	fakeas "bosh/agent/applier/applyspec/fakes"
+	boshjobsuper "bosh/jobsupervisor"
+	fakejobsuper "bosh/jobsupervisor/fakes"
+	fakesys "bosh/system/fakes"
+)
+
+func init() {
+	Describe("StartProcess", func() {
+		var (
+			jobSupervisor *fakejobsuper.FakeJobSupervisor
+			specService   *fakeas.FakeV2Service
+			fs            *fakesys.FakeFileSystem
+			action        StartProcessAction
+		)
+
+		BeforeEach(func() {
+			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+			specService = fakeas.NewFakeV2Service()
+			fs = fakesys.NewFakeFileSystem()
+			action = NewStartProcess(jobSupervisor, specService, fs, "/fake-jobs-dir", 0)
+
+			fs.WriteFileString("/fake-jobs-dir/fake-job/monit", "check process fake-process-1\n  start program \"/fake-start\"\ncheck process fake-process-2\n  start program \"/fake-start\"\n")
+			fs.WriteFileString("/fake-jobs-dir/fake-job/fake-extra.monit", "check process fake-process-3\n  start program \"/fake-start\"\n")
+			fs.SetGlob("/fake-jobs-dir/fake-job/*.monit", []string{"/fake-jobs-dir/fake-job/fake-extra.monit"})
+
+			specService.Spec = boshas.V2ApplySpec{
+				JobSpecs: []boshas.V2JobSpec{{Name: "fake-job"}},
+			}
+
+			jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+				{Name: "fake-process-1", State: "stopped"},
+				{Name: "fake-process-2", State: "stopped"},
+				{Name: "fake-process-3", State: "stopped"},
+			}
+			jobSupervisor.StartProcessState = "running"
+		})
+
+		It("is asynchronous", func() {
+			Expect(action.IsAsynchronous()).To(BeTrue())
+		})
+
+		It("is not persistent", func() {
+			Expect(action.IsPersistent()).To(BeFalse())
+		})
+
+		It("starts named processes and returns started", func() {
+			value, err := action.Run("fake-process-1", "fake-process-3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("started"))
+
+			Expect(jobSupervisor.StartProcessNames).To(Equal([]string{"fake-process-1", "fake-process-3"}))
+			Expect(jobSupervisor.Started).To(BeFalse())
+		})
+
+		It("returns error when process names are not given", func() {
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("At least one process name is required"))
+		})
+
+		It("returns error when getting current spec fails", func() {
+			specService.GetErr = errors.New("fake-get-error")
+
+			_, err := action.Run("fake-process-1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+			Expect(jobSupervisor.StartProcessNames).To(BeEmpty())
+		})
+
+		It("returns error when current spec does not have jobs", func() {
+			specService.Spec = boshas.V2ApplySpec{}
+
+			_, err := action.Run("fake-process-1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Current spec does not have jobs"))
+			Expect(jobSupervisor.StartProcessNames).To(BeEmpty())
+		})
+
+		It("returns error when process is not part of current spec", func() {
+			_, err := action.Run("fake-process-1", "fake-unknown-process")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-unknown-process is not part of current spec"))
+			Expect(jobSupervisor.StartProcessNames).To(BeEmpty())
+		})
+
+		It("returns error when process is only known to job supervisor", func() {
+			// e.g. process of a job that was removed from current spec
+			jobSupervisor.ProcessesProcesses = append(jobSupervisor.ProcessesProcesses, boshjobsuper.Process{Name: "fake-old-process"})
+
+			_, err := action.Run("fake-old-process")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-old-process is not part of current spec"))
+			Expect(jobSupervisor.StartProcessNames).To(BeEmpty())
+		})
+
+		It("returns error when listing processes fails", func() {
+			jobSupervisor.ProcessesErr = errors.New("fake-processes-error")
+
+			_, err := action.Run("fake-process-1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-processes-error"))
+		})
+
+		It("returns error when starting process fails", func() {
+			jobSupervisor.StartProcessErr = errors.New("fake-start-error")
+
+			_, err := action.Run("fake-process-1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-error"))
+		})
+
+		It("returns error listing processes that are not running after timeout", func() {
+			jobSupervisor.StartProcessState = "failing"
+			action = NewStartProcess(jobSupervisor, specService, fs, "/fake-jobs-dir", 10*time.Millisecond)
+
+			_, err := action.Run("fake-process-1", "fake-process-2")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Processes are not running after 10ms: fake-process-1 (failing), fake-process-2 (failing)"))
+		})
+	})
+}
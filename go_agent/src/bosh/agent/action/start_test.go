@@ -1,27 +1,37 @@
 package action_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "bosh/agent/action"
+	boshas "bosh/agent/applier/applyspec"
+	fakeas "bosh/agent/applier/applyspec/fakes"
+	fakescript "bosh/agent/script/fakes"
+	boshjobsuper "bosh/jobsupervisor"
 	fakejobsuper "bosh/jobsupervisor/fakes"
 )
 
 func init() {
 	Describe("Start", func() {
 		var (
-			jobSupervisor *fakejobsuper.FakeJobSupervisor
-			action        StartAction
+			jobSupervisor  *fakejobsuper.FakeJobSupervisor
+			specService    *fakeas.FakeV2Service
+			scriptProvider *fakescript.FakeScriptProvider
+			action         StartAction
 		)
 
 		BeforeEach(func() {
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-			action = NewStart(jobSupervisor)
+			specService = fakeas.NewFakeV2Service()
+			scriptProvider = fakescript.NewFakeScriptProvider()
+			action = NewStart(jobSupervisor, specService, scriptProvider, 0)
 		})
 
-		It("is synchronous", func() {
-			Expect(action.IsAsynchronous()).To(BeFalse())
+		It("is asynchronous", func() {
+			Expect(action.IsAsynchronous()).To(BeTrue())
 		})
 
 		It("is not persistent", func() {
@@ -39,5 +49,100 @@ func init() {
 			Expect(err).ToNot(HaveOccurred())
 			Expect(jobSupervisor.Started).To(BeTrue())
 		})
+
+		It("returns error when starting monitor services fails", func() {
+			jobSupervisor.StartErr = errors.New("fake-start-error")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-error"))
+		})
+
+		It("returns error when getting current spec fails", func() {
+			specService.GetErr = errors.New("fake-get-error")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+			Expect(jobSupervisor.Started).To(BeFalse())
+		})
+
+		Context("when current spec has jobs", func() {
+			var postStartScript *fakescript.FakeScript
+
+			BeforeEach(func() {
+				specService.Spec = boshas.V1ApplySpec{
+					JobSpec: boshas.JobSpec{
+						Template: "fake-job-1",
+						JobTemplateSpecs: []boshas.JobTemplateSpec{
+							{Name: "fake-job-1"},
+							{Name: "fake-job-2"},
+						},
+					},
+				}.ToV2()
+
+				postStartScript = scriptProvider.NewScript("fake-job-1", "post-start").(*fakescript.FakeScript)
+				postStartScript.ExistsBool = true
+			})
+
+			Context("when services are running", func() {
+				BeforeEach(func() {
+					jobSupervisor.StatusStatus = "running"
+				})
+
+				It("runs post-start scripts of jobs that have them", func() {
+					_, err := action.Run()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(postStartScript.DidRun).To(BeTrue())
+					Expect(scriptProvider.Scripts["fake-job-2/post-start"].DidRun).To(BeFalse())
+				})
+
+				It("returns error when post-start script fails", func() {
+					postStartScript.RunError = errors.New("fake-post-start-error")
+
+					_, err := action.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Running post-start script for job fake-job-1"))
+					Expect(err.Error()).To(ContainSubstring("fake-post-start-error"))
+				})
+			})
+
+			Context("when services do not become running before timeout", func() {
+				BeforeEach(func() {
+					jobSupervisor.StatusStatus = "failing"
+				})
+
+				It("returns error and does not run post-start scripts", func() {
+					_, err := action.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Services are failing"))
+
+					Expect(postStartScript.DidRun).To(BeFalse())
+				})
+
+				It("returns error listing services that are not running with their last status", func() {
+					jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+						{Name: "fake-process-1", State: "running"},
+						{Name: "fake-process-2", State: "failing"},
+						{Name: "fake-process-3", State: "starting"},
+					}
+
+					_, err := action.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-process-2 (failing), fake-process-3 (starting)"))
+					Expect(err.Error()).ToNot(ContainSubstring("fake-process-1"))
+				})
+
+				It("returns error without services when they cannot be listed", func() {
+					jobSupervisor.ProcessesErr = errors.New("fake-processes-error")
+
+					_, err := action.Run()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("Services are failing"))
+					Expect(err.Error()).ToNot(ContainSubstring("fake-processes-error"))
+				})
+			})
+		})
 	})
 }
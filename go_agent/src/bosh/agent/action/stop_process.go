@@ -0,0 +1,56 @@
+package action
+
+import (
+	"errors"
+	"time"
+
+	boshas "bosh/agent/applier/applyspec"
+	bosherr "bosh/errors"
+	boshjobsuper "bosh/jobsupervisor"
+	boshsys "bosh/system"
+)
+
+type StopProcessAction struct {
+	processManager processManager
+}
+
+func NewStopProcess(
+	jobSupervisor boshjobsuper.JobSupervisor,
+	specService boshas.V2Service,
+	fs boshsys.FileSystem,
+	jobsDir string,
+	timeout time.Duration,
+) (action StopProcessAction) {
+	action.processManager = newProcessManager(jobSupervisor, specService, fs, jobsDir, timeout)
+	return
+}
+
+func (a StopProcessAction) IsAsynchronous() bool {
+	return true
+}
+
+func (a StopProcessAction) IsPersistent() bool {
+	return false
+}
+
+func (a StopProcessAction) Run(names ...string) (string, error) {
+	err := a.processManager.validate(names)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Validating processes")
+	}
+
+	err = a.processManager.stop(names)
+	if err != nil {
+		return "", err
+	}
+
+	return "stopped", nil
+}
+
+func (a StopProcessAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a StopProcessAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,96 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshas "bosh/agent/applier/applyspec"
+	fakeas "bosh/agent/applier/applyspec/fakes"
+	boshjobsuper "bosh/jobsupervisor"
+	fakejobsuper "bosh/jobsupervisor/fakes"
+	fakesys "bosh/system/fakes"
+)
+
+func init() {
+	Describe("StopProcess", func() {
+		var (
+			jobSupervisor *fakejobsuper.FakeJobSupervisor
+			specService   *fakeas.FakeV2Service
+			fs            *fakesys.FakeFileSystem
+			action        StopProcessAction
+		)
+
+		BeforeEach(func() {
+			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+			specService = fakeas.NewFakeV2Service()
+			fs = fakesys.NewFakeFileSystem()
+			action = NewStopProcess(jobSupervisor, specService, fs, "/fake-jobs-dir", 0)
+
+			fs.WriteFileString("/fake-jobs-dir/fake-job/monit", "check process fake-process-1\n  start program \"/fake-start\"\ncheck process fake-process-2\n  start program \"/fake-start\"\n")
+
+			specService.Spec = boshas.V2ApplySpec{
+				JobSpecs: []boshas.V2JobSpec{{Name: "fake-job"}},
+			}
+
+			jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+				{Name: "fake-process-1", State: "running"},
+				{Name: "fake-process-2", State: "running"},
+			}
+			jobSupervisor.StopProcessState = "stopped"
+		})
+
+		It("is asynchronous", func() {
+			Expect(action.IsAsynchronous()).To(BeTrue())
+		})
+
+		It("is not persistent", func() {
+			Expect(action.IsPersistent()).To(BeFalse())
+		})
+
+		It("stops named processes and returns stopped", func() {
+			value, err := action.Run("fake-process-2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(value).To(Equal("stopped"))
+
+			Expect(jobSupervisor.StopProcessNames).To(Equal([]string{"fake-process-2"}))
+			Expect(jobSupervisor.Stopped).To(BeFalse())
+		})
+
+		It("returns error when process is not part of current spec", func() {
+			_, err := action.Run("fake-unknown-process")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-unknown-process is not part of current spec"))
+			Expect(jobSupervisor.StopProcessNames).To(BeEmpty())
+		})
+
+		It("returns error when process is only known to job supervisor", func() {
+			// e.g. process of a job that was removed from current spec
+			jobSupervisor.ProcessesProcesses = append(jobSupervisor.ProcessesProcesses, boshjobsuper.Process{Name: "fake-old-process"})
+
+			_, err := action.Run("fake-old-process")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-old-process is not part of current spec"))
+			Expect(jobSupervisor.StopProcessNames).To(BeEmpty())
+		})
+
+		It("returns error when stopping process fails", func() {
+			jobSupervisor.StopProcessErr = errors.New("fake-stop-error")
+
+			_, err := action.Run("fake-process-1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-stop-error"))
+		})
+
+		It("returns error when processes are not stopped after timeout", func() {
+			jobSupervisor.StopProcessState = ""
+
+			_, err := action.Run("fake-process-1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Processes are not stopped after"))
+			Expect(err.Error()).To(ContainSubstring("fake-process-1 (running)"))
+		})
+	})
+}
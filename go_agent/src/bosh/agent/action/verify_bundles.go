@@ -0,0 +1,60 @@
+package action
+
+import (
+	"errors"
+
+	boshappl "bosh/agent/applier"
+	boshas "bosh/agent/applier/applyspec"
+	bosherr "bosh/errors"
+)
+
+type VerifyBundlesAction struct {
+	verifier    boshappl.Verifier
+	specService boshas.V2Service
+}
+
+func NewVerifyBundles(verifier boshappl.Verifier, specService boshas.V2Service) (action VerifyBundlesAction) {
+	action.verifier = verifier
+	action.specService = specService
+	return
+}
+
+func (a VerifyBundlesAction) IsAsynchronous() bool {
+	return true
+}
+
+func (a VerifyBundlesAction) IsPersistent() bool {
+	return false
+}
+
+type VerifyBundlesOptions struct {
+	// Repair downloads and installs missing or corrupted bundles again
+	Repair bool `json:"repair"`
+}
+
+func (a VerifyBundlesAction) Run(options ...VerifyBundlesOptions) (boshappl.Verification, error) {
+	var repair bool
+	if len(options) > 0 {
+		repair = options[0].Repair
+	}
+
+	currentSpec, err := a.specService.Get()
+	if err != nil {
+		return boshappl.Verification{}, bosherr.WrapError(err, "Getting current spec")
+	}
+
+	verification, err := a.verifier.Verify(currentSpec, repair)
+	if err != nil {
+		return boshappl.Verification{}, bosherr.WrapError(err, "Verifying bundles")
+	}
+
+	return verification, nil
+}
+
+func (a VerifyBundlesAction) Resume() (interface{}, error) {
+	return nil, errors.New("not supported")
+}
+
+func (a VerifyBundlesAction) Cancel() error {
+	return errors.New("not supported")
+}
@@ -0,0 +1,83 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/action"
+	boshappl "bosh/agent/applier"
+	boshas "bosh/agent/applier/applyspec"
+	fakeas "bosh/agent/applier/applyspec/fakes"
+	fakeappl "bosh/agent/applier/fakes"
+)
+
+var _ = Describe("VerifyBundlesAction", func() {
+	var (
+		verifier    *fakeappl.FakeVerifier
+		specService *fakeas.FakeV2Service
+		action      VerifyBundlesAction
+	)
+
+	BeforeEach(func() {
+		verifier = fakeappl.NewFakeVerifier()
+		specService = fakeas.NewFakeV2Service()
+		action = NewVerifyBundles(verifier, specService)
+	})
+
+	It("is asynchronous", func() {
+		Expect(action.IsAsynchronous()).To(BeTrue())
+	})
+
+	It("is not persistent", func() {
+		Expect(action.IsPersistent()).To(BeFalse())
+	})
+
+	Describe("Run", func() {
+		var currentSpec boshas.V2ApplySpec
+
+		BeforeEach(func() {
+			currentSpec = boshas.V2ApplySpec{
+				JobSpecs: []boshas.V2JobSpec{{Name: "fake-job"}},
+			}
+			specService.Spec = currentSpec
+		})
+
+		It("verifies bundles of current spec without repairing them", func() {
+			verification := boshappl.Verification{
+				Jobs: []boshappl.VerifiedBundle{{Name: "fake-job", Status: "ok"}},
+			}
+			verifier.VerifyVerification = verification
+
+			result, err := action.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(result).To(Equal(verification))
+
+			Expect(verifier.VerifyApplySpec).To(Equal(currentSpec))
+			Expect(verifier.VerifyRepair).To(BeFalse())
+		})
+
+		It("repairs bundles when requested", func() {
+			_, err := action.Run(VerifyBundlesOptions{Repair: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verifier.VerifyRepair).To(BeTrue())
+		})
+
+		It("returns error when getting current spec fails", func() {
+			specService.GetErr = errors.New("fake-get-error")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		})
+
+		It("returns error when verifying fails", func() {
+			verifier.VerifyErr = errors.New("fake-verify-error")
+
+			_, err := action.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-verify-error"))
+		})
+	})
+})
@@ -1,11 +1,14 @@
 package agent
 
 import (
+	"time"
+
 	boshaction "bosh/agent/action"
 	boshtask "bosh/agent/task"
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
 	boshlog "bosh/logger"
+	boshmetrics "bosh/metrics"
 )
 
 const actionDispatcherLogTag = "Action Dispatcher"
@@ -21,6 +24,7 @@ type concreteActionDispatcher struct {
 	taskManager   boshtask.Manager
 	actionFactory boshaction.Factory
 	actionRunner  boshaction.Runner
+	metrics       boshmetrics.Registry
 }
 
 func NewActionDispatcher(
@@ -29,6 +33,7 @@ func NewActionDispatcher(
 	taskManager boshtask.Manager,
 	actionFactory boshaction.Factory,
 	actionRunner boshaction.Runner,
+	metrics boshmetrics.Registry,
 ) (dispatcher ActionDispatcher) {
 	return concreteActionDispatcher{
 		logger:        logger,
@@ -36,6 +41,7 @@ func NewActionDispatcher(
 		taskManager:   taskManager,
 		actionFactory: actionFactory,
 		actionRunner:  actionRunner,
+		metrics:       metrics,
 	}
 }
 
@@ -46,7 +52,7 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 		// API consumers will encounter unknown task id error when they request get_task.
 		// Other option is to return an error which will cause agent to restart again
 		// which does not help API consumer to determine that agent cannot continue tasks.
-		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+		dispatcher.logger.Error(actionDispatcherLogTag, "%s", err.Error())
 		return
 	}
 
@@ -61,13 +67,20 @@ func (dispatcher concreteActionDispatcher) ResumePreviouslyDispatchedTasks() {
 		taskID := taskInfo.TaskID
 		payload := taskInfo.Payload
 
+		resumeTask := func() (interface{}, error) { return dispatcher.actionRunner.Resume(action, payload) }
+
 		task := dispatcher.taskService.CreateTaskWithID(
 			taskID,
-			func() (interface{}, error) { return dispatcher.actionRunner.Resume(action, payload) },
+			dispatcher.measuredTaskFunc(taskInfo.Method, resumeTask),
 			func(_ boshtask.Task) error { return action.Cancel() },
 			dispatcher.removeTaskInfo,
 		)
 
+		if reporter, ok := action.(boshaction.ProgressReporter); ok {
+			task.ProgressFunc = reporter.Progress
+		}
+
+		dispatcher.metrics.TaskQueued()
 		dispatcher.taskService.StartTask(task)
 	}
 }
@@ -95,9 +108,9 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 	var task boshtask.Task
 	var err error
 
-	runTask := func() (interface{}, error) {
+	runTask := dispatcher.measuredTaskFunc(req.Method, func() (interface{}, error) {
 		return dispatcher.actionRunner.Run(action, req.GetPayload())
-	}
+	})
 
 	cancelTask := func(_ boshtask.Task) error { return action.Cancel() }
 
@@ -109,8 +122,8 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, dispatcher.removeTaskInfo)
 		if err != nil {
 			err = bosherr.WrapError(err, "Create Task Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err.Error())
+			dispatcher.logger.Error(actionDispatcherLogTag, "%s", err.Error())
+			return boshhandler.NewExceptionResponse("%s", err.Error())
 		}
 
 		taskInfo := boshtask.TaskInfo{
@@ -122,18 +135,23 @@ func (dispatcher concreteActionDispatcher) dispatchAsynchronousAction(
 		err = dispatcher.taskManager.AddTaskInfo(taskInfo)
 		if err != nil {
 			err = bosherr.WrapError(err, "Action Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err.Error())
+			dispatcher.logger.Error(actionDispatcherLogTag, "%s", err.Error())
+			return boshhandler.NewExceptionResponse("%s", err.Error())
 		}
 	} else {
 		task, err = dispatcher.taskService.CreateTask(runTask, cancelTask, nil)
 		if err != nil {
 			err = bosherr.WrapError(err, "Create Task Failed %s", req.Method)
-			dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-			return boshhandler.NewExceptionResponse(err.Error())
+			dispatcher.logger.Error(actionDispatcherLogTag, "%s", err.Error())
+			return boshhandler.NewExceptionResponse("%s", err.Error())
 		}
 	}
 
+	if reporter, ok := action.(boshaction.ProgressReporter); ok {
+		task.ProgressFunc = reporter.Progress
+	}
+
+	dispatcher.metrics.TaskQueued()
 	dispatcher.taskService.StartTask(task)
 
 	return boshhandler.NewValueResponse(boshtask.TaskStateValue{
@@ -148,21 +166,45 @@ func (dispatcher concreteActionDispatcher) dispatchSynchronousAction(
 ) boshhandler.Response {
 	dispatcher.logger.Info(actionDispatcherLogTag, "Running sync action %s", req.Method)
 
+	startedAt := time.Now()
 	value, err := dispatcher.actionRunner.Run(action, req.GetPayload())
+	dispatcher.metrics.RecordAction(req.Method, time.Since(startedAt), err)
+
 	if err != nil {
-		err = bosherr.WrapError(err, "Action Failed %s", req.Method)
-		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
-		return boshhandler.NewExceptionResponse(err.Error())
+		wrappedErr := bosherr.WrapError(err, "Action Failed %s", req.Method)
+		dispatcher.logger.Error(actionDispatcherLogTag, "%s", wrappedErr.Error())
+
+		// Director downloads build log of a failed compilation
+		// when it finds out compile_package task failed via get_task
+		if compileErr, ok := err.(boshaction.CompilePackageError); ok && compileErr.LogBlobstoreID != "" {
+			return boshhandler.NewExceptionResponseWithLog(compileErr.LogBlobstoreID, "%s", wrappedErr.Error())
+		}
+
+		return boshhandler.NewExceptionResponse("%s", wrappedErr.Error())
 	}
 
 	return boshhandler.NewValueResponse(value)
 }
 
+// measuredTaskFunc records action run and marks task finished
+// once task func returns; tasks must be marked queued when started
+func (dispatcher concreteActionDispatcher) measuredTaskFunc(method string, taskFunc boshtask.TaskFunc) boshtask.TaskFunc {
+	return func() (interface{}, error) {
+		defer dispatcher.metrics.TaskFinished()
+
+		startedAt := time.Now()
+		value, err := taskFunc()
+		dispatcher.metrics.RecordAction(method, time.Since(startedAt), err)
+
+		return value, err
+	}
+}
+
 func (dispatcher concreteActionDispatcher) removeTaskInfo(task boshtask.Task) {
 	err := dispatcher.taskManager.RemoveTaskInfo(task.ID)
 	if err != nil {
 		// There is not much we can do about failing to write state of a finished task.
 		// On next agent restart, task will be Resume()d again so it must be idempotent.
-		dispatcher.logger.Error(actionDispatcherLogTag, err.Error())
+		dispatcher.logger.Error(actionDispatcherLogTag, "%s", err.Error())
 	}
 }
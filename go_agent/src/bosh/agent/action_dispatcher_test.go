@@ -9,12 +9,14 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "bosh/agent"
+	boshaction "bosh/agent/action"
 	fakeaction "bosh/agent/action/fakes"
 	boshtask "bosh/agent/task"
 	faketask "bosh/agent/task/fakes"
 	boshassert "bosh/assert"
 	boshhandler "bosh/handler"
 	boshlog "bosh/logger"
+	fakemetrics "bosh/metrics/fakes"
 )
 
 func init() {
@@ -25,6 +27,7 @@ func init() {
 			taskManager   *faketask.FakeManager
 			actionFactory *fakeaction.FakeFactory
 			actionRunner  *fakeaction.FakeRunner
+			metrics       *fakemetrics.FakeRegistry
 			dispatcher    ActionDispatcher
 		)
 
@@ -34,7 +37,8 @@ func init() {
 			taskManager = faketask.NewFakeManager()
 			actionFactory = fakeaction.NewFakeFactory()
 			actionRunner = &fakeaction.FakeRunner{}
-			dispatcher = NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner)
+			metrics = fakemetrics.NewFakeRegistry()
+			dispatcher = NewActionDispatcher(logger, taskService, taskManager, actionFactory, actionRunner, metrics)
 		})
 
 		It("responds with exception when the method is unknown", func() {
@@ -70,6 +74,34 @@ func init() {
 				expectedJSON := fmt.Sprintf("{\"exception\":{\"message\":\"Action Failed %s: fake-run-error\"}}", req.Method)
 				boshassert.MatchesJSONString(GinkgoT(), resp, expectedJSON)
 			})
+
+			It("does not interpret error message as format string", func() {
+				actionRunner.RunErr = errors.New("fake-run-error 100%s")
+
+				resp := dispatcher.Dispatch(req)
+				expectedJSON := fmt.Sprintf("{\"exception\":{\"message\":\"Action Failed %s: fake-run-error 100%%s\"}}", req.Method)
+				boshassert.MatchesJSONString(GinkgoT(), resp, expectedJSON)
+			})
+
+			It("includes build log blobstore id when package compilation failed", func() {
+				actionRunner.RunErr = boshaction.CompilePackageError{
+					Err:            errors.New("fake-compile-error"),
+					LogBlobstoreID: "fake-log-blob-id",
+				}
+
+				resp := dispatcher.Dispatch(req)
+				expectedJSON := fmt.Sprintf("{\"exception\":{\"message\":\"Action Failed %s: fake-compile-error\",\"log_blobstore_id\":\"fake-log-blob-id\"}}", req.Method)
+				boshassert.MatchesJSONString(GinkgoT(), resp, expectedJSON)
+			})
+
+			It("records action run in metrics", func() {
+				actionRunner.RunErr = errors.New("fake-run-error")
+
+				dispatcher.Dispatch(req)
+				Expect(metrics.RecordedActions).To(HaveLen(1))
+				Expect(metrics.RecordedActions[0].Method).To(Equal("fake-action"))
+				Expect(metrics.RecordedActions[0].Err).To(Equal(actionRunner.RunErr))
+			})
 		})
 
 		Context("when action is asynchronous", func() {
@@ -104,6 +136,14 @@ func init() {
 				})
 			}
 
+			It("allows task to report progress of the action", func() {
+				action.ProgressValue = "fake-progress"
+				dispatcher.Dispatch(req)
+
+				progress := taskService.StartedTasks["fake-generated-task-id"].Progress()
+				Expect(progress).To(Equal("fake-progress"))
+			})
+
 			Context("when action is not persistent", func() {
 				BeforeEach(func() {
 					action.Persistent = false
@@ -156,6 +196,25 @@ func init() {
 
 				ItAllowsToCancelTask()
 
+				It("records task in task queue until task finishes", func() {
+					dispatcher.Dispatch(req)
+					Expect(metrics.TaskQueueDepth).To(Equal(1))
+					Expect(metrics.RecordedActions).To(BeEmpty())
+
+					_, err := taskService.StartedTasks["fake-generated-task-id"].TaskFunc()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(metrics.TaskQueueDepth).To(Equal(0))
+					Expect(metrics.RecordedActions).To(HaveLen(1))
+					Expect(metrics.RecordedActions[0].Method).To(Equal("fake-action"))
+				})
+
+				It("does not queue task that could not be created", func() {
+					taskService.CreateTaskErr = errors.New("fake-create-task-error")
+					dispatcher.Dispatch(req)
+					Expect(metrics.TaskQueueDepth).To(Equal(0))
+				})
+
 				It("does not add task to task manager since it should not be resumed if agent is restarted", func() {
 					dispatcher.Dispatch(req)
 					taskInfos, _ := taskManager.GetTaskInfos()
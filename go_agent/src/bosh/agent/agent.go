@@ -5,14 +5,18 @@ import (
 
 	boshalert "bosh/agent/alert"
 	boshas "bosh/agent/applier/applyspec"
+	boshkmsg "bosh/agent/kmsg"
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
 	boshjobsuper "bosh/jobsupervisor"
 	boshlog "bosh/logger"
 	boshmbus "bosh/mbus"
 	boshplatform "bosh/platform"
+	boshvitals "bosh/platform/vitals"
 )
 
+const agentLogTag = "Agent"
+
 type Agent struct {
 	logger            boshlog.Logger
 	mbusHandler       boshhandler.Handler
@@ -20,8 +24,12 @@ type Agent struct {
 	actionDispatcher  ActionDispatcher
 	heartbeatInterval time.Duration
 	alertBuilder      boshalert.Builder
+	alertPipeline     boshalert.Pipeline
+	thresholdChecker  boshalert.ThresholdChecker
+	vitalsSampler     boshvitals.Sampler
+	kernelWatcher     boshkmsg.Watcher
 	jobSupervisor     boshjobsuper.JobSupervisor
-	specService       boshas.V1Service
+	specService       boshas.V2Service
 }
 
 func New(
@@ -30,8 +38,12 @@ func New(
 	platform boshplatform.Platform,
 	actionDispatcher ActionDispatcher,
 	alertBuilder boshalert.Builder,
+	alertPipeline boshalert.Pipeline,
+	thresholdChecker boshalert.ThresholdChecker,
+	vitalsSampler boshvitals.Sampler,
+	kernelWatcher boshkmsg.Watcher,
 	jobSupervisor boshjobsuper.JobSupervisor,
-	specService boshas.V1Service,
+	specService boshas.V2Service,
 	heartbeatInterval time.Duration,
 ) (a Agent) {
 	a.logger = logger
@@ -40,6 +52,10 @@ func New(
 	a.actionDispatcher = actionDispatcher
 	a.heartbeatInterval = heartbeatInterval
 	a.alertBuilder = alertBuilder
+	a.alertPipeline = alertPipeline
+	a.thresholdChecker = thresholdChecker
+	a.vitalsSampler = vitalsSampler
+	a.kernelWatcher = kernelWatcher
 	a.jobSupervisor = jobSupervisor
 	a.specService = specService
 	return
@@ -57,7 +73,9 @@ func (a Agent) Run() error {
 
 	go a.subscribeActionDispatcher(errChan)
 	go a.generateHeartbeats(errChan)
-	go a.jobSupervisor.MonitorJobFailures(a.handleJobFailure(errChan))
+	go a.monitorJobFailures(errChan)
+	go a.sampleVitals(errChan)
+	go a.watchKernelMessages(errChan)
 
 	select {
 	case err = <-errChan:
@@ -120,28 +138,125 @@ func (a Agent) getHeartbeat() (boshmbus.Heartbeat, error) {
 		return boshmbus.Heartbeat{}, bosherr.WrapError(err, "Getting job spec")
 	}
 
+	// Job state already reflects that job supervisor is not available
+	processes, err := a.jobSupervisor.Processes()
+	if err != nil {
+		a.logger.Error(agentLogTag, "Failed to get processes for heartbeat: %s", err.Error())
+	}
+
 	hb := boshmbus.Heartbeat{
-		Job:      spec.JobSpec.Name,
-		Index:    spec.Index,
-		JobState: a.jobSupervisor.Status(),
-		Vitals:   vitals,
+		Job:       spec.JobName(),
+		Index:     spec.Index,
+		JobState:  a.jobSupervisor.Status(),
+		Vitals:    vitals,
+		Processes: processes,
 	}
 	return hb, nil
 }
 
 func (a Agent) handleJobFailure(errChan chan error) boshjobsuper.JobFailureHandler {
 	return func(monitAlert boshalert.MonitAlert) error {
-		alert, err := a.alertBuilder.Build(monitAlert)
-		if err != nil {
-			return bosherr.WrapError(err, "Building alert")
+		return a.sendAlert(monitAlert, errChan)
+	}
+}
+
+func (a Agent) monitorJobFailures(errChan chan error) {
+	defer a.logger.HandlePanic("Agent Monitor Job Failures")
+
+	// Agent keeps running without job failure alerts
+	// e.g. when alert port is already taken
+	err := a.jobSupervisor.MonitorJobFailures(a.handleJobFailure(errChan))
+	if err != nil {
+		a.logger.Error(agentLogTag, "Monitoring job failures: %s", err.Error())
+	}
+}
+
+func (a Agent) sampleVitals(errChan chan error) {
+	defer a.logger.HandlePanic("Agent Sample Vitals")
+
+	err := a.vitalsSampler.Run(a.handleVitalsSample(errChan))
+	if err != nil {
+		a.logger.Error(agentLogTag, "Sampling vitals: %s", err.Error())
+	}
+}
+
+func (a Agent) handleVitalsSample(errChan chan error) boshvitals.SampleHandler {
+	return func(sampledAt time.Time, vitals boshvitals.Vitals) {
+		// Threshold alerts skip alert pipeline since checker only returns
+		// an alert when metric moves to another level and would not
+		// return it again if pipeline suppressed it
+		for _, thresholdAlert := range a.thresholdChecker.Check(vitals) {
+			err := a.buildAndSendAlert(thresholdAlert, errChan)
+			if err != nil {
+				a.logger.Error(agentLogTag, "Failed to send resource threshold alert: %s", err.Error())
+			}
 		}
+	}
+}
 
-		err = a.mbusHandler.SendToHealthManager("alert", alert)
-		if err != nil {
-			err = bosherr.WrapError(err, "Sending heartbeat")
-			errChan <- err
+func (a Agent) watchKernelMessages(errChan chan error) {
+	defer a.logger.HandlePanic("Agent Watch Kernel Messages")
+
+	// Agent keeps running without kernel alerts
+	// e.g. when kernel messages are not readable
+	err := a.kernelWatcher.Watch(a.handleKernelEvent(errChan))
+	if err != nil {
+		a.logger.Error(agentLogTag, "Watching kernel messages: %s", err.Error())
+	}
+}
+
+func (a Agent) handleKernelEvent(errChan chan error) boshkmsg.EventHandler {
+	return func(event boshalert.KernelEvent) error {
+		kernelAlert := event.MonitAlert()
+
+		for _, pipelineAlert := range a.alertPipeline.Process(kernelAlert) {
+			// Pipeline might send e.g. flapping alert in place of kernel alert
+			if pipelineAlert != kernelAlert {
+				err := a.buildAndSendAlert(pipelineAlert, errChan)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			alert, err := a.alertBuilder.BuildKernelAlert(event)
+			if err != nil {
+				return bosherr.WrapError(err, "Building kernel alert")
+			}
+
+			err = a.mbusHandler.SendToHealthManager("alert", alert)
+			if err != nil {
+				err = bosherr.WrapError(err, "Sending kernel alert")
+				errChan <- err
+			}
 		}
 
 		return nil
 	}
 }
+
+func (a Agent) sendAlert(monitAlert boshalert.MonitAlert, errChan chan error) error {
+	for _, pipelineAlert := range a.alertPipeline.Process(monitAlert) {
+		err := a.buildAndSendAlert(pipelineAlert, errChan)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a Agent) buildAndSendAlert(monitAlert boshalert.MonitAlert, errChan chan error) error {
+	alert, err := a.alertBuilder.Build(monitAlert)
+	if err != nil {
+		return bosherr.WrapError(err, "Building alert")
+	}
+
+	err = a.mbusHandler.SendToHealthManager("alert", alert)
+	if err != nil {
+		err = bosherr.WrapError(err, "Sending alert")
+		errChan <- err
+	}
+
+	return nil
+}
@@ -12,13 +12,16 @@ import (
 	fakealert "bosh/agent/alert/fakes"
 	boshas "bosh/agent/applier/applyspec"
 	fakeas "bosh/agent/applier/applyspec/fakes"
+	fakekmsg "bosh/agent/kmsg/fakes"
 	boshhandler "bosh/handler"
+	boshjobsuper "bosh/jobsupervisor"
 	fakejobsuper "bosh/jobsupervisor/fakes"
 	boshlog "bosh/logger"
 	boshmbus "bosh/mbus"
 	fakembus "bosh/mbus/fakes"
 	fakeplatform "bosh/platform/fakes"
 	boshvitals "bosh/platform/vitals"
+	fakevitals "bosh/platform/vitals/fakes"
 )
 
 type FakeActionDispatcher struct {
@@ -46,8 +49,12 @@ func init() {
 			platform         *fakeplatform.FakePlatform
 			actionDispatcher *FakeActionDispatcher
 			alertBuilder     *fakealert.FakeAlertBuilder
+			alertPipeline    *fakealert.FakePipeline
+			thresholdChecker *fakealert.FakeThresholdChecker
+			vitalsSampler    *fakevitals.FakeSampler
+			kernelWatcher    *fakekmsg.FakeWatcher
 			jobSupervisor    *fakejobsuper.FakeJobSupervisor
-			specService      *fakeas.FakeV1Service
+			specService      *fakeas.FakeV2Service
 		)
 
 		BeforeEach(func() {
@@ -56,9 +63,13 @@ func init() {
 			platform = fakeplatform.NewFakePlatform()
 			actionDispatcher = &FakeActionDispatcher{}
 			alertBuilder = fakealert.NewFakeAlertBuilder()
+			alertPipeline = fakealert.NewFakePipeline()
+			thresholdChecker = fakealert.NewFakeThresholdChecker()
+			vitalsSampler = fakevitals.NewFakeSampler()
+			kernelWatcher = fakekmsg.NewFakeWatcher()
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
-			specService = fakeas.NewFakeV1Service()
-			agent = New(logger, handler, platform, actionDispatcher, alertBuilder, jobSupervisor, specService, 5*time.Millisecond)
+			specService = fakeas.NewFakeV2Service()
+			agent = New(logger, handler, platform, actionDispatcher, alertBuilder, alertPipeline, thresholdChecker, vitalsSampler, kernelWatcher, jobSupervisor, specService, 5*time.Millisecond)
 		})
 
 		Describe("Run", func() {
@@ -98,9 +109,12 @@ func init() {
 					specService.Spec = boshas.V1ApplySpec{
 						JobSpec: boshas.JobSpec{Name: &jobName},
 						Index:   &jobIndex,
-					}
+					}.ToV2()
 
 					jobSupervisor.StatusStatus = "fake-state"
+					jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+						{Name: "fake-process", State: "running"},
+					}
 
 					platform.FakeVitalsService.GetVitals = boshvitals.Vitals{
 						Load: []string{"a", "b", "c"},
@@ -114,12 +128,15 @@ func init() {
 					Index:    &expectedJobIndex,
 					JobState: "fake-state",
 					Vitals:   boshvitals.Vitals{Load: []string{"a", "b", "c"}},
+					Processes: []boshjobsuper.Process{
+						{Name: "fake-process", State: "running"},
+					},
 				}
 
 				It("sends initial heartbeat", func() {
 					// Configure periodic heartbeat every 5 hours
 					// so that we are sure that we will not receive it
-					agent = New(logger, handler, platform, actionDispatcher, alertBuilder, jobSupervisor, specService, 5*time.Hour)
+					agent = New(logger, handler, platform, actionDispatcher, alertBuilder, alertPipeline, thresholdChecker, vitalsSampler, kernelWatcher, jobSupervisor, specService, 5*time.Hour)
 
 					// Immediately exit after sending initial heartbeat
 					handler.SendToHealthManagerErr = errors.New("stop")
@@ -154,6 +171,26 @@ func init() {
 				})
 			})
 
+			Context("when job spec only includes V2 jobs", func() {
+				BeforeEach(func() {
+					handler.KeepOnRunning()
+
+					specService.Spec = boshas.V2ApplySpec{
+						JobSpecs: []boshas.V2JobSpec{{Name: "fake-job-1"}, {Name: "fake-job-2"}},
+					}
+				})
+
+				It("sends heartbeat with names of V2 jobs", func() {
+					handler.SendToHealthManagerErr = errors.New("stop")
+
+					err := agent.Run()
+					Expect(err).To(HaveOccurred())
+
+					heartbeat := handler.HMRequests()[0].Payload.(boshmbus.Heartbeat)
+					Expect(*heartbeat.Job).To(Equal("fake-job-1,fake-job-2"))
+				})
+			})
+
 			Context("when the agent fails to get job spec for a heartbeat", func() {
 				BeforeEach(func() {
 					specService.GetErr = errors.New("fake-spec-service-error")
@@ -186,6 +223,108 @@ func init() {
 				failureAlert := boshalert.MonitAlert{ID: "fake-monit-alert"}
 				jobSupervisor.JobFailureAlert = &failureAlert
 
+				pipelineAlert := boshalert.MonitAlert{ID: "fake-pipeline-alert"}
+				alertPipeline.ProcessAlerts = []boshalert.MonitAlert{pipelineAlert}
+
+				builtAlert := boshalert.Alert{ID: "fake-built-alert"}
+				alertBuilder.BuildAlert = builtAlert
+
+				// Immediately exit from Run() after alert is sent
+				handler.SendToHealthManagerCallBack = func(hmRequest fakembus.HMRequest) {
+					if hmRequest.Topic == "alert" {
+						handler.SendToHealthManagerErr = errors.New("stop")
+					}
+				}
+
+				err := agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				Expect(alertPipeline.ProcessInputs).To(Equal([]boshalert.MonitAlert{failureAlert}))
+				Expect(alertBuilder.BuildInput).To(Equal(pipelineAlert))
+
+				// Check for inclusion because heartbeats might have been received
+				Expect(handler.HMRequests()).To(ContainElement(
+					fakembus.HMRequest{Topic: "alert", Payload: builtAlert},
+				))
+			})
+
+			It("sends resource threshold alerts for sampled vitals without alert pipeline", func() {
+				handler.KeepOnRunning()
+
+				sampledVitals := boshvitals.Vitals{Load: []string{"a", "b", "c"}}
+				vitalsSampler.Samples = []boshvitals.Vitals{sampledVitals}
+
+				thresholdAlert := boshalert.MonitAlert{ID: "fake-threshold-alert"}
+				thresholdChecker.CheckAlerts = []boshalert.MonitAlert{thresholdAlert}
+
+				builtAlert := boshalert.Alert{ID: "fake-built-alert"}
+				alertBuilder.BuildAlert = builtAlert
+
+				// Immediately exit from Run() after alert is sent
+				handler.SendToHealthManagerCallBack = func(hmRequest fakembus.HMRequest) {
+					if hmRequest.Topic == "alert" {
+						handler.SendToHealthManagerErr = errors.New("stop")
+					}
+				}
+
+				err := agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				Expect(thresholdChecker.CheckInputs()).To(Equal([]boshvitals.Vitals{sampledVitals}))
+				Expect(alertPipeline.ProcessInputs).To(BeEmpty())
+				Expect(alertBuilder.BuildInput).To(Equal(thresholdAlert))
+
+				// Check for inclusion because heartbeats might have been received
+				Expect(handler.HMRequests()).To(ContainElement(
+					fakembus.HMRequest{Topic: "alert", Payload: builtAlert},
+				))
+			})
+
+			It("sends kernel alerts passed by alert pipeline to health manager", func() {
+				handler.KeepOnRunning()
+
+				kernelEvent := boshalert.KernelEvent{Type: boshalert.KernelEventOOMKill, Pid: 1234}
+				kernelWatcher.Events = []boshalert.KernelEvent{kernelEvent}
+
+				alertPipeline.ProcessAlerts = []boshalert.MonitAlert{kernelEvent.MonitAlert()}
+
+				builtAlert := boshalert.KernelAlert{
+					Alert: boshalert.Alert{ID: "fake-kernel-alert"},
+					Type:  boshalert.KernelEventOOMKill,
+				}
+				alertBuilder.BuildKernelAlertAlert = builtAlert
+
+				// Immediately exit from Run() after alert is sent
+				handler.SendToHealthManagerCallBack = func(hmRequest fakembus.HMRequest) {
+					if hmRequest.Topic == "alert" {
+						handler.SendToHealthManagerErr = errors.New("stop")
+					}
+				}
+
+				err := agent.Run()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("stop"))
+
+				Expect(alertPipeline.ProcessInputs).To(Equal([]boshalert.MonitAlert{kernelEvent.MonitAlert()}))
+				Expect(alertBuilder.BuildKernelAlertInput).To(Equal(kernelEvent))
+
+				// Check for inclusion because heartbeats might have been received
+				Expect(handler.HMRequests()).To(ContainElement(
+					fakembus.HMRequest{Topic: "alert", Payload: builtAlert},
+				))
+			})
+
+			It("sends alert returned by alert pipeline in place of kernel alert", func() {
+				handler.KeepOnRunning()
+
+				kernelEvent := boshalert.KernelEvent{Type: boshalert.KernelEventSegfault, Pid: 1234}
+				kernelWatcher.Events = []boshalert.KernelEvent{kernelEvent}
+
+				flappingAlert := boshalert.MonitAlert{ID: "fake-flapping-alert", Event: boshalert.FlappingEvent}
+				alertPipeline.ProcessAlerts = []boshalert.MonitAlert{flappingAlert}
+
 				builtAlert := boshalert.Alert{ID: "fake-built-alert"}
 				alertBuilder.BuildAlert = builtAlert
 
@@ -200,7 +339,7 @@ func init() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("stop"))
 
-				Expect(alertBuilder.BuildInput).To(Equal(failureAlert))
+				Expect(alertBuilder.BuildInput).To(Equal(flappingAlert))
 
 				// Check for inclusion because heartbeats might have been received
 				Expect(handler.HMRequests()).To(ContainElement(
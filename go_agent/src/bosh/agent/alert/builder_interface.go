@@ -1,7 +1,12 @@
 package alert
 
+import (
+	"time"
+)
+
 type Builder interface {
 	Build(input MonitAlert) (alert Alert, err error)
+	BuildKernelAlert(input KernelEvent) (alert KernelAlert, err error)
 }
 
 type Alert struct {
@@ -20,3 +25,44 @@ type MonitAlert struct {
 	Date        string
 	Description string
 }
+
+const (
+	KernelEventOOMKill  = "oom_kill"
+	KernelEventSegfault = "segfault"
+)
+
+// KernelEvent describes process killed or crashed as reported by the kernel
+type KernelEvent struct {
+	// One of KernelEventOOMKill or KernelEventSegfault
+	Type string
+
+	Pid     int
+	Command string
+
+	// Service is job supervisor process name; empty if process is unknown
+	Service string
+
+	// Only known for OOM kills
+	RSSKb  int
+	Cgroup string
+
+	Message string
+	Time    time.Time
+}
+
+// KernelAlert is sent to health manager in place of regular alert
+// to let it know exactly which process was killed by the kernel
+type KernelAlert struct {
+	Alert
+
+	Type    string             `json:"type"`
+	Process KernelAlertProcess `json:"process"`
+}
+
+type KernelAlertProcess struct {
+	Pid     int    `json:"pid"`
+	Command string `json:"command"`
+	Service string `json:"service,omitempty"`
+	RSSKb   int    `json:"rss_kb,omitempty"`
+	Cgroup  string `json:"cgroup,omitempty"`
+}
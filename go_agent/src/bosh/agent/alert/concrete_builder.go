@@ -31,6 +31,50 @@ func (b concreteBuilder) Build(input MonitAlert) (alert Alert, err error) {
 	return
 }
 
+func (b concreteBuilder) BuildKernelAlert(input KernelEvent) (alert KernelAlert, err error) {
+	monitAlert := input.MonitAlert()
+
+	alert.ID = monitAlert.ID
+	alert.Severity = b.getSeverity(monitAlert)
+	alert.Title = b.getTitle(monitAlert)
+	alert.Summary = input.Message
+	alert.CreatedAt = input.Time.Unix()
+
+	alert.Type = input.Type
+	alert.Process = KernelAlertProcess{
+		Pid:     input.Pid,
+		Command: input.Command,
+		Service: input.Service,
+		RSSKb:   input.RSSKb,
+		Cgroup:  input.Cgroup,
+	}
+
+	return
+}
+
+// MonitAlert describes kernel event as an alert of the killed process
+// so that it goes through the same alert pipeline as job failures
+func (input KernelEvent) MonitAlert() MonitAlert {
+	service := input.Service
+	if service == "" {
+		service = input.Command
+	}
+
+	event, eventFound := kernelEventToEvent[input.Type]
+	if !eventFound {
+		event = input.Type
+	}
+
+	return MonitAlert{
+		ID:          fmt.Sprintf("%d.%s.%d@kernel", input.Time.Unix(), input.Type, input.Pid),
+		Service:     service,
+		Event:       event,
+		Action:      "alert",
+		Date:        input.Time.Format(time.RFC1123Z),
+		Description: input.Message,
+	}
+}
+
 func (b concreteBuilder) getSeverity(input MonitAlert) (severity SeverityLevel) {
 	severity, severityFound := eventToSeverity[strings.ToLower(input.Event)]
 	if !severityFound {
@@ -73,6 +117,11 @@ const (
 	SeverityDefault  SeverityLevel = SeverityCritical
 )
 
+var kernelEventToEvent = map[string]string{
+	KernelEventOOMKill:  "out of memory kill",
+	KernelEventSegfault: "segfault",
+}
+
 var eventToSeverity = map[string]SeverityLevel{
 	"action done":                  SeverityIgnored,
 	"checksum failed":              SeverityCritical,
@@ -99,6 +148,7 @@ var eventToSeverity = map[string]SeverityLevel{
 	"filesystem flags succeeded":   SeverityIgnored,
 	"filesystem flags changed":     SeverityWarning,
 	"filesystem flags not changed": SeverityIgnored,
+	"flapping":                     SeverityCritical,
 	"gid failed":                   SeverityError,
 	"gid succeeded":                SeverityIgnored,
 	"gid changed":                  SeverityWarning,
@@ -107,6 +157,8 @@ var eventToSeverity = map[string]SeverityLevel{
 	"heartbeat succeeded":          SeverityIgnored,
 	"heartbeat changed":            SeverityWarning,
 	"heartbeat not changed":        SeverityIgnored,
+	"health probe failed":          SeverityError,
+	"health probe recovered":       SeverityWarning,
 	"icmp failed":                  SeverityCritical,
 	"icmp succeeded":               SeverityIgnored,
 	"icmp changed":                 SeverityWarning,
@@ -139,6 +191,11 @@ var eventToSeverity = map[string]SeverityLevel{
 	"resource limit succeeded":     SeverityIgnored,
 	"resource limit changed":       SeverityWarning,
 	"resource limit not changed":   SeverityIgnored,
+	"resource threshold warning":   SeverityWarning,
+	"resource threshold critical":  SeverityCritical,
+	"resource threshold recovered": SeverityIgnored,
+	"out of memory kill":           SeverityCritical,
+	"segfault":                     SeverityError,
 	"size failed":                  SeverityError,
 	"size succeeded":               SeverityIgnored,
 	"size changed":                 SeverityError,
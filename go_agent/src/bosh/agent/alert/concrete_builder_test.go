@@ -57,6 +57,13 @@ func init() {
 					"action done": SeverityIgnored,
 					"Action done": SeverityIgnored,
 					"action Done": SeverityIgnored,
+
+					"health probe failed":    SeverityError,
+					"health probe recovered": SeverityWarning,
+
+					ThresholdWarningEvent:   SeverityWarning,
+					ThresholdCriticalEvent:  SeverityCritical,
+					ThresholdRecoveredEvent: SeverityIgnored,
 				}
 
 				for event, expectedSeverity := range alerts {
@@ -104,5 +111,79 @@ func init() {
 				Expect(builtAlert.Title).To(Equal("nats (10.0.0.1, 192.168.0.1) - does not exist - restart"))
 			})
 		})
+
+		Describe("BuildKernelAlert", func() {
+			var event KernelEvent
+
+			BeforeEach(func() {
+				event = KernelEvent{
+					Type:    KernelEventOOMKill,
+					Pid:     1234,
+					Command: "redis-server",
+					Service: "redis",
+					RSSKb:   524288,
+					Cgroup:  "/system.slice/redis.service",
+					Message: "Killed process 1234 (redis-server)",
+					Time:    time.Unix(1306076861, 0),
+				}
+			})
+
+			It("builds alert with victim process details", func() {
+				settingsService.IPs = []string{"10.0.0.1"}
+
+				builtAlert, err := builder.BuildKernelAlert(event)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(builtAlert).To(Equal(KernelAlert{
+					Alert: Alert{
+						ID:        "1306076861.oom_kill.1234@kernel",
+						Severity:  SeverityCritical,
+						Title:     "redis (10.0.0.1) - out of memory kill - alert",
+						Summary:   "Killed process 1234 (redis-server)",
+						CreatedAt: 1306076861,
+					},
+					Type: KernelEventOOMKill,
+					Process: KernelAlertProcess{
+						Pid:     1234,
+						Command: "redis-server",
+						Service: "redis",
+						RSSKb:   524288,
+						Cgroup:  "/system.slice/redis.service",
+					},
+				}))
+			})
+
+			It("uses command in title when service is not known", func() {
+				event.Type = KernelEventSegfault
+				event.Service = ""
+
+				builtAlert, err := builder.BuildKernelAlert(event)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(builtAlert.Title).To(Equal("redis-server - segfault - alert"))
+				Expect(builtAlert.Severity).To(Equal(SeverityError))
+			})
+		})
+
+		Describe("KernelEvent.MonitAlert", func() {
+			It("describes kernel event as alert of killed process", func() {
+				event := KernelEvent{
+					Type:    KernelEventOOMKill,
+					Pid:     1234,
+					Command: "redis-server",
+					Service: "redis",
+					Message: "Killed process 1234 (redis-server)",
+					Time:    time.Unix(1306076861, 0).UTC(),
+				}
+
+				Expect(event.MonitAlert()).To(Equal(MonitAlert{
+					ID:          "1306076861.oom_kill.1234@kernel",
+					Service:     "redis",
+					Event:       "out of memory kill",
+					Action:      "alert",
+					Date:        "Sun, 22 May 2011 15:07:41 +0000",
+					Description: "Killed process 1234 (redis-server)",
+				}))
+			})
+		})
 	})
 }
@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	boshlog "bosh/logger"
+)
+
+const concretePipelineLogTag = "alertPipeline"
+
+// concretePipeline keeps crash looping processes from flooding
+// health manager with alerts. Alerts are first checked for flapping,
+// then deduplicated and finally rate limited per service.
+type concretePipeline struct {
+	options PipelineOptions
+	logger  boshlog.Logger
+
+	lock     sync.Mutex
+	services map[string]*serviceAlerts
+}
+
+type serviceAlerts struct {
+	lastEvent string
+
+	// Times when service changed its state within flap window
+	changes  []time.Time
+	flapping bool
+
+	// Times when alerts were sent within rate limit window
+	sent []time.Time
+
+	// Last time alert was sent for last event
+	lastSent time.Time
+}
+
+func NewPipeline(options PipelineOptions, logger boshlog.Logger) Pipeline {
+	return &concretePipeline{
+		options:  options,
+		logger:   logger,
+		services: map[string]*serviceAlerts{},
+	}
+}
+
+func (p *concretePipeline) Process(input MonitAlert) []MonitAlert {
+	if p.options.Disabled {
+		return []MonitAlert{input}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := p.options.now()
+	event := strings.ToLower(input.Event)
+
+	service, found := p.services[input.Service]
+	if !found {
+		service = &serviceAlerts{}
+		p.services[input.Service] = service
+	}
+
+	repeated := service.lastEvent == event
+
+	if service.lastEvent != "" && !repeated {
+		service.changes = append(service.changes, now)
+	}
+	service.lastEvent = event
+	service.changes = timesSince(service.changes, now.Add(-p.options.flapWindow()))
+
+	if len(service.changes) >= p.options.flapThreshold() {
+		if service.flapping {
+			p.logger.Debug(concretePipelineLogTag, "Suppressing alert %s since service %s is flapping", input.ID, input.Service)
+			return nil
+		}
+
+		service.flapping = true
+		return []MonitAlert{p.flappingAlert(input, len(service.changes))}
+	}
+
+	service.flapping = false
+
+	// Only repeated alerts are deduplicated; state change always goes through
+	if repeated && now.Sub(service.lastSent) < p.options.dedupeWindow() {
+		p.logger.Debug(concretePipelineLogTag, "Suppressing duplicate alert %s for service %s", input.ID, input.Service)
+		return nil
+	}
+
+	service.sent = timesSince(service.sent, now.Add(-p.options.rateLimitWindow()))
+
+	if len(service.sent) >= p.options.rateLimit() {
+		p.logger.Debug(concretePipelineLogTag, "Suppressing alert %s since service %s exceeded rate limit", input.ID, input.Service)
+		return nil
+	}
+
+	service.sent = append(service.sent, now)
+	service.lastSent = now
+
+	return []MonitAlert{input}
+}
+
+func (p *concretePipeline) flappingAlert(input MonitAlert, changes int) MonitAlert {
+	return MonitAlert{
+		ID:      input.ID,
+		Service: input.Service,
+		Event:   FlappingEvent,
+		Action:  "alert",
+		Date:    input.Date,
+		Description: fmt.Sprintf(
+			"service changed state %d times within %s; further alerts are suppressed until it settles",
+			changes, p.options.flapWindow(),
+		),
+	}
+}
+
+// timesSince drops times before given time; times are in ascending order
+func timesSince(times []time.Time, since time.Time) []time.Time {
+	for i, t := range times {
+		if !t.Before(since) {
+			return times[i:]
+		}
+	}
+	return nil
+}
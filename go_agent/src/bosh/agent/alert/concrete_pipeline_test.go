@@ -0,0 +1,151 @@
+package alert_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/alert"
+	boshlog "bosh/logger"
+)
+
+func init() {
+	Describe("concretePipeline", func() {
+		var (
+			options  PipelineOptions
+			pipeline Pipeline
+			now      time.Time
+		)
+
+		BeforeEach(func() {
+			now = time.Date(2011, time.May, 22, 20, 7, 41, 0, time.UTC)
+			options = PipelineOptions{
+				Now: func() time.Time { return now },
+			}
+		})
+
+		JustBeforeEach(func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			pipeline = NewPipeline(options, logger)
+		})
+
+		buildAlert := func(service, event string) MonitAlert {
+			return MonitAlert{
+				ID:      "fake-id",
+				Service: service,
+				Event:   event,
+				Action:  "restart",
+				Date:    "Sun, 22 May 2011 20:07:41 +0500",
+			}
+		}
+
+		Describe("Process", func() {
+			It("passes through first alert for service and event", func() {
+				alert := buildAlert("nats", "does not exist")
+				Expect(pipeline.Process(alert)).To(Equal([]MonitAlert{alert}))
+			})
+
+			It("suppresses duplicate service and event within dedupe window", func() {
+				alert := buildAlert("nats", "does not exist")
+				Expect(pipeline.Process(alert)).To(HaveLen(1))
+				Expect(pipeline.Process(alert)).To(BeEmpty())
+				Expect(pipeline.Process(buildAlert("nats", "Does Not Exist"))).To(BeEmpty())
+			})
+
+			It("sends duplicate again after dedupe window expires", func() {
+				alert := buildAlert("nats", "does not exist")
+				Expect(pipeline.Process(alert)).To(HaveLen(1))
+
+				now = now.Add(59 * time.Second)
+				Expect(pipeline.Process(alert)).To(BeEmpty())
+
+				now = now.Add(time.Second)
+				Expect(pipeline.Process(alert)).To(HaveLen(1))
+			})
+
+			It("does not suppress state change back to previously sent event", func() {
+				Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(HaveLen(1))
+				Expect(pipeline.Process(buildAlert("nats", "exists"))).To(HaveLen(1))
+				Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(HaveLen(1))
+			})
+
+			It("does not suppress same event for different services", func() {
+				Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(HaveLen(1))
+				Expect(pipeline.Process(buildAlert("redis", "does not exist"))).To(HaveLen(1))
+			})
+
+			Context("when rate limit is reached for a service", func() {
+				BeforeEach(func() {
+					options.RateLimit = 2
+				})
+
+				It("suppresses further alerts for that service only", func() {
+					Expect(pipeline.Process(buildAlert("nats", "pid failed"))).To(HaveLen(1))
+					Expect(pipeline.Process(buildAlert("nats", "ppid failed"))).To(HaveLen(1))
+					Expect(pipeline.Process(buildAlert("nats", "uid failed"))).To(BeEmpty())
+					Expect(pipeline.Process(buildAlert("redis", "uid failed"))).To(HaveLen(1))
+				})
+			})
+
+			Context("when service changes state too often", func() {
+				BeforeEach(func() {
+					options.FlapThreshold = 2
+					options.FlapWindowSeconds = 60
+					options.RateLimit = 100
+				})
+
+				It("sends single flapping alert and suppresses alerts while flapping", func() {
+					Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(HaveLen(1))
+					Expect(pipeline.Process(buildAlert("nats", "exists"))).To(HaveLen(1))
+
+					alerts := pipeline.Process(buildAlert("nats", "does not exist"))
+					Expect(alerts).To(HaveLen(1))
+					Expect(alerts[0].Service).To(Equal("nats"))
+					Expect(alerts[0].Event).To(Equal(FlappingEvent))
+					Expect(alerts[0].Description).To(ContainSubstring("changed state 2 times within 1m0s"))
+
+					Expect(pipeline.Process(buildAlert("nats", "exists"))).To(BeEmpty())
+					Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(BeEmpty())
+				})
+
+				It("does not consider state changes outside of flap window", func() {
+					Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(HaveLen(1))
+
+					now = now.Add(61 * time.Second)
+					Expect(pipeline.Process(buildAlert("nats", "exists"))).To(Equal([]MonitAlert{buildAlert("nats", "exists")}))
+
+					now = now.Add(61 * time.Second)
+					Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(Equal([]MonitAlert{buildAlert("nats", "does not exist")}))
+				})
+
+				It("stops flapping once state changes fall out of flap window", func() {
+					Expect(pipeline.Process(buildAlert("nats", "does not exist"))).To(HaveLen(1))
+					Expect(pipeline.Process(buildAlert("nats", "exists"))).To(HaveLen(1))
+
+					alerts := pipeline.Process(buildAlert("nats", "does not exist"))
+					Expect(alerts).To(HaveLen(1))
+					Expect(alerts[0].Event).To(Equal(FlappingEvent))
+
+					now = now.Add(30 * time.Second)
+					Expect(pipeline.Process(buildAlert("nats", "exists"))).To(BeEmpty())
+
+					now = now.Add(61 * time.Second)
+					Expect(pipeline.Process(buildAlert("nats", "exists"))).To(Equal([]MonitAlert{buildAlert("nats", "exists")}))
+				})
+			})
+
+			Context("when pipeline is disabled", func() {
+				BeforeEach(func() {
+					options.Disabled = true
+				})
+
+				It("passes through all alerts", func() {
+					alert := buildAlert("nats", "does not exist")
+					Expect(pipeline.Process(alert)).To(Equal([]MonitAlert{alert}))
+					Expect(pipeline.Process(alert)).To(Equal([]MonitAlert{alert}))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,127 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	boshlog "bosh/logger"
+	boshvitals "bosh/platform/vitals"
+)
+
+const concreteThresholdCheckerLogTag = "thresholdChecker"
+
+type thresholdLevel int
+
+const (
+	thresholdLevelNone thresholdLevel = iota
+	thresholdLevelWarning
+	thresholdLevelCritical
+)
+
+type concreteThresholdChecker struct {
+	options ThresholdOptions
+	logger  boshlog.Logger
+
+	// levels holds current level of each threshold by index
+	lock   sync.Mutex
+	levels []thresholdLevel
+}
+
+func NewThresholdChecker(options ThresholdOptions, logger boshlog.Logger) ThresholdChecker {
+	return &concreteThresholdChecker{
+		options: options,
+		logger:  logger,
+		levels:  make([]thresholdLevel, len(options.Thresholds)),
+	}
+}
+
+func (c *concreteThresholdChecker) Check(vitals boshvitals.Vitals) []MonitAlert {
+	if c.options.Disabled || len(c.options.Thresholds) == 0 {
+		return nil
+	}
+
+	values, err := vitals.Values()
+	if err != nil {
+		c.logger.Error(concreteThresholdCheckerLogTag, "Getting vitals values: %s", err.Error())
+		return nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var alerts []MonitAlert
+
+	now := time.Now()
+
+	for i, threshold := range c.options.Thresholds {
+		// Missing metric (e.g. persistent disk is not mounted) keeps current level
+		value, found := values[threshold.Metric]
+		if !found {
+			continue
+		}
+
+		level := c.nextLevel(threshold, c.levels[i], value)
+		if level == c.levels[i] {
+			continue
+		}
+
+		c.levels[i] = level
+		alerts = append(alerts, c.buildAlert(threshold, level, value, now))
+	}
+
+	return alerts
+}
+
+func (c *concreteThresholdChecker) nextLevel(threshold Threshold, current thresholdLevel, value float64) thresholdLevel {
+	if exceeds(threshold.Critical, value, current >= thresholdLevelCritical, threshold.hysteresis()) {
+		return thresholdLevelCritical
+	}
+
+	if exceeds(threshold.Warning, value, current >= thresholdLevelWarning, threshold.hysteresis()) {
+		return thresholdLevelWarning
+	}
+
+	return thresholdLevelNone
+}
+
+// exceeds checks value against level; level that is
+// already reached is kept until value drops below hysteresis
+func exceeds(level, value float64, reached bool, hysteresis float64) bool {
+	if level <= 0 {
+		return false
+	}
+
+	if reached {
+		return value > level-hysteresis
+	}
+
+	return value >= level
+}
+
+func (c *concreteThresholdChecker) buildAlert(threshold Threshold, level thresholdLevel, value float64, now time.Time) MonitAlert {
+	var event, description string
+
+	switch level {
+	case thresholdLevelCritical:
+		event = ThresholdCriticalEvent
+		description = fmt.Sprintf("%s is %.1f, reached critical level %.1f", threshold.Metric, value, threshold.Critical)
+	case thresholdLevelWarning:
+		event = ThresholdWarningEvent
+		description = fmt.Sprintf("%s is %.1f, reached warning level %.1f", threshold.Metric, value, threshold.Warning)
+	default:
+		event = ThresholdRecoveredEvent
+		description = fmt.Sprintf("%s is %.1f, back below alert levels", threshold.Metric, value)
+	}
+
+	c.logger.Info(concreteThresholdCheckerLogTag, "Resource threshold changed: %s", description)
+
+	return MonitAlert{
+		ID:          fmt.Sprintf("%d.%s@localhost", now.Unix(), threshold.Metric),
+		Service:     threshold.Metric,
+		Event:       event,
+		Action:      "alert",
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	}
+}
@@ -0,0 +1,109 @@
+package alert_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/alert"
+	boshlog "bosh/logger"
+	boshvitals "bosh/platform/vitals"
+)
+
+func init() {
+	Describe("concreteThresholdChecker", func() {
+		var (
+			options ThresholdOptions
+			checker ThresholdChecker
+		)
+
+		BeforeEach(func() {
+			options = ThresholdOptions{
+				Thresholds: []Threshold{
+					{Metric: "disk.persistent.percent", Warning: 80, Critical: 90},
+					{Metric: "swap.percent", Warning: 50, Hysteresis: 10},
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			checker = NewThresholdChecker(options, logger)
+		})
+
+		buildVitals := func(diskPercent, swapPercent string) boshvitals.Vitals {
+			return boshvitals.Vitals{
+				Disk: boshvitals.DiskVitals{
+					"persistent": boshvitals.SpecificDiskVitals{Percent: diskPercent},
+				},
+				Swap: boshvitals.MemoryVitals{Percent: swapPercent},
+			}
+		}
+
+		events := func(alerts []MonitAlert) []string {
+			var events []string
+			for _, alert := range alerts {
+				events = append(events, alert.Service+": "+alert.Event)
+			}
+			return events
+		}
+
+		Describe("Check", func() {
+			It("does not alert while values are below levels", func() {
+				Expect(checker.Check(buildVitals("79", "49"))).To(BeEmpty())
+			})
+
+			It("alerts once when value reaches level", func() {
+				alerts := checker.Check(buildVitals("85", "10"))
+				Expect(alerts).To(HaveLen(1))
+				Expect(alerts[0].Service).To(Equal("disk.persistent.percent"))
+				Expect(alerts[0].Event).To(Equal(ThresholdWarningEvent))
+				Expect(alerts[0].Action).To(Equal("alert"))
+				Expect(alerts[0].Description).To(Equal("disk.persistent.percent is 85.0, reached warning level 80.0"))
+
+				Expect(checker.Check(buildVitals("86", "10"))).To(BeEmpty())
+			})
+
+			It("escalates from warning to critical", func() {
+				Expect(events(checker.Check(buildVitals("85", "10")))).To(Equal([]string{
+					"disk.persistent.percent: " + ThresholdWarningEvent,
+				}))
+				Expect(events(checker.Check(buildVitals("95", "10")))).To(Equal([]string{
+					"disk.persistent.percent: " + ThresholdCriticalEvent,
+				}))
+			})
+
+			It("keeps level until value drops below hysteresis", func() {
+				Expect(checker.Check(buildVitals("91", "60"))).To(HaveLen(2))
+
+				Expect(checker.Check(buildVitals("87", "45"))).To(BeEmpty())
+
+				Expect(events(checker.Check(buildVitals("85", "40")))).To(Equal([]string{
+					"disk.persistent.percent: " + ThresholdWarningEvent,
+					"swap.percent: " + ThresholdRecoveredEvent,
+				}))
+
+				Expect(checker.Check(buildVitals("76", "41"))).To(BeEmpty())
+
+				Expect(events(checker.Check(buildVitals("74", "41")))).To(Equal([]string{
+					"disk.persistent.percent: " + ThresholdRecoveredEvent,
+				}))
+			})
+
+			It("keeps level when metric is missing from vitals", func() {
+				Expect(checker.Check(buildVitals("85", "10"))).To(HaveLen(1))
+				Expect(checker.Check(boshvitals.Vitals{})).To(BeEmpty())
+				Expect(checker.Check(buildVitals("85", "10"))).To(BeEmpty())
+			})
+
+			Context("when resource alerts are disabled", func() {
+				BeforeEach(func() {
+					options.Disabled = true
+				})
+
+				It("does not alert", func() {
+					Expect(checker.Check(buildVitals("100", "100"))).To(BeEmpty())
+				})
+			})
+		})
+	})
+}
@@ -8,6 +8,10 @@ type FakeAlertBuilder struct {
 	BuildInput boshalert.MonitAlert
 	BuildAlert boshalert.Alert
 	BuildErr   error
+
+	BuildKernelAlertInput boshalert.KernelEvent
+	BuildKernelAlertAlert boshalert.KernelAlert
+	BuildKernelAlertErr   error
 }
 
 func NewFakeAlertBuilder() *FakeAlertBuilder {
@@ -18,3 +22,8 @@ func (b *FakeAlertBuilder) Build(input boshalert.MonitAlert) (boshalert.Alert, e
 	b.BuildInput = input
 	return b.BuildAlert, b.BuildErr
 }
+
+func (b *FakeAlertBuilder) BuildKernelAlert(input boshalert.KernelEvent) (boshalert.KernelAlert, error) {
+	b.BuildKernelAlertInput = input
+	return b.BuildKernelAlertAlert, b.BuildKernelAlertErr
+}
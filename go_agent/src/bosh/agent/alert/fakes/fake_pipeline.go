@@ -0,0 +1,19 @@
+package fakes
+
+import (
+	boshalert "bosh/agent/alert"
+)
+
+type FakePipeline struct {
+	ProcessInputs []boshalert.MonitAlert
+	ProcessAlerts []boshalert.MonitAlert
+}
+
+func NewFakePipeline() *FakePipeline {
+	return &FakePipeline{}
+}
+
+func (p *FakePipeline) Process(input boshalert.MonitAlert) []boshalert.MonitAlert {
+	p.ProcessInputs = append(p.ProcessInputs, input)
+	return p.ProcessAlerts
+}
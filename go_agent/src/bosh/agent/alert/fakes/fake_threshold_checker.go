@@ -0,0 +1,34 @@
+package fakes
+
+import (
+	"sync"
+
+	boshalert "bosh/agent/alert"
+	boshvitals "bosh/platform/vitals"
+)
+
+type FakeThresholdChecker struct {
+	lock sync.Mutex
+
+	checkInputs []boshvitals.Vitals
+	CheckAlerts []boshalert.MonitAlert
+}
+
+func NewFakeThresholdChecker() *FakeThresholdChecker {
+	return &FakeThresholdChecker{}
+}
+
+func (c *FakeThresholdChecker) Check(vitals boshvitals.Vitals) []boshalert.MonitAlert {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.checkInputs = append(c.checkInputs, vitals)
+	return c.CheckAlerts
+}
+
+func (c *FakeThresholdChecker) CheckInputs() []boshvitals.Vitals {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.checkInputs
+}
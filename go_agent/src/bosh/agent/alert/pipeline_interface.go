@@ -0,0 +1,12 @@
+package alert
+
+// FlappingEvent is used for alert sent in place of alerts
+// of service that changes its state too often
+const FlappingEvent = "flapping"
+
+// Pipeline decides which job failure alerts reach health manager
+type Pipeline interface {
+	// Process returns alerts that should be sent in place of given alert;
+	// suppressed alerts result in no alerts
+	Process(input MonitAlert) []MonitAlert
+}
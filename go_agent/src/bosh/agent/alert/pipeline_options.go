@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"time"
+)
+
+const (
+	DefaultDedupeWindowSeconds    = 60
+	DefaultRateLimit              = 10
+	DefaultRateLimitWindowSeconds = 10 * 60
+	DefaultFlapThreshold          = 5
+	DefaultFlapWindowSeconds      = 5 * 60
+)
+
+type PipelineOptions struct {
+	// Disabled forwards all alerts to health manager
+	Disabled bool
+
+	// Alerts with the same service and event are sent
+	// at most once within this many seconds
+	DedupeWindowSeconds int
+
+	// At most RateLimit alerts are sent for a single service
+	// within RateLimitWindowSeconds
+	RateLimit              int
+	RateLimitWindowSeconds int
+
+	// Service is flapping when it changes its state (alert event)
+	// FlapThreshold times within FlapWindowSeconds
+	FlapThreshold     int
+	FlapWindowSeconds int
+
+	// Now returns current time; defaults to time.Now
+	Now func() time.Time `json:"-"`
+}
+
+func (o PipelineOptions) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+	return o.Now()
+}
+
+func (o PipelineOptions) dedupeWindow() time.Duration {
+	return optionSeconds(o.DedupeWindowSeconds, DefaultDedupeWindowSeconds)
+}
+
+func (o PipelineOptions) rateLimit() int {
+	if o.RateLimit <= 0 {
+		return DefaultRateLimit
+	}
+	return o.RateLimit
+}
+
+func (o PipelineOptions) rateLimitWindow() time.Duration {
+	return optionSeconds(o.RateLimitWindowSeconds, DefaultRateLimitWindowSeconds)
+}
+
+func (o PipelineOptions) flapThreshold() int {
+	if o.FlapThreshold <= 0 {
+		return DefaultFlapThreshold
+	}
+	return o.FlapThreshold
+}
+
+func (o PipelineOptions) flapWindow() time.Duration {
+	return optionSeconds(o.FlapWindowSeconds, DefaultFlapWindowSeconds)
+}
+
+func optionSeconds(seconds, defaultSeconds int) time.Duration {
+	if seconds <= 0 {
+		return time.Duration(defaultSeconds) * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
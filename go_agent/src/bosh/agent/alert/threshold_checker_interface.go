@@ -0,0 +1,17 @@
+package alert
+
+import (
+	boshvitals "bosh/platform/vitals"
+)
+
+const (
+	ThresholdWarningEvent   = "resource threshold warning"
+	ThresholdCriticalEvent  = "resource threshold critical"
+	ThresholdRecoveredEvent = "resource threshold recovered"
+)
+
+// ThresholdChecker turns vitals crossing configured thresholds into alerts
+type ThresholdChecker interface {
+	// Check returns alerts only when metric moves between levels
+	Check(vitals boshvitals.Vitals) []MonitAlert
+}
@@ -0,0 +1,35 @@
+package alert
+
+const DefaultThresholdHysteresis = 5
+
+type ThresholdOptions struct {
+	// Disabled turns off resource alerts
+	Disabled bool
+
+	// Thresholds are checked against vitals sampled
+	// every VitalsHistory.IntervalSeconds, e.g.
+	// {"Metric": "disk.persistent.percent", "Warning": 80, "Critical": 90}
+	Thresholds []Threshold
+}
+
+type Threshold struct {
+	// Metric is dot separated path into vitals JSON
+	// e.g. "swap.percent" or "disk.system.inode_percent"
+	Metric string
+
+	// Alert is raised when value reaches Warning or Critical level;
+	// zero level is not checked
+	Warning  float64
+	Critical float64
+
+	// Raised alert is only cleared once value drops Hysteresis
+	// below the level so that alerts do not flap around the level
+	Hysteresis float64
+}
+
+func (t Threshold) hysteresis() float64 {
+	if t.Hysteresis <= 0 {
+		return DefaultThresholdHysteresis
+	}
+	return t.Hysteresis
+}
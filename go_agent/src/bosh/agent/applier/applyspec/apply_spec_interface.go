@@ -6,6 +6,6 @@ import (
 
 type ApplySpec interface {
 	Jobs() []models.Job
-	Packages() []models.Package
+	Packages() ([]models.Package, error)
 	MaxLogFileSize() string
 }
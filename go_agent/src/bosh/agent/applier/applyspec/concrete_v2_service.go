@@ -0,0 +1,53 @@
+package applyspec
+
+import (
+	"encoding/json"
+
+	bosherr "bosh/errors"
+	boshsys "bosh/system"
+)
+
+type concreteV2Service struct {
+	specFilePath string
+	fs           boshsys.FileSystem
+}
+
+func NewConcreteV2Service(fs boshsys.FileSystem, specFilePath string) (service concreteV2Service) {
+	service.fs = fs
+	service.specFilePath = specFilePath
+	return
+}
+
+func (s concreteV2Service) Get() (V2ApplySpec, error) {
+	var spec V2ApplySpec
+
+	if !s.fs.FileExists(s.specFilePath) {
+		return spec, nil
+	}
+
+	contents, err := s.fs.ReadFile(s.specFilePath)
+	if err != nil {
+		return spec, bosherr.WrapError(err, "Reading json spec file")
+	}
+
+	err = json.Unmarshal([]byte(contents), &spec)
+	if err != nil {
+		return spec, bosherr.WrapError(err, "Unmarshalling json spec file")
+	}
+
+	return spec, nil
+}
+
+func (s concreteV2Service) Set(spec V2ApplySpec) error {
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling apply spec")
+	}
+
+	err = s.fs.WriteFile(s.specFilePath, specBytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing spec to disk")
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package applyspec_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/applier/applyspec"
+	boshassert "bosh/assert"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("concreteV2Service", func() {
+	var (
+		fs       *fakesys.FakeFileSystem
+		specPath string
+		service  V2Service
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		specPath = "/spec.json"
+		service = NewConcreteV2Service(fs, specPath)
+	})
+
+	Describe("Get", func() {
+		Context("when filesystem has a V2 spec file", func() {
+			BeforeEach(func() {
+				fs.WriteFileString(specPath, `{
+					"deployment": "fake-deployment-name",
+					"jobs": [{"name": "fake-job", "version": "fake-version"}]
+				}`)
+			})
+
+			It("reads spec from filesystem", func() {
+				spec, err := service.Get()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(V2ApplySpec{
+					V1ApplySpec: V1ApplySpec{Deployment: "fake-deployment-name"},
+					JobSpecs:    []V2JobSpec{{Name: "fake-job", Version: "fake-version"}},
+				}))
+			})
+
+			It("returns error if reading spec from filesystem errs", func() {
+				fs.ReadFileError = errors.New("fake-read-error")
+
+				spec, err := service.Get()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+				Expect(spec).To(Equal(V2ApplySpec{}))
+			})
+		})
+
+		Context("when filesystem has a V1 spec file", func() {
+			BeforeEach(func() {
+				fs.WriteFileString(specPath, `{
+					"deployment": "fake-deployment-name",
+					"job": {"sha1": "fake-job-sha1", "templates": [{"name": "fake-job", "version": "fake-version"}]}
+				}`)
+			})
+
+			It("returns spec translated into V2 form", func() {
+				spec, err := service.Get()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec.Deployment).To(Equal("fake-deployment-name"))
+				Expect(spec.JobSpecs).To(Equal([]V2JobSpec{
+					{Name: "fake-job", Version: "fake-version", Sha1: "fake-job-sha1"},
+				}))
+			})
+		})
+
+		Context("when filesystem does not have a spec file", func() {
+			It("returns empty spec", func() {
+				spec, err := service.Get()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(spec).To(Equal(V2ApplySpec{}))
+			})
+		})
+	})
+
+	Describe("Set", func() {
+		newSpec := V2ApplySpec{
+			V1ApplySpec: V1ApplySpec{Deployment: "fake-deployment-name"},
+			JobSpecs:    []V2JobSpec{{Name: "fake-job"}},
+		}
+
+		It("writes spec to filesystem", func() {
+			err := service.Set(newSpec)
+			Expect(err).ToNot(HaveOccurred())
+
+			specPathStats := fs.GetFileTestStat(specPath)
+			Expect(specPathStats).ToNot(BeNil())
+			boshassert.MatchesJSONBytes(GinkgoT(), newSpec, specPathStats.Content)
+		})
+
+		It("returns error if writing spec to filesystem errs", func() {
+			fs.WriteToFileError = errors.New("fake-write-error")
+
+			err := service.Set(newSpec)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-write-error"))
+		})
+	})
+})
@@ -7,6 +7,7 @@ import (
 type FakeApplySpec struct {
 	JobResults           []models.Job
 	PackageResults       []models.Package
+	PackagesErr          error
 	MaxLogFileSizeResult string
 }
 
@@ -14,8 +15,8 @@ func (s FakeApplySpec) Jobs() []models.Job {
 	return s.JobResults
 }
 
-func (s FakeApplySpec) Packages() []models.Package {
-	return s.PackageResults
+func (s FakeApplySpec) Packages() ([]models.Package, error) {
+	return s.PackageResults, s.PackagesErr
 }
 
 func (s FakeApplySpec) MaxLogFileSize() string {
@@ -0,0 +1,32 @@
+package fakes
+
+import boshas "bosh/agent/applier/applyspec"
+
+type FakeV2Service struct {
+	Spec boshas.V2ApplySpec
+
+	GetErr error
+	SetErr error
+}
+
+func NewFakeV2Service() (service *FakeV2Service) {
+	service = &FakeV2Service{}
+	return
+}
+
+func (s *FakeV2Service) Get() (spec boshas.V2ApplySpec, err error) {
+	if s.GetErr != nil {
+		err = s.GetErr
+	}
+	spec = s.Spec
+	return
+}
+
+func (s *FakeV2Service) Set(spec boshas.V2ApplySpec) (err error) {
+	if s.SetErr != nil {
+		err = s.SetErr
+		return
+	}
+	s.Spec = spec
+	return
+}
@@ -7,3 +7,12 @@ type V1Service interface {
 
 	Set(spec V1ApplySpec) (err error)
 }
+
+type V2Service interface {
+	// Error will only be returned if Set() was used and Get() cannot retrieve saved copy.
+	// New empty spec will be returned if Set() was never used.
+	// Previously saved V1 spec is returned in V2 form.
+	Get() (spec V2ApplySpec, err error)
+
+	Set(spec V2ApplySpec) (err error)
+}
@@ -37,13 +37,17 @@ func (s V1ApplySpec) Jobs() []models.Job {
 	jobsWithSource := []models.Job{}
 	for _, j := range s.JobSpec.JobTemplateSpecsAsJobs() {
 		j.Source = s.RenderedTemplatesArchiveSpec.AsSource(j)
-		j.Packages = s.Packages()
+		j.Packages = s.packages()
 		jobsWithSource = append(jobsWithSource, j)
 	}
 	return jobsWithSource
 }
 
-func (s V1ApplySpec) Packages() []models.Package {
+func (s V1ApplySpec) Packages() ([]models.Package, error) {
+	return s.packages(), nil
+}
+
+func (s V1ApplySpec) packages() []models.Package {
 	packages := []models.Package{}
 	for _, value := range s.PackageSpecs {
 		packages = append(packages, value.AsPackage())
@@ -51,6 +55,30 @@ func (s V1ApplySpec) Packages() []models.Package {
 	return packages
 }
 
+// ToV2 translates V1 apply spec into V2 form where every job template
+// shares single rendered templates archive and depends on all packages.
+func (s V1ApplySpec) ToV2() V2ApplySpec {
+	jobSpecs := []V2JobSpec{}
+
+	for _, templateSpec := range s.JobSpec.JobTemplateSpecs {
+		jobSpecs = append(jobSpecs, V2JobSpec{
+			Name:    templateSpec.Name,
+			Version: templateSpec.Version,
+
+			// V1 drain compares sha1 of the whole job
+			Sha1: s.JobSpec.Sha1,
+
+			RenderedTemplatesArchiveSpec: s.RenderedTemplatesArchiveSpec,
+			PackageSpecs:                 s.PackageSpecs,
+		})
+	}
+
+	return V2ApplySpec{
+		V1ApplySpec: s,
+		JobSpecs:    jobSpecs,
+	}
+}
+
 func (s V1ApplySpec) MaxLogFileSize() string {
 	fileSize := s.PropertiesSpec.LoggingSpec.MaxLogFileSize
 	if len(fileSize) > 0 {
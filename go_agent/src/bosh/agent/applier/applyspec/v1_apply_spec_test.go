@@ -248,6 +248,65 @@ var _ = Describe("V1ApplySpec", func() {
 		})
 	})
 
+	Describe("ToV2", func() {
+		It("returns V2 spec where every job template shares rendered templates archive and packages", func() {
+			packageSpecs := map[string]PackageSpec{
+				"fake-package1": PackageSpec{Name: "fake-package1", Version: "fake-package1-version"},
+			}
+
+			spec := V1ApplySpec{
+				Deployment: "fake-deployment",
+				JobSpec: JobSpec{
+					Sha1: "fake-job-legacy-sha1",
+					JobTemplateSpecs: []JobTemplateSpec{
+						JobTemplateSpec{Name: "fake-job1-name", Version: "fake-job1-version", Sha1: "fake-job1-sha1"},
+						JobTemplateSpec{Name: "fake-job2-name", Version: "fake-job2-version", Sha1: "fake-job2-sha1"},
+					},
+				},
+				PackageSpecs: packageSpecs,
+				RenderedTemplatesArchiveSpec: RenderedTemplatesArchiveSpec{
+					Sha1:        "fake-rendered-templates-archive-sha1",
+					BlobstoreID: "fake-rendered-templates-archive-blobstore-id",
+				},
+			}
+
+			v2Spec := spec.ToV2()
+			Expect(v2Spec.V1ApplySpec).To(Equal(spec))
+			Expect(v2Spec.JobSpecs).To(Equal([]V2JobSpec{
+				V2JobSpec{
+					Name:                         "fake-job1-name",
+					Version:                      "fake-job1-version",
+					Sha1:                         "fake-job-legacy-sha1",
+					RenderedTemplatesArchiveSpec: spec.RenderedTemplatesArchiveSpec,
+					PackageSpecs:                 packageSpecs,
+				},
+				V2JobSpec{
+					Name:                         "fake-job2-name",
+					Version:                      "fake-job2-version",
+					Sha1:                         "fake-job-legacy-sha1",
+					RenderedTemplatesArchiveSpec: spec.RenderedTemplatesArchiveSpec,
+					PackageSpecs:                 packageSpecs,
+				},
+			}))
+		})
+
+		It("returns same jobs as V1 spec", func() {
+			spec := V1ApplySpec{
+				JobSpec: JobSpec{
+					JobTemplateSpecs: []JobTemplateSpec{
+						JobTemplateSpec{Name: "fake-job1-name", Version: "fake-job1-version"},
+					},
+				},
+				PackageSpecs: map[string]PackageSpec{
+					"fake-package1": PackageSpec{Name: "fake-package1", Version: "fake-package1-version"},
+				},
+				RenderedTemplatesArchiveSpec: RenderedTemplatesArchiveSpec{Sha1: "fake-archive-sha1"},
+			}
+
+			Expect(spec.ToV2().Jobs()).To(Equal(spec.Jobs()))
+		})
+	})
+
 	Describe("MaxLogFileSize", func() {
 		It("returns 50M if size is not provided", func() {
 			spec := V1ApplySpec{}
@@ -0,0 +1,159 @@
+package applyspec
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	models "bosh/agent/applier/models"
+	bosherr "bosh/errors"
+)
+
+// V2ApplySpec describes colocated jobs that each have
+// their own rendered templates archive, package dependencies and properties.
+// V1 fields are kept so that consumers of V1 fields
+// (e.g. director reading get_state) continue to work.
+type V2ApplySpec struct {
+	V1ApplySpec
+
+	JobSpecs []V2JobSpec `json:"jobs"`
+}
+
+type V2JobSpec struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Sha1 changes whenever job templates change (used for drain's job_changed)
+	Sha1 string `json:"sha1"`
+
+	// Archive contains a single directory named after the job
+	RenderedTemplatesArchiveSpec RenderedTemplatesArchiveSpec `json:"rendered_templates_archive"`
+
+	// Packages that this job depends on
+	PackageSpecs map[string]PackageSpec `json:"packages"`
+
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// UnmarshalJSON translates V1 apply specs (without jobs) into V2 form.
+func (s *V2ApplySpec) UnmarshalJSON(data []byte) error {
+	// Type alias prevents infinite recursion
+	type v2ApplySpec V2ApplySpec
+
+	var spec v2ApplySpec
+
+	err := json.Unmarshal(data, &spec)
+	if err != nil {
+		return err
+	}
+
+	*s = V2ApplySpec(spec)
+
+	if s.JobSpecs == nil {
+		s.JobSpecs = s.V1ApplySpec.ToV2().JobSpecs
+	}
+
+	return nil
+}
+
+// Jobs returns a list of pre-rendered job templates
+// each extracted from its own tarball.
+func (s V2ApplySpec) Jobs() []models.Job {
+	jobs := []models.Job{}
+	for _, jobSpec := range s.JobSpecs {
+		jobs = append(jobs, jobSpec.AsJob())
+	}
+	return jobs
+}
+
+// Packages returns all packages needed by any of the jobs.
+// Jobs cannot depend on different versions of the same package
+// since all packages are enabled in a single packages directory.
+func (s V2ApplySpec) Packages() ([]models.Package, error) {
+	packageSpecs := map[string]PackageSpec{}
+
+	for name, pkgSpec := range s.PackageSpecs {
+		packageSpecs[name] = pkgSpec
+	}
+
+	for _, jobSpec := range s.JobSpecs {
+		for name, pkgSpec := range jobSpec.PackageSpecs {
+			existingPkgSpec, found := packageSpecs[name]
+			if found && existingPkgSpec.Version != pkgSpec.Version {
+				return nil, bosherr.New(
+					"Job %s needs package %s version %s but version %s is needed as well",
+					jobSpec.Name, name, pkgSpec.Version, existingPkgSpec.Version,
+				)
+			}
+
+			packageSpecs[name] = pkgSpec
+		}
+	}
+
+	packages := []models.Package{}
+	for _, name := range sortedPackageNames(packageSpecs) {
+		pkgSpec := packageSpecs[name]
+		packages = append(packages, pkgSpec.AsPackage())
+	}
+	return packages, nil
+}
+
+func (s V2ApplySpec) JobNames() []string {
+	names := []string{}
+	for _, jobSpec := range s.JobSpecs {
+		names = append(names, jobSpec.Name)
+	}
+	return names
+}
+
+// JobName returns V1 job name and falls back to names of V2 jobs
+// (e.g. "job-a,job-b") since multi-job specs might not include V1 job
+func (s V2ApplySpec) JobName() *string {
+	if s.JobSpec.Name != nil {
+		return s.JobSpec.Name
+	}
+
+	names := s.JobNames()
+	if len(names) == 0 {
+		return nil
+	}
+
+	name := strings.Join(names, ",")
+
+	return &name
+}
+
+func (s V2ApplySpec) JobSpecByName(name string) (V2JobSpec, bool) {
+	for _, jobSpec := range s.JobSpecs {
+		if jobSpec.Name == name {
+			return jobSpec, true
+		}
+	}
+	return V2JobSpec{}, false
+}
+
+func (s V2JobSpec) AsJob() models.Job {
+	job := models.Job{
+		Name:    s.Name,
+		Version: s.Version,
+	}
+
+	job.Source = s.RenderedTemplatesArchiveSpec.AsSource(job)
+
+	job.Packages = []models.Package{}
+	for _, name := range sortedPackageNames(s.PackageSpecs) {
+		pkgSpec := s.PackageSpecs[name]
+		job.Packages = append(job.Packages, pkgSpec.AsPackage())
+	}
+
+	return job
+}
+
+func sortedPackageNames(packageSpecs map[string]PackageSpec) []string {
+	names := []string{}
+	for name := range packageSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
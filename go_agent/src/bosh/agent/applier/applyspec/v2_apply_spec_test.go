@@ -0,0 +1,225 @@
+package applyspec_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/applier/applyspec"
+	models "bosh/agent/applier/models"
+)
+
+var _ = Describe("V2ApplySpec", func() {
+	Describe("json unmarshalling", func() {
+		It("returns parsed apply spec from json", func() {
+			specJSON := `{
+				"deployment": "fake-deployment",
+				"configuration_hash": "fake-config-hash",
+				"jobs": [
+					{
+						"name": "fake-job-1",
+						"version": "fake-job-1-version",
+						"sha1": "fake-job-1-sha1",
+						"rendered_templates_archive": {"sha1": "fake-archive-1-sha1", "blobstore_id": "fake-archive-1-blob-id"},
+						"packages": {
+							"fake-pkg-1": {"name": "fake-pkg-1", "version": "fake-pkg-1-version", "sha1": "fake-pkg-1-sha1", "blobstore_id": "fake-pkg-1-blob-id"}
+						},
+						"properties": {"fake-prop": "fake-value"}
+					}
+				]
+			}`
+
+			var spec V2ApplySpec
+			err := json.Unmarshal([]byte(specJSON), &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(spec).To(Equal(V2ApplySpec{
+				V1ApplySpec: V1ApplySpec{
+					Deployment:        "fake-deployment",
+					ConfigurationHash: "fake-config-hash",
+				},
+				JobSpecs: []V2JobSpec{
+					{
+						Name:    "fake-job-1",
+						Version: "fake-job-1-version",
+						Sha1:    "fake-job-1-sha1",
+						RenderedTemplatesArchiveSpec: RenderedTemplatesArchiveSpec{
+							Sha1:        "fake-archive-1-sha1",
+							BlobstoreID: "fake-archive-1-blob-id",
+						},
+						PackageSpecs: map[string]PackageSpec{
+							"fake-pkg-1": {Name: "fake-pkg-1", Version: "fake-pkg-1-version", Sha1: "fake-pkg-1-sha1", BlobstoreID: "fake-pkg-1-blob-id"},
+						},
+						Properties: map[string]interface{}{"fake-prop": "fake-value"},
+					},
+				},
+			}))
+		})
+
+		It("translates V1 apply spec json into V2 form", func() {
+			specJSON := `{
+				"job": {
+					"sha1": "fake-job-sha1",
+					"templates": [
+						{"name": "fake-job-1", "version": "fake-job-1-version"},
+						{"name": "fake-job-2", "version": "fake-job-2-version"}
+					]
+				},
+				"packages": {
+					"fake-pkg-1": {"name": "fake-pkg-1", "version": "fake-pkg-1-version"}
+				},
+				"rendered_templates_archive": {"sha1": "fake-archive-sha1", "blobstore_id": "fake-archive-blob-id"}
+			}`
+
+			var spec V2ApplySpec
+			err := json.Unmarshal([]byte(specJSON), &spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			var v1Spec V1ApplySpec
+			err = json.Unmarshal([]byte(specJSON), &v1Spec)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(spec).To(Equal(v1Spec.ToV2()))
+			Expect(spec.JobNames()).To(Equal([]string{"fake-job-1", "fake-job-2"}))
+		})
+	})
+
+	Describe("Jobs", func() {
+		It("returns jobs with their own templates archive and package dependencies", func() {
+			spec := V2ApplySpec{
+				JobSpecs: []V2JobSpec{
+					{
+						Name:    "fake-job-1",
+						Version: "fake-job-1-version",
+						RenderedTemplatesArchiveSpec: RenderedTemplatesArchiveSpec{
+							Sha1:        "fake-archive-1-sha1",
+							BlobstoreID: "fake-archive-1-blob-id",
+						},
+						PackageSpecs: map[string]PackageSpec{
+							"fake-pkg-1": {Name: "fake-pkg-1", Version: "fake-pkg-1-version", Sha1: "fake-pkg-1-sha1", BlobstoreID: "fake-pkg-1-blob-id"},
+						},
+					},
+					{
+						Name:    "fake-job-2",
+						Version: "fake-job-2-version",
+						RenderedTemplatesArchiveSpec: RenderedTemplatesArchiveSpec{
+							Sha1:        "fake-archive-2-sha1",
+							BlobstoreID: "fake-archive-2-blob-id",
+						},
+					},
+				},
+			}
+
+			Expect(spec.Jobs()).To(Equal([]models.Job{
+				{
+					Name:    "fake-job-1",
+					Version: "fake-job-1-version",
+					Source: models.Source{
+						Sha1:          "fake-archive-1-sha1",
+						BlobstoreID:   "fake-archive-1-blob-id",
+						PathInArchive: "fake-job-1",
+					},
+					Packages: []models.Package{
+						{
+							Name:    "fake-pkg-1",
+							Version: "fake-pkg-1-version",
+							Source:  models.Source{Sha1: "fake-pkg-1-sha1", BlobstoreID: "fake-pkg-1-blob-id"},
+						},
+					},
+				},
+				{
+					Name:    "fake-job-2",
+					Version: "fake-job-2-version",
+					Source: models.Source{
+						Sha1:          "fake-archive-2-sha1",
+						BlobstoreID:   "fake-archive-2-blob-id",
+						PathInArchive: "fake-job-2",
+					},
+					Packages: []models.Package{},
+				},
+			}))
+		})
+	})
+
+	Describe("Packages", func() {
+		It("returns packages of all jobs without duplicates", func() {
+			pkg1 := PackageSpec{Name: "fake-pkg-1", Version: "fake-pkg-1-version"}
+			pkg2 := PackageSpec{Name: "fake-pkg-2", Version: "fake-pkg-2-version"}
+			pkg3 := PackageSpec{Name: "fake-pkg-3", Version: "fake-pkg-3-version"}
+
+			spec := V2ApplySpec{
+				V1ApplySpec: V1ApplySpec{
+					PackageSpecs: map[string]PackageSpec{"fake-pkg-3": pkg3},
+				},
+				JobSpecs: []V2JobSpec{
+					{Name: "fake-job-1", PackageSpecs: map[string]PackageSpec{"fake-pkg-1": pkg1, "fake-pkg-2": pkg2}},
+					{Name: "fake-job-2", PackageSpecs: map[string]PackageSpec{"fake-pkg-2": pkg2}},
+				},
+			}
+
+			Expect(spec.Packages()).To(Equal([]models.Package{
+				pkg1.AsPackage(),
+				pkg2.AsPackage(),
+				pkg3.AsPackage(),
+			}))
+		})
+
+		It("returns error when jobs need different versions of the same package", func() {
+			pkg1 := PackageSpec{Name: "fake-pkg", Version: "fake-pkg-version-1"}
+			pkg2 := PackageSpec{Name: "fake-pkg", Version: "fake-pkg-version-2"}
+
+			spec := V2ApplySpec{
+				JobSpecs: []V2JobSpec{
+					{Name: "fake-job-1", PackageSpecs: map[string]PackageSpec{"fake-pkg": pkg1}},
+					{Name: "fake-job-2", PackageSpecs: map[string]PackageSpec{"fake-pkg": pkg2}},
+				},
+			}
+
+			_, err := spec.Packages()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Job fake-job-2 needs package fake-pkg version fake-pkg-version-2"))
+			Expect(err.Error()).To(ContainSubstring("version fake-pkg-version-1 is needed as well"))
+		})
+	})
+
+	Describe("JobName", func() {
+		It("returns V1 job name when it is included", func() {
+			jobName := "fake-v1-job"
+
+			spec := V2ApplySpec{
+				V1ApplySpec: V1ApplySpec{JobSpec: JobSpec{Name: &jobName}},
+				JobSpecs:    []V2JobSpec{{Name: "fake-job-1"}, {Name: "fake-job-2"}},
+			}
+
+			Expect(*spec.JobName()).To(Equal("fake-v1-job"))
+		})
+
+		It("returns names of V2 jobs when V1 job is not included", func() {
+			spec := V2ApplySpec{
+				JobSpecs: []V2JobSpec{{Name: "fake-job-1"}, {Name: "fake-job-2"}},
+			}
+
+			Expect(*spec.JobName()).To(Equal("fake-job-1,fake-job-2"))
+		})
+
+		It("returns nil when there are no jobs", func() {
+			Expect(V2ApplySpec{}.JobName()).To(BeNil())
+		})
+	})
+
+	Describe("JobSpecByName", func() {
+		It("returns job spec with given name", func() {
+			spec := V2ApplySpec{
+				JobSpecs: []V2JobSpec{{Name: "fake-job-1"}, {Name: "fake-job-2", Version: "fake-version"}},
+			}
+
+			jobSpec, found := spec.JobSpecByName("fake-job-2")
+			Expect(found).To(BeTrue())
+			Expect(jobSpec).To(Equal(V2JobSpec{Name: "fake-job-2", Version: "fake-version"}))
+
+			_, found = spec.JobSpecByName("fake-job-3")
+			Expect(found).To(BeFalse())
+		})
+	})
+})
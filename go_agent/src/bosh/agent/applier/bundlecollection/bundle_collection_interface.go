@@ -23,6 +23,10 @@ type Bundle interface {
 	IsInstalled() (bool, error)
 	GetInstallPath() (fs boshsys.FileSystem, path string, err error)
 
+	// Verify returns error if installed contents
+	// do not match contents at installation time
+	Verify() (err error)
+
 	Enable() (fs boshsys.FileSystem, path string, err error)
 	Disable() (err error)
 }
@@ -0,0 +1,144 @@
+package bundlecollection
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	bosherr "bosh/errors"
+	boshsys "bosh/system"
+)
+
+// bundleManifest records hashes of regular files in an installed bundle.
+// Files added after installation (e.g. job specific package symlinks)
+// are not part of the manifest and are ignored during verification.
+type bundleManifest struct {
+	// Keyed by file path relative to install path
+	Files map[string]string `json:"files"`
+}
+
+func buildBundleManifest(fs boshsys.FileSystem, dir string) (bundleManifest, error) {
+	manifest := bundleManifest{Files: map[string]string{}}
+
+	err := fs.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return bosherr.WrapError(err, "Determining relative path of %s", path)
+		}
+
+		fileSha1, err := fileSha1(fs, path)
+		if err != nil {
+			return err
+		}
+
+		manifest.Files[relPath] = fileSha1
+
+		return nil
+	})
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Hashing files in %s", dir)
+	}
+
+	return manifest, nil
+}
+
+func readBundleManifest(fs boshsys.FileSystem, path string) (bundleManifest, error) {
+	var manifest bundleManifest
+
+	contents, err := fs.ReadFile(path)
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Reading bundle manifest")
+	}
+
+	err = json.Unmarshal(contents, &manifest)
+	if err != nil {
+		return manifest, bosherr.WrapError(err, "Unmarshalling bundle manifest")
+	}
+
+	return manifest, nil
+}
+
+func (m bundleManifest) Write(fs boshsys.FileSystem, path string) error {
+	contents, err := json.Marshal(m)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling bundle manifest")
+	}
+
+	err = fs.WriteFile(path, contents)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing bundle manifest")
+	}
+
+	return nil
+}
+
+// Verify returns error describing every recorded file
+// that is missing or whose contents changed.
+func (m bundleManifest) Verify(fs boshsys.FileSystem, dir string) error {
+	var problems []string
+
+	for _, relPath := range m.sortedPaths() {
+		path := filepath.Join(dir, relPath)
+
+		if !fs.FileExists(path) {
+			problems = append(problems, fmt.Sprintf("%s is missing", relPath))
+			continue
+		}
+
+		actualSha1, err := fileSha1(fs, path)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s cannot be hashed: %s", relPath, err.Error()))
+			continue
+		}
+
+		if actualSha1 != m.Files[relPath] {
+			problems = append(problems, fmt.Sprintf("%s has SHA1 %s instead of %s", relPath, actualSha1, m.Files[relPath]))
+		}
+	}
+
+	if len(problems) > 0 {
+		return bosherr.New("Bundle files do not match manifest: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+func (m bundleManifest) sortedPaths() []string {
+	var paths []string
+	for path := range m.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func fileSha1(fs boshsys.FileSystem, path string) (string, error) {
+	file, err := fs.OpenFile(path)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening %s", path)
+	}
+
+	defer file.Close()
+
+	h := sha1.New()
+
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Reading %s", path)
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -18,6 +18,9 @@ type FakeBundle struct {
 
 	IsInstalledErr error
 
+	Verified  bool
+	VerifyErr error
+
 	GetDirPath  string
 	GetDirFs    boshsys.FileSystem
 	GetDirError error
@@ -63,6 +66,12 @@ func (s *FakeBundle) IsInstalled() (bool, error) {
 	return s.Installed, s.IsInstalledErr
 }
 
+func (s *FakeBundle) Verify() error {
+	s.Verified = true
+	s.ActionsCalled = append(s.ActionsCalled, "Verify")
+	return s.VerifyErr
+}
+
 func (s *FakeBundle) Enable() (boshsys.FileSystem, string, error) {
 	s.Enabled = true
 	s.ActionsCalled = append(s.ActionsCalled, "Enable")
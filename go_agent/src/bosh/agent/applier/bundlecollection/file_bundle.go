@@ -16,22 +16,24 @@ const (
 )
 
 type FileBundle struct {
-	installPath string
-	enablePath  string
-	fs          boshsys.FileSystem
-	logger      boshlog.Logger
+	installPath  string
+	enablePath   string
+	manifestPath string
+	fs           boshsys.FileSystem
+	logger       boshlog.Logger
 }
 
 func NewFileBundle(
-	installPath, enablePath string,
+	installPath, enablePath, manifestPath string,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 ) FileBundle {
 	return FileBundle{
-		installPath: installPath,
-		enablePath:  enablePath,
-		fs:          fs,
-		logger:      logger,
+		installPath:  installPath,
+		enablePath:   enablePath,
+		manifestPath: manifestPath,
+		fs:           fs,
+		logger:       logger,
 	}
 }
 
@@ -48,6 +50,13 @@ func (b FileBundle) Install(sourcePath string) (boshsys.FileSystem, string, erro
 		return nil, "", bosherr.WrapError(err, "Creating parent installation directory")
 	}
 
+	// Manifest of source files is the same as of installed files
+	// since they are only moved to installation directory
+	err = b.writeManifest(sourcePath)
+	if err != nil {
+		return nil, "", err
+	}
+
 	// Rename MUST be the last possibly-failing operation
 	// because IsInstalled() relies on installPath presence.
 	err = b.fs.Rename(sourcePath, b.installPath)
@@ -84,6 +93,29 @@ func (b FileBundle) IsInstalled() (bool, error) {
 	return b.fs.FileExists(b.installPath), nil
 }
 
+// Verify compares installed files with hashes recorded during Install.
+// Bundles installed without contents or before manifests
+// were recorded cannot be verified and are considered intact.
+func (b FileBundle) Verify() error {
+	b.logger.Debug(fileBundleLogTag, "Verifying %v", b)
+
+	if !b.fs.FileExists(b.installPath) {
+		return bosherr.New("bundle must be installed")
+	}
+
+	if !b.fs.FileExists(b.manifestPath) {
+		b.logger.Debug(fileBundleLogTag, "Skipping verification of %v without manifest", b)
+		return nil
+	}
+
+	manifest, err := readBundleManifest(b.fs, b.manifestPath)
+	if err != nil {
+		return err
+	}
+
+	return manifest.Verify(b.fs, b.installPath)
+}
+
 func (b FileBundle) Enable() (boshsys.FileSystem, string, error) {
 	b.logger.Debug(fileBundleLogTag, "Enabling %v", b)
 
@@ -125,7 +157,26 @@ func (b FileBundle) Disable() error {
 func (b FileBundle) Uninstall() error {
 	b.logger.Debug(fileBundleLogTag, "Uninstalling %v", b)
 
+	err := b.fs.RemoveAll(b.manifestPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing bundle manifest")
+	}
+
 	// RemoveAll MUST be the last possibly-failing operation
 	// because IsInstalled() relies on installPath presence.
 	return b.fs.RemoveAll(b.installPath)
 }
+
+func (b FileBundle) writeManifest(sourcePath string) error {
+	manifest, err := buildBundleManifest(b.fs, sourcePath)
+	if err != nil {
+		return bosherr.WrapError(err, "Building bundle manifest")
+	}
+
+	err = b.fs.MkdirAll(filepath.Dir(b.manifestPath), installDirsPerms)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating bundle manifest directory")
+	}
+
+	return manifest.Write(b.fs, b.manifestPath)
+}
@@ -8,7 +8,10 @@ import (
 	boshsys "bosh/system"
 )
 
-const fileBundleCollectionLogTag = "FileBundleCollection"
+const (
+	fileBundleCollectionLogTag = "FileBundleCollection"
+	manifestsDirName           = "bundle_manifests"
+)
 
 type fileBundleDefinition struct {
 	name    string
@@ -70,7 +73,11 @@ func (bc FileBundleCollection) Get(definition BundleDefinition) (Bundle, error)
 
 	installPath := filepath.Join(bc.installPath, bc.name, definition.BundleName(), definition.BundleVersion())
 	enablePath := filepath.Join(bc.enablePath, bc.name, definition.BundleName())
-	return NewFileBundle(installPath, enablePath, bc.fs, bc.logger), nil
+
+	// Manifests are kept outside of bundle collection dir so that they are not listed as bundles
+	manifestPath := filepath.Join(bc.installPath, manifestsDirName, bc.name, definition.BundleName(), definition.BundleVersion()+".json")
+
+	return NewFileBundle(installPath, enablePath, manifestPath, bc.fs, bc.logger), nil
 }
 
 func (bc FileBundleCollection) List() ([]Bundle, error) {
@@ -51,6 +51,7 @@ var _ = Describe("FileBundleCollection", func() {
 			expectedBundle := NewFileBundle(
 				"/fake-collection-path/data/fake-collection-name/fake-bundle-name/fake-bundle-version",
 				"/fake-collection-path/fake-collection-name/fake-bundle-name",
+				"/fake-collection-path/data/bundle_manifests/fake-collection-name/fake-bundle-name/fake-bundle-version.json",
 				fs,
 				logger,
 			)
@@ -78,6 +79,7 @@ var _ = Describe("FileBundleCollection", func() {
 	Describe("List", func() {
 		installPath := "/fake-collection-path/data/fake-collection-name"
 		enablePath := "/fake-collection-path/fake-collection-name"
+		manifestPath := "/fake-collection-path/data/bundle_manifests/fake-collection-name"
 
 		It("returns list of installed bundles", func() {
 			fs.SetGlob(installPath+"/*/*", []string{
@@ -93,18 +95,21 @@ var _ = Describe("FileBundleCollection", func() {
 				NewFileBundle(
 					installPath+"/fake-bundle-1-name/fake-bundle-1-version-1",
 					enablePath+"/fake-bundle-1-name",
+					manifestPath+"/fake-bundle-1-name/fake-bundle-1-version-1.json",
 					fs,
 					logger,
 				),
 				NewFileBundle(
 					installPath+"/fake-bundle-1-name/fake-bundle-1-version-2",
 					enablePath+"/fake-bundle-1-name",
+					manifestPath+"/fake-bundle-1-name/fake-bundle-1-version-2.json",
 					fs,
 					logger,
 				),
 				NewFileBundle(
 					installPath+"/fake-bundle-2-name/fake-bundle-2-version-1",
 					enablePath+"/fake-bundle-2-name",
+					manifestPath+"/fake-bundle-2-name/fake-bundle-2-version-1.json",
 					fs,
 					logger,
 				),
@@ -14,20 +14,22 @@ import (
 
 var _ = Describe("FileBundle", func() {
 	var (
-		fs          *fakesys.FakeFileSystem
-		logger      boshlog.Logger
-		sourcePath  string
-		installPath string
-		enablePath  string
-		fileBundle  FileBundle
+		fs           *fakesys.FakeFileSystem
+		logger       boshlog.Logger
+		sourcePath   string
+		installPath  string
+		enablePath   string
+		manifestPath string
+		fileBundle   FileBundle
 	)
 
 	BeforeEach(func() {
 		fs = fakesys.NewFakeFileSystem()
 		installPath = "/install-path"
 		enablePath = "/enable-path"
+		manifestPath = "/manifests/install-path.json"
 		logger = boshlog.NewLogger(boshlog.LevelNone)
-		fileBundle = NewFileBundle(installPath, enablePath, fs, logger)
+		fileBundle = NewFileBundle(installPath, enablePath, manifestPath, fs, logger)
 	})
 
 	createSourcePath := func() string {
@@ -89,6 +91,30 @@ var _ = Describe("FileBundle", func() {
 			Expect(fs.RenameNewPaths[0]).To(Equal(installPath))
 		})
 
+		It("records manifest of hashes of installed files", func() {
+			fs.WriteFileString(sourcePath+"/bin/ctl", "fake-ctl")
+			fs.WriteFileString(sourcePath+"/monit", "fake-monit")
+			fs.Symlink("/fake-target", sourcePath+"/fake-symlink")
+
+			_, _, err := fileBundle.Install(sourcePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			manifest, err := fs.ReadFileString(manifestPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manifest).To(Equal(`{"files":{` +
+				`"bin/ctl":"8999a6519ea8925f177788536e29f46162a6cfb8",` +
+				`"monit":"809549a4b733bc50c920d39884f3f55adbdbadd1"}}`))
+		})
+
+		It("returns error and does not install bundle when hashing installed files fails", func() {
+			fs.WalkErr = errors.New("fake-walk-error")
+
+			_, _, err := fileBundle.Install(sourcePath)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-walk-error"))
+			Expect(fs.FileExists(installPath)).To(BeFalse())
+		})
+
 		It("returns error when moving source to install path fails", func() {
 			fs.RenameError = errors.New("fake-rename-error")
 
@@ -302,7 +328,7 @@ var _ = Describe("FileBundle", func() {
 				_, _, err = fileBundle.Enable()
 				Expect(err).NotTo(HaveOccurred())
 
-				newerFileBundle := NewFileBundle(newerInstallPath, enablePath, fs, logger)
+				newerFileBundle := NewFileBundle(newerInstallPath, enablePath, "/manifests/newer-install-path.json", fs, logger)
 
 				otherSourcePath := createSourcePath()
 				_, _, err = newerFileBundle.Install(otherSourcePath)
@@ -334,6 +360,65 @@ var _ = Describe("FileBundle", func() {
 		})
 	})
 
+	Describe("Verify", func() {
+		BeforeEach(func() {
+			fs.WriteFileString(sourcePath+"/bin/ctl", "fake-ctl")
+			fs.WriteFileString(sourcePath+"/monit", "fake-monit")
+
+			_, _, err := fileBundle.Install(sourcePath)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Fake file system does not move directory contents
+			fs.WriteFileString(installPath+"/bin/ctl", "fake-ctl")
+			fs.WriteFileString(installPath+"/monit", "fake-monit")
+		})
+
+		It("returns no error when installed files match manifest", func() {
+			err := fileBundle.Verify()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("ignores files added after installation", func() {
+			fs.WriteFileString(installPath+"/packages/fake-pkg", "fake-pkg")
+
+			err := fileBundle.Verify()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns error when installed file was changed", func() {
+			fs.WriteFileString(installPath+"/monit", "fake-changed-monit")
+
+			err := fileBundle.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("monit has SHA1"))
+			Expect(err.Error()).ToNot(ContainSubstring("bin/ctl"))
+		})
+
+		It("returns error when installed file is missing", func() {
+			fs.RemoveAll(installPath + "/bin/ctl")
+
+			err := fileBundle.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("bin/ctl is missing"))
+		})
+
+		It("returns no error when bundle does not have manifest", func() {
+			fs.RemoveAll(manifestPath)
+			fs.WriteFileString(installPath+"/monit", "fake-changed-monit")
+
+			err := fileBundle.Verify()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns error when bundle is not installed", func() {
+			fs.RemoveAll(installPath)
+
+			err := fileBundle.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("bundle must be installed"))
+		})
+	})
+
 	Describe("Uninstall", func() {
 		It("removes the files from disk", func() {
 			_, _, err := fileBundle.Install(sourcePath)
@@ -343,6 +428,7 @@ var _ = Describe("FileBundle", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fs.FileExists(installPath)).To(BeFalse())
+			Expect(fs.FileExists(manifestPath)).To(BeFalse())
 		})
 
 		It("is idempotent", func() {
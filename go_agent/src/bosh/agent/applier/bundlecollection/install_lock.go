@@ -0,0 +1,34 @@
+package bundlecollection
+
+import (
+	"sync"
+)
+
+// InstallLock serializes installation of the same bundle
+// so that concurrent Prepare calls do not download and install it twice.
+type InstallLock struct {
+	mutex sync.Mutex
+	locks map[string]*sync.Mutex
+}
+
+func NewInstallLock() *InstallLock {
+	return &InstallLock{locks: map[string]*sync.Mutex{}}
+}
+
+// Lock blocks until no one else is installing bundle with given definition.
+// Returned func must be called to release the lock.
+func (l *InstallLock) Lock(definition BundleDefinition) func() {
+	key := definition.BundleName() + "/" + definition.BundleVersion()
+
+	l.mutex.Lock()
+	bundleLock, found := l.locks[key]
+	if !found {
+		bundleLock = &sync.Mutex{}
+		l.locks[key] = bundleLock
+	}
+	l.mutex.Unlock()
+
+	bundleLock.Lock()
+
+	return bundleLock.Unlock
+}
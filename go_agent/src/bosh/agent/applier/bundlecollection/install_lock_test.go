@@ -0,0 +1,61 @@
+package bundlecollection_test
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/applier/bundlecollection"
+	models "bosh/agent/applier/models"
+)
+
+var _ = Describe("InstallLock", func() {
+	var (
+		lock *InstallLock
+	)
+
+	BeforeEach(func() {
+		lock = NewInstallLock()
+	})
+
+	It("does not allow same bundle to be locked twice at the same time", func() {
+		pkg := models.Package{Name: "fake-name", Version: "fake-version"}
+
+		unlock := lock.Lock(pkg)
+
+		lockedCh := make(chan struct{})
+		go func() {
+			lock.Lock(pkg)()
+			close(lockedCh)
+		}()
+
+		Consistently(lockedCh, 50*time.Millisecond).ShouldNot(BeClosed())
+
+		unlock()
+
+		Eventually(lockedCh).Should(BeClosed())
+	})
+
+	It("allows different bundles to be locked at the same time", func() {
+		pkg1 := models.Package{Name: "fake-name-1", Version: "fake-version"}
+		pkg2 := models.Package{Name: "fake-name-2", Version: "fake-version"}
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+
+		unlock1 := lock.Lock(pkg1)
+		go func() {
+			defer wg.Done()
+			lock.Lock(pkg2)()
+		}()
+		go func() {
+			defer wg.Done()
+			lock.Lock(models.Package{Name: "fake-name-2", Version: "other-version"})()
+		}()
+
+		wg.Wait()
+		unlock1()
+	})
+})
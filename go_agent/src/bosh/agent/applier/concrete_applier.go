@@ -1,21 +1,45 @@
 package applier
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
 	as "bosh/agent/applier/applyspec"
 	ja "bosh/agent/applier/jobapplier"
+	models "bosh/agent/applier/models"
 	pa "bosh/agent/applier/packageapplier"
+	boshscript "bosh/agent/script"
 	bosherr "bosh/errors"
 	boshjobsuper "bosh/jobsupervisor"
+	boshlog "bosh/logger"
 	boshsettings "bosh/settings"
 	boshdirs "bosh/settings/directories"
+	boshsys "bosh/system"
 )
 
+const logTag = "concreteApplier"
+
+// DefaultMaxParallelDownloads is used when Options do not specify a limit
+const DefaultMaxParallelDownloads = 5
+
+type Options struct {
+	// Maximum number of job and package blobs
+	// that are downloaded and installed at the same time
+	MaxParallelDownloads int
+}
+
 type concreteApplier struct {
 	jobApplier        ja.JobApplier
 	packageApplier    pa.PackageApplier
 	logrotateDelegate LogrotateDelegate
 	jobSupervisor     boshjobsuper.JobSupervisor
+	scriptProvider    boshscript.ScriptProvider
 	dirProvider       boshdirs.DirectoriesProvider
+	fs                boshsys.FileSystem
+	options           Options
+	logger            boshlog.Logger
 }
 
 func NewConcreteApplier(
@@ -23,37 +47,150 @@ func NewConcreteApplier(
 	packageApplier pa.PackageApplier,
 	logrotateDelegate LogrotateDelegate,
 	jobSupervisor boshjobsuper.JobSupervisor,
+	scriptProvider boshscript.ScriptProvider,
 	dirProvider boshdirs.DirectoriesProvider,
+	fs boshsys.FileSystem,
+	options Options,
+	logger boshlog.Logger,
 ) *concreteApplier {
+	if options.MaxParallelDownloads < 1 {
+		options.MaxParallelDownloads = DefaultMaxParallelDownloads
+	}
+
 	return &concreteApplier{
 		jobApplier:        jobApplier,
 		packageApplier:    packageApplier,
 		logrotateDelegate: logrotateDelegate,
 		jobSupervisor:     jobSupervisor,
+		scriptProvider:    scriptProvider,
 		dirProvider:       dirProvider,
+		fs:                fs,
+		options:           options,
+		logger:            logger,
 	}
 }
 
+type prepareStep struct {
+	description string
+	prepare     func() error
+}
+
 func (a *concreteApplier) Prepare(desiredApplySpec as.ApplySpec) error {
+	var steps []prepareStep
+
 	for _, job := range desiredApplySpec.Jobs() {
-		err := a.jobApplier.Prepare(job)
-		if err != nil {
-			return bosherr.WrapError(err, "Preparing job %s", job.Name)
-		}
+		job := job
+		steps = append(steps, prepareStep{
+			description: fmt.Sprintf("job %s", job.Name),
+			prepare:     func() error { return a.jobApplier.Prepare(job) },
+		})
 	}
 
-	for _, pkg := range desiredApplySpec.Packages() {
-		err := a.packageApplier.Prepare(pkg)
-		if err != nil {
-			return bosherr.WrapError(err, "Preparing package %s", pkg.Name)
+	pkgs, err := desiredApplySpec.Packages()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting desired packages")
+	}
+
+	for _, pkg := range pkgs {
+		pkg := pkg
+		steps = append(steps, prepareStep{
+			description: fmt.Sprintf("package %s", pkg.Name),
+			prepare:     func() error { return a.packageApplier.Prepare(pkg) },
+		})
+	}
+
+	return a.runPrepareSteps(steps)
+}
+
+// runPrepareSteps downloads and installs bundles using at most
+// MaxParallelDownloads goroutines. No new steps are started after
+// the first failure; all failures of already started steps are reported.
+func (a *concreteApplier) runPrepareSteps(steps []prepareStep) error {
+	var (
+		wg       sync.WaitGroup
+		mutex    sync.Mutex
+		failures []string
+	)
+
+	semaphore := make(chan struct{}, a.options.MaxParallelDownloads)
+
+	for _, step := range steps {
+		semaphore <- struct{}{}
+
+		mutex.Lock()
+		failed := len(failures) > 0
+		mutex.Unlock()
+
+		if failed {
+			<-semaphore
+			break
 		}
+
+		wg.Add(1)
+
+		go func(step prepareStep) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			err := step.prepare()
+			if err != nil {
+				mutex.Lock()
+				failures = append(failures, fmt.Sprintf("Preparing %s: %s", step.description, err.Error()))
+				mutex.Unlock()
+			}
+		}(step)
+	}
+
+	wg.Wait()
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return bosherr.New("Preparing %d of %d bundles failed: %s", len(failures), len(steps), strings.Join(failures, "; "))
 	}
 
 	return nil
 }
 
 func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec) error {
-	err := a.jobSupervisor.RemoveAllJobs()
+	// Download all bundles before touching running jobs
+	// so that a failed download leaves VM in its previous state
+	err := a.Prepare(desiredApplySpec)
+	if err != nil {
+		return bosherr.WrapError(err, "Preparing desired apply spec")
+	}
+
+	snapshot, err := a.takeSnapshot()
+	if err != nil {
+		return bosherr.WrapError(err, "Taking snapshot of current state")
+	}
+
+	err = a.applyJobsAndPackages(currentApplySpec, desiredApplySpec)
+	if err != nil {
+		a.logger.Error(logTag, "Apply failed, restoring previous state: %s", err.Error())
+
+		return RollbackError{
+			ApplyErr:    err,
+			RollbackErr: a.restoreSnapshot(snapshot, currentApplySpec),
+		}
+	}
+
+	return a.setUpLogrotate(desiredApplySpec)
+}
+
+func (a *concreteApplier) applyJobsAndPackages(currentApplySpec, desiredApplySpec as.ApplySpec) error {
+	currentPkgs, err := currentApplySpec.Packages()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting current packages")
+	}
+
+	desiredPkgs, err := desiredApplySpec.Packages()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting desired packages")
+	}
+
+	err = a.jobSupervisor.RemoveAllJobs()
 	if err != nil {
 		return bosherr.WrapError(err, "Removing all jobs")
 	}
@@ -71,24 +208,29 @@ func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec)
 		return bosherr.WrapError(err, "Keeping only needed jobs")
 	}
 
-	for _, pkg := range desiredApplySpec.Packages() {
+	for _, pkg := range desiredPkgs {
 		err = a.packageApplier.Apply(pkg)
 		if err != nil {
 			return bosherr.WrapError(err, "Applying package %s", pkg.Name)
 		}
 	}
 
-	err = a.packageApplier.KeepOnly(append(currentApplySpec.Packages(), desiredApplySpec.Packages()...))
+	err = a.packageApplier.KeepOnly(append(currentPkgs, desiredPkgs...))
 	if err != nil {
 		return bosherr.WrapError(err, "Keeping only needed packages")
 	}
 
-	for i := 0; i < len(jobs); i++ {
-		job := jobs[len(jobs)-1-i]
+	err = a.configureJobs(jobs)
+	if err != nil {
+		return err
+	}
 
-		err = a.jobApplier.Configure(job, i)
+	// Jobs are not monitored yet so pre-start scripts
+	// can prepare anything that job processes need
+	for _, job := range jobs {
+		err = a.runPreStartScript(job.Name)
 		if err != nil {
-			return bosherr.WrapError(err, "Configuring job %s", job.Name)
+			return bosherr.WrapError(err, "Running pre-start script for job %s", job.Name)
 		}
 	}
 
@@ -97,7 +239,29 @@ func (a *concreteApplier) Apply(currentApplySpec, desiredApplySpec as.ApplySpec)
 		return bosherr.WrapError(err, "Reloading jobSupervisor")
 	}
 
-	return a.setUpLogrotate(desiredApplySpec)
+	return nil
+}
+
+func (a *concreteApplier) configureJobs(jobs []models.Job) error {
+	for i := 0; i < len(jobs); i++ {
+		job := jobs[len(jobs)-1-i]
+
+		err := a.jobApplier.Configure(job, i)
+		if err != nil {
+			return bosherr.WrapError(err, "Configuring job %s", job.Name)
+		}
+	}
+
+	return nil
+}
+
+func (a *concreteApplier) runPreStartScript(jobName string) error {
+	script := a.scriptProvider.NewScript(jobName, "pre-start")
+	if !script.Exists() {
+		return nil
+	}
+
+	return script.Run()
 }
 
 func (a *concreteApplier) setUpLogrotate(applySpec as.ApplySpec) error {
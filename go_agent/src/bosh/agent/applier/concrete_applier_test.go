@@ -2,6 +2,8 @@ package applier_test
 
 import (
 	"errors"
+	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -12,9 +14,12 @@ import (
 	fakeja "bosh/agent/applier/jobapplier/fakes"
 	models "bosh/agent/applier/models"
 	fakepa "bosh/agent/applier/packageapplier/fakes"
+	fakescript "bosh/agent/script/fakes"
 	fakejobsuper "bosh/jobsupervisor/fakes"
+	boshlog "bosh/logger"
 	boshsettings "bosh/settings"
 	boshdirs "bosh/settings/directories"
+	fakesys "bosh/system/fakes"
 	boshuuid "bosh/uuid"
 )
 
@@ -55,6 +60,8 @@ func init() {
 			packageApplier    *fakepa.FakePackageApplier
 			logRotateDelegate *FakeLogRotateDelegate
 			jobSupervisor     *fakejobsuper.FakeJobSupervisor
+			scriptProvider    *fakescript.FakeScriptProvider
+			fs                *fakesys.FakeFileSystem
 			applier           Applier
 		)
 
@@ -63,12 +70,18 @@ func init() {
 			packageApplier = fakepa.NewFakePackageApplier()
 			logRotateDelegate = &FakeLogRotateDelegate{}
 			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+			scriptProvider = fakescript.NewFakeScriptProvider()
+			fs = fakesys.NewFakeFileSystem()
 			applier = NewConcreteApplier(
 				jobApplier,
 				packageApplier,
 				logRotateDelegate,
 				jobSupervisor,
+				scriptProvider,
 				boshdirs.NewDirectoriesProvider("/fake-base-dir"),
+				fs,
+				Options{MaxParallelDownloads: 2},
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 		})
 
@@ -103,7 +116,7 @@ func init() {
 					&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg1, pkg2}},
 				)
 				Expect(err).ToNot(HaveOccurred())
-				Expect(packageApplier.PreparedPackages).To(Equal([]models.Package{pkg1, pkg2}))
+				Expect(packageApplier.PreparedPackages).To(ConsistOf(pkg1, pkg2))
 			})
 
 			It("returns error when preparing packages fails", func() {
@@ -117,9 +130,101 @@ func init() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-prepare-package-error"))
 			})
+
+			It("prepares at most MaxParallelDownloads bundles at the same time", func() {
+				var (
+					lock             sync.Mutex
+					running          int
+					maxRunning       int
+					pkgs             []models.Package
+					startedAllowedCh = make(chan struct{})
+				)
+
+				for i := 0; i < 6; i++ {
+					pkgs = append(pkgs, buildPackage())
+				}
+
+				packageApplier.PrepareCallBack = func(_ models.Package) error {
+					lock.Lock()
+					running++
+					if running > maxRunning {
+						maxRunning = running
+					}
+					lock.Unlock()
+
+					<-startedAllowedCh
+
+					lock.Lock()
+					running--
+					lock.Unlock()
+					return nil
+				}
+
+				go func() {
+					time.Sleep(50 * time.Millisecond)
+					close(startedAllowedCh)
+				}()
+
+				err := applier.Prepare(&fakeas.FakeApplySpec{PackageResults: pkgs})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(packageApplier.PreparedPackages).To(HaveLen(6))
+				Expect(maxRunning).To(Equal(2))
+			})
+
+			It("stops preparing remaining bundles after first failure and reports every failed bundle", func() {
+				pkg1 := buildPackage()
+				pkg2 := buildPackage()
+				pkg3 := buildPackage()
+
+				bothStartedCh := make(chan struct{})
+				var startedCount int
+				var lock sync.Mutex
+
+				packageApplier.PrepareCallBack = func(pkg models.Package) error {
+					if pkg == pkg3 {
+						return nil
+					}
+
+					lock.Lock()
+					startedCount++
+					if startedCount == 2 {
+						close(bothStartedCh)
+					}
+					lock.Unlock()
+
+					<-bothStartedCh
+					return errors.New("fake-prepare-error-" + pkg.Name)
+				}
+
+				err := applier.Prepare(
+					&fakeas.FakeApplySpec{PackageResults: []models.Package{pkg1, pkg2, pkg3}},
+				)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("2 of 3 bundles failed"))
+				Expect(err.Error()).To(ContainSubstring("fake-prepare-error-" + pkg1.Name))
+				Expect(err.Error()).To(ContainSubstring("fake-prepare-error-" + pkg2.Name))
+				Expect(packageApplier.PreparedPackages).To(ConsistOf(pkg1, pkg2))
+			})
 		})
 
 		Describe("Apply", func() {
+			It("prepares desired jobs and packages before removing jobs from job supervisor", func() {
+				job := buildJob()
+				pkg := buildPackage()
+
+				jobApplier.PrepareError = errors.New("fake-prepare-job-error")
+
+				err := applier.Apply(
+					&fakeas.FakeApplySpec{},
+					&fakeas.FakeApplySpec{JobResults: []models.Job{job}, PackageResults: []models.Package{pkg}},
+				)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-prepare-job-error"))
+
+				Expect(jobSupervisor.RemovedAllJobs).To(BeFalse())
+				Expect(jobApplier.AppliedJobs).To(Equal([]models.Job{}))
+			})
+
 			It("removes all jobs from job supervisor", func() {
 				err := applier.Apply(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{})
 				Expect(err).ToNot(HaveOccurred())
@@ -210,6 +315,7 @@ func init() {
 				)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{pkg1, pkg2}))
+				Expect(packageApplier.PreparedPackages).To(ConsistOf(pkg1, pkg2))
 			})
 
 			It("apply errs when applying packages errs", func() {
@@ -264,6 +370,35 @@ func init() {
 				Expect(jobSupervisor.Reloaded).To(BeTrue())
 			})
 
+			It("runs pre-start scripts of jobs that have them", func() {
+				job1 := models.Job{Name: "fake-job-name-1", Version: "fake-version-name-1"}
+				job2 := models.Job{Name: "fake-job-name-2", Version: "fake-version-name-2"}
+
+				scriptProvider.NewScript("fake-job-name-1", "pre-start").(*fakescript.FakeScript).ExistsBool = true
+
+				err := applier.Apply(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{JobResults: []models.Job{job1, job2}})
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(scriptProvider.Scripts["fake-job-name-1/pre-start"].DidRun).To(BeTrue())
+				Expect(scriptProvider.Scripts["fake-job-name-2/pre-start"].DidRun).To(BeFalse())
+			})
+
+			It("runs pre-start scripts before reloading job supervisor", func() {
+				job := models.Job{Name: "fake-job-name-1", Version: "fake-version-name-1"}
+
+				script := scriptProvider.NewScript("fake-job-name-1", "pre-start").(*fakescript.FakeScript)
+				script.ExistsBool = true
+				script.RunError = errors.New("fake-pre-start-error")
+
+				err := applier.Apply(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{JobResults: []models.Job{job}})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Running pre-start script for job fake-job-name-1"))
+				Expect(err.Error()).To(ContainSubstring("fake-pre-start-error"))
+
+				// Reloaded only once while rolling back
+				Expect(jobSupervisor.ReloadCount).To(Equal(1))
+			})
+
 			It("apply errs if monitor fails reload", func() {
 				jobs := []models.Job{}
 				jobSupervisor.ReloadErr = errors.New("error reloading monit")
@@ -307,6 +442,122 @@ func init() {
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-set-up-logrotate-error"))
 			})
+
+			Context("when applying desired spec fails midway", func() {
+				var (
+					oldJob      models.Job
+					oldPkg      models.Package
+					newJob      models.Job
+					currentSpec *fakeas.FakeApplySpec
+					desiredSpec *fakeas.FakeApplySpec
+				)
+
+				BeforeEach(func() {
+					oldJob = models.Job{Name: "old-job", Version: "old-sha1"}
+					oldPkg = models.Package{Name: "old-pkg", Version: "old-sha1"}
+					newJob = models.Job{Name: "new-job", Version: "new-sha1"}
+
+					currentSpec = &fakeas.FakeApplySpec{JobResults: []models.Job{oldJob}, PackageResults: []models.Package{oldPkg}}
+					desiredSpec = &fakeas.FakeApplySpec{JobResults: []models.Job{newJob}, MaxLogFileSizeResult: "fake-size"}
+
+					fs.Symlink("/fake-base-dir/data/jobs/old-job/old-sha1", "/fake-base-dir/jobs/old-job")
+					fs.Symlink("/fake-base-dir/data/packages/old-pkg/old-sha1", "/fake-base-dir/packages/old-pkg")
+
+					// Second glob happens during restore after desired job was enabled
+					fs.SetGlob("/fake-base-dir/jobs/*",
+						[]string{"/fake-base-dir/jobs/old-job"},
+						[]string{"/fake-base-dir/jobs/old-job", "/fake-base-dir/jobs/new-job"},
+					)
+					fs.SetGlob("/fake-base-dir/packages/*", []string{"/fake-base-dir/packages/old-pkg"})
+
+					jobApplier.ApplyCallBack = func(job models.Job) {
+						if job.Name == "new-job" {
+							fs.Symlink("/fake-base-dir/data/jobs/new-job/new-sha1", "/fake-base-dir/jobs/new-job")
+						}
+					}
+
+					jobApplier.ConfigureCallBack = func(job models.Job) error {
+						if job.Name == "new-job" {
+							return errors.New("fake-configure-job-error")
+						}
+						return nil
+					}
+				})
+
+				It("removes bundle symlinks enabled by desired spec", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+
+					Expect(fs.FileExists("/fake-base-dir/jobs/new-job")).To(BeFalse())
+					Expect(fs.FileExists("/fake-base-dir/jobs/old-job")).To(BeTrue())
+					Expect(fs.FileExists("/fake-base-dir/packages/old-pkg")).To(BeTrue())
+				})
+
+				It("applies jobs and packages of current spec again", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+
+					Expect(jobApplier.AppliedJobs).To(Equal([]models.Job{newJob, oldJob}))
+					Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{oldPkg}))
+				})
+
+				It("configures jobs of current spec through job supervisor and reloads it", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+
+					Expect(jobSupervisor.RemovedAllJobs).To(BeTrue())
+					Expect(jobApplier.ConfiguredJobs).To(Equal([]models.Job{newJob, oldJob}))
+					Expect(jobApplier.ConfiguredJobIndices).To(Equal([]int{0, 0}))
+					Expect(jobSupervisor.ReloadCount).To(Equal(1))
+				})
+
+				It("returns rollback error that includes apply error", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-configure-job-error"))
+					Expect(err.Error()).To(ContainSubstring("rolled back to previous state"))
+
+					rollbackErr, ok := err.(RollbackError)
+					Expect(ok).To(BeTrue())
+					Expect(rollbackErr.RollbackErr).ToNot(HaveOccurred())
+				})
+
+				It("returns rollback error that includes both errors when restoring previous state fails", func() {
+					jobApplier.ConfigureCallBack = func(job models.Job) error {
+						return errors.New("fake-configure-" + job.Name + "-error")
+					}
+
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("fake-configure-new-job-error"))
+					Expect(err.Error()).To(ContainSubstring("rolling back to previous state failed"))
+					Expect(err.Error()).To(ContainSubstring("fake-configure-old-job-error"))
+				})
+
+				It("does not set up logrotation", func() {
+					err := applier.Apply(currentSpec, desiredSpec)
+					Expect(err).To(HaveOccurred())
+					Expect(logRotateDelegate.SetupLogrotateArgs).To(Equal(SetupLogrotateArgs{}))
+				})
+			})
+
+			It("returns error and does not change job supervisor when taking snapshot fails", func() {
+				fs.GlobErr = errors.New("fake-glob-error")
+
+				err := applier.Apply(&fakeas.FakeApplySpec{}, &fakeas.FakeApplySpec{})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-glob-error"))
+				Expect(jobSupervisor.RemovedAllJobs).To(BeFalse())
+			})
+
+			It("returns error and does not change job supervisor when desired packages cannot be determined", func() {
+				desiredSpec := &fakeas.FakeApplySpec{PackagesErr: errors.New("fake-packages-error")}
+
+				err := applier.Apply(&fakeas.FakeApplySpec{}, desiredSpec)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-packages-error"))
+				Expect(jobSupervisor.RemovedAllJobs).To(BeFalse())
+			})
 		})
 	})
 }
@@ -0,0 +1,198 @@
+package applier
+
+import (
+	"fmt"
+
+	boshas "bosh/agent/applier/applyspec"
+	boshbc "bosh/agent/applier/bundlecollection"
+	models "bosh/agent/applier/models"
+	boshblob "bosh/blobstore"
+	bosherr "bosh/errors"
+)
+
+type concretePlanner struct {
+	jobsBc     boshbc.BundleCollection
+	packagesBc boshbc.BundleCollection
+	blobCache  boshblob.Cache
+}
+
+func NewConcretePlanner(jobsBc, packagesBc boshbc.BundleCollection, blobCache boshblob.Cache) Planner {
+	return concretePlanner{
+		jobsBc:     jobsBc,
+		packagesBc: packagesBc,
+		blobCache:  blobCache,
+	}
+}
+
+func (p concretePlanner) Plan(currentApplySpec, desiredApplySpec boshas.ApplySpec) (Plan, error) {
+	var plan Plan
+	var err error
+
+	plan.Jobs, err = p.planBundles(p.jobsBc, jobDefinitions(currentApplySpec), jobDefinitions(desiredApplySpec))
+	if err != nil {
+		return plan, bosherr.WrapError(err, "Planning jobs")
+	}
+
+	currentPkgs, err := currentApplySpec.Packages()
+	if err != nil {
+		return plan, bosherr.WrapError(err, "Getting current packages")
+	}
+
+	desiredPkgs, err := desiredApplySpec.Packages()
+	if err != nil {
+		return plan, bosherr.WrapError(err, "Getting desired packages")
+	}
+
+	plan.Packages, err = p.planBundles(p.packagesBc, packageDefinitions(currentPkgs), packageDefinitions(desiredPkgs))
+	if err != nil {
+		return plan, bosherr.WrapError(err, "Planning packages")
+	}
+
+	plan.Downloads = []BlobDownload{}
+
+	downloadsByBlobID := map[string]int{}
+
+	// Bundles may come from the same blob (e.g. jobs from V1 spec
+	// share rendered templates archive) so it is only listed once
+	addDownload := func(source models.Source, description string) {
+		// Cached blobs are copied out of the cache instead of downloaded
+		if p.blobCache.Contains(source.Sha1) {
+			return
+		}
+
+		i, found := downloadsByBlobID[source.BlobstoreID]
+		if !found {
+			download := BlobDownload{
+				BlobstoreID: source.BlobstoreID,
+				Sha1:        source.Sha1,
+				Bundles:     []string{},
+			}
+
+			size, found := p.blobCache.Size(source.BlobstoreID)
+			if found {
+				download.Size = &size
+			}
+
+			i = len(plan.Downloads)
+			downloadsByBlobID[source.BlobstoreID] = i
+			plan.Downloads = append(plan.Downloads, download)
+		}
+		plan.Downloads[i].Bundles = append(plan.Downloads[i].Bundles, description)
+	}
+
+	for _, job := range desiredApplySpec.Jobs() {
+		installed, err := p.isInstalled(p.jobsBc, job)
+		if err != nil {
+			return plan, bosherr.WrapError(err, "Checking if job %s is installed", job.Name)
+		}
+
+		if !installed {
+			addDownload(job.Source, fmt.Sprintf("job %s", job.Name))
+		}
+	}
+
+	for _, pkg := range desiredPkgs {
+		installed, err := p.isInstalled(p.packagesBc, pkg)
+		if err != nil {
+			return plan, bosherr.WrapError(err, "Checking if package %s is installed", pkg.Name)
+		}
+
+		if !installed {
+			addDownload(pkg.Source, fmt.Sprintf("package %s", pkg.Name))
+		}
+	}
+
+	return plan, nil
+}
+
+func (p concretePlanner) planBundles(
+	bundleCollection boshbc.BundleCollection,
+	currentDefinitions, desiredDefinitions []boshbc.BundleDefinition,
+) (BundlePlan, error) {
+	plan := BundlePlan{
+		Install: []PlannedBundle{},
+		Remove:  []PlannedBundle{},
+		Keep:    []PlannedBundle{},
+	}
+
+	for _, definition := range desiredDefinitions {
+		planned, err := p.plannedBundle(bundleCollection, definition)
+		if err != nil {
+			return plan, err
+		}
+
+		if containsDefinition(currentDefinitions, definition) {
+			plan.Keep = append(plan.Keep, planned)
+		} else {
+			plan.Install = append(plan.Install, planned)
+		}
+	}
+
+	for _, definition := range currentDefinitions {
+		if containsDefinition(desiredDefinitions, definition) {
+			continue
+		}
+
+		planned, err := p.plannedBundle(bundleCollection, definition)
+		if err != nil {
+			return plan, err
+		}
+
+		plan.Remove = append(plan.Remove, planned)
+	}
+
+	return plan, nil
+}
+
+func (p concretePlanner) plannedBundle(
+	bundleCollection boshbc.BundleCollection,
+	definition boshbc.BundleDefinition,
+) (PlannedBundle, error) {
+	installed, err := p.isInstalled(bundleCollection, definition)
+	if err != nil {
+		return PlannedBundle{}, bosherr.WrapError(err, "Checking if %s is installed", definition.BundleName())
+	}
+
+	return PlannedBundle{
+		Name:      definition.BundleName(),
+		Version:   definition.BundleVersion(),
+		Installed: installed,
+	}, nil
+}
+
+func (p concretePlanner) isInstalled(
+	bundleCollection boshbc.BundleCollection,
+	definition boshbc.BundleDefinition,
+) (bool, error) {
+	bundle, err := bundleCollection.Get(definition)
+	if err != nil {
+		return false, bosherr.WrapError(err, "Getting bundle")
+	}
+
+	return bundle.IsInstalled()
+}
+
+func jobDefinitions(applySpec boshas.ApplySpec) []boshbc.BundleDefinition {
+	definitions := []boshbc.BundleDefinition{}
+	for _, job := range applySpec.Jobs() {
+		definitions = append(definitions, job)
+	}
+	return definitions
+}
+
+func packageDefinitions(pkgs []models.Package) []boshbc.BundleDefinition {
+	definitions := []boshbc.BundleDefinition{}
+	for _, pkg := range pkgs {
+		definitions = append(definitions, pkg)
+	}
+	return definitions
+}
+
+func containsDefinition(definitions []boshbc.BundleDefinition, definition boshbc.BundleDefinition) bool {
+	for _, d := range definitions {
+		if d.BundleName() == definition.BundleName() && d.BundleVersion() == definition.BundleVersion() {
+			return true
+		}
+	}
+	return false
+}
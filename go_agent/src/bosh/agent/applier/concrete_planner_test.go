@@ -0,0 +1,183 @@
+package applier_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/applier"
+	fakeas "bosh/agent/applier/applyspec/fakes"
+	fakebc "bosh/agent/applier/bundlecollection/fakes"
+	models "bosh/agent/applier/models"
+	fakeblob "bosh/blobstore/fakes"
+)
+
+var _ = Describe("concretePlanner", func() {
+	var (
+		jobsBc     *fakebc.FakeBundleCollection
+		packagesBc *fakebc.FakeBundleCollection
+		blobCache  *fakeblob.FakeCache
+		planner    Planner
+	)
+
+	BeforeEach(func() {
+		jobsBc = fakebc.NewFakeBundleCollection()
+		packagesBc = fakebc.NewFakeBundleCollection()
+		blobCache = fakeblob.NewFakeCache()
+		planner = NewConcretePlanner(jobsBc, packagesBc, blobCache)
+	})
+
+	Describe("Plan", func() {
+		var (
+			keptJob, removedJob, newJob models.Job
+			keptPkg, removedPkg, newPkg models.Package
+		)
+
+		BeforeEach(func() {
+			templatesSource := models.Source{Sha1: "fake-templates-sha1", BlobstoreID: "fake-templates-blob-id"}
+
+			keptJob = models.Job{Name: "fake-kept-job", Version: "fake-version", Source: templatesSource}
+			removedJob = models.Job{Name: "fake-removed-job", Version: "fake-version", Source: templatesSource}
+			newJob = models.Job{Name: "fake-new-job", Version: "fake-version", Source: templatesSource}
+
+			keptPkg = models.Package{
+				Name:    "fake-kept-pkg",
+				Version: "fake-version",
+				Source:  models.Source{Sha1: "fake-kept-sha1", BlobstoreID: "fake-kept-blob-id"},
+			}
+			removedPkg = models.Package{
+				Name:    "fake-removed-pkg",
+				Version: "fake-version",
+				Source:  models.Source{Sha1: "fake-removed-sha1", BlobstoreID: "fake-removed-blob-id"},
+			}
+			newPkg = models.Package{
+				Name:    "fake-new-pkg",
+				Version: "fake-version",
+				Source:  models.Source{Sha1: "fake-new-sha1", BlobstoreID: "fake-new-blob-id"},
+			}
+
+			jobsBc.FakeGet(keptJob).Installed = true
+			jobsBc.FakeGet(removedJob).Installed = true
+			packagesBc.FakeGet(keptPkg).Installed = true
+			packagesBc.FakeGet(removedPkg).Installed = true
+		})
+
+		runPlan := func() (Plan, error) {
+			return planner.Plan(
+				&fakeas.FakeApplySpec{
+					JobResults:     []models.Job{keptJob, removedJob},
+					PackageResults: []models.Package{keptPkg, removedPkg},
+				},
+				&fakeas.FakeApplySpec{
+					JobResults:     []models.Job{keptJob, newJob},
+					PackageResults: []models.Package{keptPkg, newPkg},
+				},
+			)
+		}
+
+		It("returns jobs to install, remove and keep", func() {
+			plan, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Jobs).To(Equal(BundlePlan{
+				Install: []PlannedBundle{{Name: "fake-new-job", Version: newJob.BundleVersion(), Installed: false}},
+				Remove:  []PlannedBundle{{Name: "fake-removed-job", Version: removedJob.BundleVersion(), Installed: true}},
+				Keep:    []PlannedBundle{{Name: "fake-kept-job", Version: keptJob.BundleVersion(), Installed: true}},
+			}))
+		})
+
+		It("returns packages to install, remove and keep", func() {
+			plan, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Packages).To(Equal(BundlePlan{
+				Install: []PlannedBundle{{Name: "fake-new-pkg", Version: newPkg.BundleVersion(), Installed: false}},
+				Remove:  []PlannedBundle{{Name: "fake-removed-pkg", Version: removedPkg.BundleVersion(), Installed: true}},
+				Keep:    []PlannedBundle{{Name: "fake-kept-pkg", Version: keptPkg.BundleVersion(), Installed: true}},
+			}))
+		})
+
+		It("marks bundles that were already installed by a previous prepare", func() {
+			packagesBc.FakeGet(newPkg).Installed = true
+
+			plan, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.Packages.Install).To(Equal([]PlannedBundle{
+				{Name: "fake-new-pkg", Version: newPkg.BundleVersion(), Installed: true},
+			}))
+		})
+
+		It("returns blob downloads only for bundles that are not installed", func() {
+			plan, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Downloads).To(Equal([]BlobDownload{
+				{
+					BlobstoreID: "fake-templates-blob-id",
+					Sha1:        "fake-templates-sha1",
+					Bundles:     []string{"job fake-new-job"},
+				},
+				{
+					BlobstoreID: "fake-new-blob-id",
+					Sha1:        "fake-new-sha1",
+					Bundles:     []string{"package fake-new-pkg"},
+				},
+			}))
+		})
+
+		It("returns single download for jobs sharing rendered templates archive", func() {
+			jobsBc.FakeGet(keptJob).Installed = false
+
+			plan, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Downloads[0]).To(Equal(BlobDownload{
+				BlobstoreID: "fake-templates-blob-id",
+				Sha1:        "fake-templates-sha1",
+				Bundles:     []string{"job fake-kept-job", "job fake-new-job"},
+			}))
+		})
+
+		It("includes blob sizes when blobstore can tell them", func() {
+			blobCache.SizeSizes["fake-new-blob-id"] = 1024
+
+			plan, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Downloads[0].Size).To(BeNil())
+			Expect(*plan.Downloads[1].Size).To(Equal(int64(1024)))
+		})
+
+		It("does not return downloads for blobs that are already cached", func() {
+			blobCache.ContainsFingerprints["fake-templates-sha1"] = true
+
+			plan, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(plan.Downloads).To(Equal([]BlobDownload{
+				{
+					BlobstoreID: "fake-new-blob-id",
+					Sha1:        "fake-new-sha1",
+					Bundles:     []string{"package fake-new-pkg"},
+				},
+			}))
+		})
+
+		It("returns error when checking if bundle is installed fails", func() {
+			packagesBc.FakeGet(newPkg).IsInstalledErr = errors.New("fake-is-installed-error")
+
+			_, err := runPlan()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-is-installed-error"))
+		})
+
+		It("does not install, enable or remove any bundles", func() {
+			_, err := runPlan()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(jobsBc.FakeGet(removedJob).ActionsCalled).To(BeEmpty())
+			Expect(packagesBc.FakeGet(newPkg).ActionsCalled).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,129 @@
+package applier
+
+import (
+	boshas "bosh/agent/applier/applyspec"
+	boshbc "bosh/agent/applier/bundlecollection"
+	ja "bosh/agent/applier/jobapplier"
+	pa "bosh/agent/applier/packageapplier"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+)
+
+const verifierLogTag = "concreteVerifier"
+
+type concreteVerifier struct {
+	jobsBc         boshbc.BundleCollection
+	packagesBc     boshbc.BundleCollection
+	jobApplier     ja.JobApplier
+	packageApplier pa.PackageApplier
+	logger         boshlog.Logger
+}
+
+func NewConcreteVerifier(
+	jobsBc boshbc.BundleCollection,
+	packagesBc boshbc.BundleCollection,
+	jobApplier ja.JobApplier,
+	packageApplier pa.PackageApplier,
+	logger boshlog.Logger,
+) Verifier {
+	return concreteVerifier{
+		jobsBc:         jobsBc,
+		packagesBc:     packagesBc,
+		jobApplier:     jobApplier,
+		packageApplier: packageApplier,
+		logger:         logger,
+	}
+}
+
+func (v concreteVerifier) Verify(applySpec boshas.ApplySpec, repair bool) (Verification, error) {
+	verification := Verification{
+		Jobs:     []VerifiedBundle{},
+		Packages: []VerifiedBundle{},
+	}
+
+	// Packages are repaired first since job specific package symlinks
+	// are recreated when repaired jobs are applied
+	pkgs, err := applySpec.Packages()
+	if err != nil {
+		return verification, bosherr.WrapError(err, "Getting packages")
+	}
+
+	for _, pkg := range pkgs {
+		pkg := pkg
+
+		verified, err := v.verifyBundle(v.packagesBc, pkg, repair, func() error { return v.packageApplier.Apply(pkg) })
+		if err != nil {
+			return verification, bosherr.WrapError(err, "Verifying package %s", pkg.Name)
+		}
+
+		verification.Packages = append(verification.Packages, verified)
+	}
+
+	for _, job := range applySpec.Jobs() {
+		job := job
+
+		verified, err := v.verifyBundle(v.jobsBc, job, repair, func() error { return v.jobApplier.Apply(job) })
+		if err != nil {
+			return verification, bosherr.WrapError(err, "Verifying job %s", job.Name)
+		}
+
+		verification.Jobs = append(verification.Jobs, verified)
+	}
+
+	return verification, nil
+}
+
+func (v concreteVerifier) verifyBundle(
+	bc boshbc.BundleCollection,
+	definition boshbc.BundleDefinition,
+	repair bool,
+	apply func() error,
+) (VerifiedBundle, error) {
+	verified := VerifiedBundle{
+		Name:    definition.BundleName(),
+		Version: definition.BundleVersion(),
+		Status:  BundleStatusOk,
+	}
+
+	bundle, err := bc.Get(definition)
+	if err != nil {
+		return verified, bosherr.WrapError(err, "Getting bundle")
+	}
+
+	installed, err := bundle.IsInstalled()
+	if err != nil {
+		return verified, bosherr.WrapError(err, "Checking if bundle is installed")
+	}
+
+	if !installed {
+		verified.Status = BundleStatusMissing
+	} else {
+		err = bundle.Verify()
+		if err != nil {
+			verified.Status = BundleStatusCorrupted
+			verified.Error = err.Error()
+		}
+	}
+
+	if verified.Status == BundleStatusOk || !repair {
+		return verified, nil
+	}
+
+	v.logger.Info(verifierLogTag, "Repairing %s bundle %s/%s", verified.Status, verified.Name, verified.Version)
+
+	// Applying reinstalls bundle only when it is not installed
+	err = bundle.Uninstall()
+	if err == nil {
+		err = apply()
+	}
+
+	if err != nil {
+		verified.Error = bosherr.WrapError(err, "Repairing bundle").Error()
+		return verified, nil
+	}
+
+	verified.Status = BundleStatusRepaired
+	verified.Error = ""
+
+	return verified, nil
+}
@@ -0,0 +1,147 @@
+package applier_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/applier"
+	fakeas "bosh/agent/applier/applyspec/fakes"
+	fakebc "bosh/agent/applier/bundlecollection/fakes"
+	fakeja "bosh/agent/applier/jobapplier/fakes"
+	models "bosh/agent/applier/models"
+	fakepa "bosh/agent/applier/packageapplier/fakes"
+	boshlog "bosh/logger"
+)
+
+var _ = Describe("concreteVerifier", func() {
+	var (
+		jobsBc         *fakebc.FakeBundleCollection
+		packagesBc     *fakebc.FakeBundleCollection
+		jobApplier     *fakeja.FakeJobApplier
+		packageApplier *fakepa.FakePackageApplier
+		verifier       Verifier
+	)
+
+	BeforeEach(func() {
+		jobsBc = fakebc.NewFakeBundleCollection()
+		packagesBc = fakebc.NewFakeBundleCollection()
+		jobApplier = fakeja.NewFakeJobApplier()
+		packageApplier = fakepa.NewFakePackageApplier()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		verifier = NewConcreteVerifier(jobsBc, packagesBc, jobApplier, packageApplier, logger)
+	})
+
+	Describe("Verify", func() {
+		var (
+			job             models.Job
+			pkg             models.Package
+			applySpec       *fakeas.FakeApplySpec
+			jobBundle       *fakebc.FakeBundle
+			pkgBundle       *fakebc.FakeBundle
+			corruptedBundle *fakebc.FakeBundle
+			corruptedPkg    models.Package
+		)
+
+		BeforeEach(func() {
+			job = models.Job{Name: "fake-job", Version: "fake-job-version"}
+			pkg = models.Package{Name: "fake-pkg", Version: "fake-pkg-version"}
+			corruptedPkg = models.Package{Name: "fake-corrupted-pkg", Version: "fake-pkg-version"}
+
+			applySpec = &fakeas.FakeApplySpec{
+				JobResults:     []models.Job{job},
+				PackageResults: []models.Package{pkg, corruptedPkg},
+			}
+
+			jobBundle = jobsBc.FakeGet(job)
+			jobBundle.Installed = true
+
+			pkgBundle = packagesBc.FakeGet(pkg)
+			pkgBundle.Installed = true
+
+			corruptedBundle = packagesBc.FakeGet(corruptedPkg)
+			corruptedBundle.Installed = true
+			corruptedBundle.VerifyErr = errors.New("fake-verify-err")
+		})
+
+		Context("when not repairing", func() {
+			It("reports status of each bundle", func() {
+				jobBundle.Installed = false
+
+				verification, err := verifier.Verify(applySpec, false)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(verification).To(Equal(Verification{
+					Jobs: []VerifiedBundle{
+						{Name: "fake-job", Version: job.BundleVersion(), Status: "missing"},
+					},
+					Packages: []VerifiedBundle{
+						{Name: "fake-pkg", Version: pkg.BundleVersion(), Status: "ok"},
+						{Name: "fake-corrupted-pkg", Version: corruptedPkg.BundleVersion(), Status: "corrupted", Error: "fake-verify-err"},
+					},
+				}))
+				Expect(verification.Intact()).To(BeFalse())
+
+				Expect(pkgBundle.Verified).To(BeTrue())
+				Expect(jobBundle.Verified).To(BeFalse())
+			})
+
+			It("does not change bundles", func() {
+				_, err := verifier.Verify(applySpec, false)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(corruptedBundle.ActionsCalled).To(Equal([]string{"Verify"}))
+				Expect(packageApplier.AppliedPackages).To(BeEmpty())
+				Expect(jobApplier.AppliedJobs).To(BeEmpty())
+			})
+		})
+
+		Context("when repairing", func() {
+			It("uninstalls corrupted bundles and applies them again", func() {
+				verification, err := verifier.Verify(applySpec, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(verification.Packages[1]).To(Equal(VerifiedBundle{
+					Name:    "fake-corrupted-pkg",
+					Version: corruptedPkg.BundleVersion(),
+					Status:  "repaired",
+				}))
+				Expect(verification.Intact()).To(BeTrue())
+
+				Expect(corruptedBundle.ActionsCalled).To(Equal([]string{"Verify", "Uninstall"}))
+				Expect(packageApplier.AppliedPackages).To(Equal([]models.Package{corruptedPkg}))
+				Expect(jobApplier.AppliedJobs).To(BeEmpty())
+			})
+
+			It("applies missing bundles", func() {
+				jobBundle.Installed = false
+
+				verification, err := verifier.Verify(applySpec, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(verification.Jobs[0].Status).To(Equal("repaired"))
+				Expect(jobApplier.AppliedJobs).To(Equal([]models.Job{job}))
+			})
+
+			It("reports bundle as corrupted when repairing fails", func() {
+				packageApplier.ApplyError = errors.New("fake-apply-err")
+
+				verification, err := verifier.Verify(applySpec, true)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(verification.Packages[1].Status).To(Equal("corrupted"))
+				Expect(verification.Packages[1].Error).To(ContainSubstring("fake-apply-err"))
+				Expect(verification.Intact()).To(BeFalse())
+			})
+		})
+
+		It("returns error when checking if bundle is installed fails", func() {
+			pkgBundle.IsInstalledErr = errors.New("fake-is-installed-err")
+
+			_, err := verifier.Verify(applySpec, false)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-is-installed-err"))
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package fakes
+
+import (
+	boshappl "bosh/agent/applier"
+	as "bosh/agent/applier/applyspec"
+)
+
+type FakePlanner struct {
+	PlanCurrentApplySpec as.ApplySpec
+	PlanDesiredApplySpec as.ApplySpec
+	PlanPlan             boshappl.Plan
+	PlanErr              error
+}
+
+func NewFakePlanner() *FakePlanner {
+	return &FakePlanner{}
+}
+
+func (p *FakePlanner) Plan(currentApplySpec, desiredApplySpec as.ApplySpec) (boshappl.Plan, error) {
+	p.PlanCurrentApplySpec = currentApplySpec
+	p.PlanDesiredApplySpec = desiredApplySpec
+	return p.PlanPlan, p.PlanErr
+}
@@ -0,0 +1,23 @@
+package fakes
+
+import (
+	boshappl "bosh/agent/applier"
+	as "bosh/agent/applier/applyspec"
+)
+
+type FakeVerifier struct {
+	VerifyApplySpec    as.ApplySpec
+	VerifyRepair       bool
+	VerifyVerification boshappl.Verification
+	VerifyErr          error
+}
+
+func NewFakeVerifier() *FakeVerifier {
+	return &FakeVerifier{}
+}
+
+func (v *FakeVerifier) Verify(applySpec as.ApplySpec, repair bool) (boshappl.Verification, error) {
+	v.VerifyApplySpec = applySpec
+	v.VerifyRepair = repair
+	return v.VerifyVerification, v.VerifyErr
+}
@@ -1,19 +1,26 @@
 package fakes
 
 import (
+	"sync"
+
 	models "bosh/agent/applier/models"
 )
 
 type FakeJobApplier struct {
-	PreparedJobs []models.Job
-	PrepareError error
+	prepareLock sync.Mutex
+
+	PreparedJobs    []models.Job
+	PrepareError    error
+	PrepareCallBack func(job models.Job) error
 
-	AppliedJobs []models.Job
-	ApplyError  error
+	AppliedJobs   []models.Job
+	ApplyError    error
+	ApplyCallBack func(job models.Job)
 
 	ConfiguredJobs       []models.Job
 	ConfiguredJobIndices []int
 	ConfigureError       error
+	ConfigureCallBack    func(job models.Job) error
 
 	KeepOnlyJobs []models.Job
 	KeepOnlyErr  error
@@ -26,18 +33,35 @@ func NewFakeJobApplier() *FakeJobApplier {
 }
 
 func (s *FakeJobApplier) Prepare(job models.Job) error {
+	s.prepareLock.Lock()
 	s.PreparedJobs = append(s.PreparedJobs, job)
+	s.prepareLock.Unlock()
+
+	if s.PrepareCallBack != nil {
+		return s.PrepareCallBack(job)
+	}
+
 	return s.PrepareError
 }
 
 func (s *FakeJobApplier) Apply(job models.Job) error {
 	s.AppliedJobs = append(s.AppliedJobs, job)
+
+	if s.ApplyCallBack != nil {
+		s.ApplyCallBack(job)
+	}
+
 	return s.ApplyError
 }
 
 func (s *FakeJobApplier) Configure(job models.Job, jobIndex int) error {
 	s.ConfiguredJobs = append(s.ConfiguredJobs, job)
 	s.ConfiguredJobIndices = append(s.ConfiguredJobIndices, jobIndex)
+
+	if s.ConfigureCallBack != nil {
+		return s.ConfigureCallBack(job)
+	}
+
 	return s.ConfigureError
 }
 
@@ -21,6 +21,7 @@ const logTag = "renderedJobApplier"
 
 type renderedJobApplier struct {
 	jobsBc                 boshbc.BundleCollection
+	installLock            *boshbc.InstallLock
 	jobSupervisor          boshjobsuper.JobSupervisor
 	packageApplierProvider boshpa.PackageApplierProvider
 	blobstore              boshblob.Blobstore
@@ -40,6 +41,7 @@ func NewRenderedJobApplier(
 ) *renderedJobApplier {
 	return &renderedJobApplier{
 		jobsBc:                 jobsBc,
+		installLock:            boshbc.NewInstallLock(),
 		jobSupervisor:          jobSupervisor,
 		packageApplierProvider: packageApplierProvider,
 		blobstore:              blobstore,
@@ -52,6 +54,10 @@ func NewRenderedJobApplier(
 func (s renderedJobApplier) Prepare(job models.Job) error {
 	s.logger.Debug(logTag, "Preparing job %v", job)
 
+	// Jobs may be prepared concurrently (see concreteApplier)
+	unlock := s.installLock.Lock(job)
+	defer unlock()
+
 	jobBundle, err := s.jobsBc.Get(job)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting job bundle")
@@ -18,6 +18,9 @@ type concretePackageApplier struct {
 	// KeepOnly will permanently uninstall packages when operating as owner
 	packagesBcOwner bool
 
+	// installLock prevents concurrent Prepare calls from installing same package twice
+	installLock *bc.InstallLock
+
 	blobstore  boshblob.Blobstore
 	compressor boshcmd.Compressor
 	fs         boshsys.FileSystem
@@ -35,6 +38,7 @@ func NewConcretePackageApplier(
 	return &concretePackageApplier{
 		packagesBc:      packagesBc,
 		packagesBcOwner: packagesBcOwner,
+		installLock:     bc.NewInstallLock(),
 		blobstore:       blobstore,
 		compressor:      compressor,
 		fs:              fs,
@@ -45,6 +49,9 @@ func NewConcretePackageApplier(
 func (s concretePackageApplier) Prepare(pkg models.Package) error {
 	s.logger.Debug(logTag, "Preparing package %v", pkg)
 
+	unlock := s.installLock.Lock(pkg)
+	defer unlock()
+
 	pkgBundle, err := s.packagesBc.Get(pkg)
 	if err != nil {
 		return bosherr.WrapError(err, "Getting package bundle")
@@ -1,14 +1,19 @@
 package fakes
 
 import (
+	"sync"
+
 	models "bosh/agent/applier/models"
 )
 
 type FakePackageApplier struct {
+	lock sync.Mutex
+
 	ActionsCalled []string
 
 	PreparedPackages []models.Package
 	PrepareError     error
+	PrepareCallBack  func(pkg models.Package) error
 
 	AppliedPackages []models.Package
 	ApplyError      error
@@ -24,8 +29,15 @@ func NewFakePackageApplier() *FakePackageApplier {
 }
 
 func (s *FakePackageApplier) Prepare(pkg models.Package) error {
+	s.lock.Lock()
 	s.ActionsCalled = append(s.ActionsCalled, "Prepare")
 	s.PreparedPackages = append(s.PreparedPackages, pkg)
+	s.lock.Unlock()
+
+	if s.PrepareCallBack != nil {
+		return s.PrepareCallBack(pkg)
+	}
+
 	return s.PrepareError
 }
 
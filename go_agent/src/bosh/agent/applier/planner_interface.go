@@ -0,0 +1,47 @@
+package applier
+
+import (
+	boshas "bosh/agent/applier/applyspec"
+)
+
+// Planner describes what Apply would do without changing anything.
+type Planner interface {
+	Plan(currentApplySpec, desiredApplySpec boshas.ApplySpec) (Plan, error)
+}
+
+type Plan struct {
+	Jobs      BundlePlan     `json:"jobs"`
+	Packages  BundlePlan     `json:"packages"`
+	Downloads []BlobDownload `json:"downloads"`
+}
+
+type BundlePlan struct {
+	// Bundles from desired spec that are not in current spec
+	Install []PlannedBundle `json:"install"`
+
+	// Bundles from current spec that are not in desired spec
+	Remove []PlannedBundle `json:"remove"`
+
+	// Bundles that are in both current and desired specs
+	Keep []PlannedBundle `json:"keep"`
+}
+
+type PlannedBundle struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Installed indicates that bundle contents are already on disk
+	// e.g. left over from a previous apply or installed by prepare
+	Installed bool `json:"installed"`
+}
+
+type BlobDownload struct {
+	BlobstoreID string `json:"blobstore_id"`
+	Sha1        string `json:"sha1"`
+
+	// Size is only included when blobstore can tell it without downloading the blob
+	Size *int64 `json:"size,omitempty"`
+
+	// e.g. ["job fake-job", "package fake-pkg"]
+	Bundles []string `json:"bundles"`
+}
@@ -0,0 +1,128 @@
+package applier
+
+import (
+	"fmt"
+	"path/filepath"
+
+	as "bosh/agent/applier/applyspec"
+	bosherr "bosh/errors"
+)
+
+// RollbackError is returned by Apply when desired state could not be applied
+// and previously applied state was restored (or failed to be restored).
+type RollbackError struct {
+	ApplyErr    error
+	RollbackErr error
+}
+
+func (e RollbackError) Error() string {
+	if e.RollbackErr != nil {
+		return fmt.Sprintf("%s (rolling back to previous state failed: %s)", e.ApplyErr.Error(), e.RollbackErr.Error())
+	}
+	return fmt.Sprintf("%s (rolled back to previous state)", e.ApplyErr.Error())
+}
+
+// stateSnapshot keeps enough information to undo a partially applied spec.
+// Bundles themselves are not copied since KeepOnly keeps bundles
+// from the current spec installed until the next successful apply.
+type stateSnapshot struct {
+	// e.g. /var/vcap/jobs/job-a, /var/vcap/packages/pkg-a
+	bundleSymlinks map[string]bool
+}
+
+func (a *concreteApplier) takeSnapshot() (stateSnapshot, error) {
+	snapshot := stateSnapshot{
+		bundleSymlinks: map[string]bool{},
+	}
+
+	symlinkPaths, err := a.bundleSymlinkPaths()
+	if err != nil {
+		return snapshot, err
+	}
+
+	for _, path := range symlinkPaths {
+		snapshot.bundleSymlinks[path] = true
+	}
+
+	return snapshot, nil
+}
+
+// restoreSnapshot applies jobs and packages of current spec again
+// and configures them through job supervisor so that whatever
+// job supervisor uses (monit files, systemd units, etc.) is restored
+func (a *concreteApplier) restoreSnapshot(snapshot stateSnapshot, currentApplySpec as.ApplySpec) error {
+	symlinkPaths, err := a.bundleSymlinkPaths()
+	if err != nil {
+		return err
+	}
+
+	// Bundles that were only enabled by desired spec
+	for _, path := range symlinkPaths {
+		if !snapshot.bundleSymlinks[path] {
+			err = a.fs.RemoveAll(path)
+			if err != nil {
+				return bosherr.WrapError(err, "Removing bundle symlink %s", path)
+			}
+		}
+	}
+
+	// Applying jobs also restores their job specific package symlinks
+	jobs := currentApplySpec.Jobs()
+	for _, job := range jobs {
+		err = a.jobApplier.Apply(job)
+		if err != nil {
+			return bosherr.WrapError(err, "Applying job %s", job.Name)
+		}
+	}
+
+	pkgs, err := currentApplySpec.Packages()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting current packages")
+	}
+
+	for _, pkg := range pkgs {
+		err = a.packageApplier.Apply(pkg)
+		if err != nil {
+			return bosherr.WrapError(err, "Applying package %s", pkg.Name)
+		}
+	}
+
+	err = a.jobSupervisor.RemoveAllJobs()
+	if err != nil {
+		return bosherr.WrapError(err, "Removing all jobs")
+	}
+
+	err = a.configureJobs(jobs)
+	if err != nil {
+		return err
+	}
+
+	err = a.jobSupervisor.Reload()
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading jobSupervisor")
+	}
+
+	return nil
+}
+
+// bundleSymlinkPaths returns enabled job and package bundles
+// (e.g. /var/vcap/jobs/job-a, /var/vcap/packages/pkg-a)
+func (a *concreteApplier) bundleSymlinkPaths() ([]string, error) {
+	var paths []string
+
+	enableDirs := []string{
+		a.dirProvider.JobsDir(),
+		filepath.Join(a.dirProvider.BaseDir(), "packages"),
+	}
+
+	for _, enableDir := range enableDirs {
+		matches, err := a.fs.Glob(filepath.Join(enableDir, "*"))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Globbing bundle symlinks in %s", enableDir)
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,45 @@
+package applier
+
+import (
+	boshas "bosh/agent/applier/applyspec"
+)
+
+// Verifier checks that installed bundles were not modified after installation.
+type Verifier interface {
+	// Verify optionally repairs missing or corrupted bundles
+	// by downloading and installing them again
+	Verify(applySpec boshas.ApplySpec, repair bool) (Verification, error)
+}
+
+const (
+	BundleStatusOk        = "ok"
+	BundleStatusMissing   = "missing"
+	BundleStatusCorrupted = "corrupted"
+	BundleStatusRepaired  = "repaired"
+)
+
+type Verification struct {
+	Jobs     []VerifiedBundle `json:"jobs"`
+	Packages []VerifiedBundle `json:"packages"`
+}
+
+type VerifiedBundle struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// One of BundleStatus* constants
+	Status string `json:"status"`
+
+	// Describes corruption or repair failure
+	Error string `json:"error,omitempty"`
+}
+
+// Intact returns true if all bundles are either ok or repaired.
+func (v Verification) Intact() bool {
+	for _, bundle := range append(v.Jobs, v.Packages...) {
+		if bundle.Status != BundleStatusOk && bundle.Status != BundleStatusRepaired {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,212 @@
+package compiler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	boshmodels "bosh/agent/applier/models"
+	bosherr "bosh/errors"
+)
+
+func (c concreteCompiler) CompileBatch(pkgs []BatchPackage) (map[string]BatchResult, error) {
+	pkgsByName, err := c.validateBatch(pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Removing packages")
+	}
+
+	var (
+		wg        sync.WaitGroup
+		resultsMu sync.Mutex
+	)
+
+	results := map[string]BatchResult{}
+
+	doneChs := map[string]chan struct{}{}
+	for name := range pkgsByName {
+		doneChs[name] = make(chan struct{})
+	}
+
+	semaphore := make(chan struct{}, c.options.MaxParallelPackages)
+
+	for _, pkg := range pkgs {
+		wg.Add(1)
+
+		go func(pkg BatchPackage) {
+			defer wg.Done()
+			defer close(doneChs[pkg.Name])
+
+			var failedDeps []string
+
+			for _, depName := range sortedDependencyNames(pkg.Dependencies) {
+				_, inBatch := pkgsByName[depName]
+				if !inBatch {
+					continue
+				}
+
+				<-doneChs[depName]
+
+				resultsMu.Lock()
+				depFailed := results[depName].Error != ""
+				resultsMu.Unlock()
+
+				if depFailed {
+					failedDeps = append(failedDeps, depName)
+				}
+			}
+
+			var result BatchResult
+
+			if len(failedDeps) > 0 {
+				result.Error = fmt.Sprintf("Dependencies failed to compile: %v", failedDeps)
+			} else {
+				semaphore <- struct{}{}
+				result = c.compileBatchPackage(pkg, pkgsByName)
+				<-semaphore
+			}
+
+			resultsMu.Lock()
+			results[pkg.Name] = result
+			resultsMu.Unlock()
+		}(pkg)
+	}
+
+	wg.Wait()
+
+	// Compiled packages were kept installed only for packages from this batch
+	err = c.packageApplier.KeepOnly([]boshmodels.Package{})
+	if err != nil {
+		c.logger.Error(compilerLogTag, "Failed to remove packages after compiling batch: %s", err.Error())
+	}
+
+	return results, nil
+}
+
+func (c concreteCompiler) compileBatchPackage(pkg BatchPackage, pkgsByName map[string]BatchPackage) BatchResult {
+	deps := []boshmodels.Package{}
+
+	for _, depName := range sortedDependencyNames(pkg.Dependencies) {
+		dep := pkg.Dependencies[depName]
+
+		depModel := boshmodels.Package{
+			Name:    depName,
+			Version: dep.Version,
+		}
+
+		// Packages compiled in this batch are already installed
+		batchDep, inBatch := pkgsByName[depName]
+		if inBatch {
+			depModel.Version = batchDep.Version
+		} else {
+			depModel.Source = boshmodels.Source{
+				Sha1:        dep.Sha1,
+				BlobstoreID: dep.BlobstoreID,
+			}
+		}
+
+		deps = append(deps, depModel)
+	}
+
+	blobID, sha1, logBlobID, err := c.compile(pkg.Package, deps, true)
+	if err != nil {
+		return BatchResult{
+			LogBlobstoreID: logBlobID,
+			Error:          bosherr.WrapError(err, "Compiling package %s", pkg.Name).Error(),
+		}
+	}
+
+	return BatchResult{
+		BlobstoreID:    blobID,
+		Sha1:           sha1,
+		LogBlobstoreID: logBlobID,
+	}
+}
+
+// validateBatch makes sure that packages can be compiled in dependency order
+func (c concreteCompiler) validateBatch(pkgs []BatchPackage) (map[string]BatchPackage, error) {
+	pkgsByName := map[string]BatchPackage{}
+
+	for _, pkg := range pkgs {
+		if pkg.Name == "" {
+			return nil, bosherr.New("Package name must not be empty")
+		}
+
+		if _, found := pkgsByName[pkg.Name]; found {
+			return nil, bosherr.New("Package %s is included more than once", pkg.Name)
+		}
+
+		pkgsByName[pkg.Name] = pkg
+	}
+
+	for _, pkg := range pkgs {
+		for depName, dep := range pkg.Dependencies {
+			_, inBatch := pkgsByName[depName]
+			if !inBatch && dep.BlobstoreID == "" {
+				return nil, bosherr.New("Dependency %s of package %s is neither in batch nor has blobstore ID", depName, pkg.Name)
+			}
+		}
+	}
+
+	// Repeatedly remove packages without uncompiled dependencies
+	remainingDeps := map[string]int{}
+	dependents := map[string][]string{}
+
+	for _, pkg := range pkgs {
+		for depName := range pkg.Dependencies {
+			if _, inBatch := pkgsByName[depName]; inBatch {
+				remainingDeps[pkg.Name]++
+				dependents[depName] = append(dependents[depName], pkg.Name)
+			}
+		}
+	}
+
+	var ready []string
+	for _, pkg := range pkgs {
+		if remainingDeps[pkg.Name] == 0 {
+			ready = append(ready, pkg.Name)
+		}
+	}
+
+	ordered := 0
+
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		ordered++
+
+		for _, dependent := range dependents[name] {
+			remainingDeps[dependent]--
+			if remainingDeps[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if ordered != len(pkgs) {
+		var cyclic []string
+		for name, count := range remainingDeps {
+			if count > 0 {
+				cyclic = append(cyclic, name)
+			}
+		}
+		sort.Strings(cyclic)
+
+		return nil, bosherr.New("Packages have cyclic dependencies: %v", cyclic)
+	}
+
+	return pkgsByName, nil
+}
+
+func sortedDependencyNames(deps Dependencies) []string {
+	var names []string
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
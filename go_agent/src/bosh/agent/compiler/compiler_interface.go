@@ -5,7 +5,15 @@ import (
 )
 
 type Compiler interface {
-	Compile(pkg Package, deps []boshmodels.Package) (blobID, sha1 string, err error)
+	// Compile returns blob ID of uploaded build log when packaging script ran
+	// even if compilation failed
+	Compile(pkg Package, deps []boshmodels.Package) (blobID, sha1, logBlobID string, err error)
+
+	// CompileBatch compiles packages after their dependencies;
+	// packages that do not depend on each other are compiled in parallel.
+	// Returned error indicates that batch is invalid; failures
+	// of individual packages are included in results.
+	CompileBatch(pkgs []BatchPackage) (map[string]BatchResult, error)
 }
 
 type Package struct {
@@ -16,3 +24,18 @@ type Package struct {
 }
 
 type Dependencies map[string]Package
+
+type BatchPackage struct {
+	Package
+
+	// Dependencies compiled in the same batch only need name and version;
+	// other dependencies must have blobstore ID and sha1
+	Dependencies Dependencies `json:"dependencies"`
+}
+
+type BatchResult struct {
+	BlobstoreID    string `json:"blobstore_id,omitempty"`
+	Sha1           string `json:"sha1,omitempty"`
+	LogBlobstoreID string `json:"log_blobstore_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
@@ -3,20 +3,59 @@ package compiler
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"time"
 
 	boshbc "bosh/agent/applier/bundlecollection"
 	boshmodels "bosh/agent/applier/models"
 	boshpa "bosh/agent/applier/packageapplier"
 	boshblob "bosh/blobstore"
 	bosherr "bosh/errors"
+	boshlog "bosh/logger"
 	boshcmd "bosh/platform/commands"
 	boshsys "bosh/system"
 )
 
+const (
+	DefaultUser           = "bosh_compile"
+	DefaultCPUShares      = 512
+	DefaultTimeoutSeconds = 2 * 60 * 60
+	DefaultCgroupRoot     = "/sys/fs/cgroup"
+)
+
+// DefaultMaxParallelPackages is used when Options do not specify a limit
+var DefaultMaxParallelPackages = runtime.NumCPU()
+
+type Options struct {
+	// User that runs packaging scripts; created if it does not exist
+	User string
+
+	// Relative CPU weight of packaging scripts (agent has 1024)
+	CPUShares int
+
+	// Maximum memory usage of packaging scripts in bytes; 0 means no limit
+	MemoryLimit int64
+
+	// Packaging scripts are killed after running this long
+	TimeoutSeconds int
+
+	// Dir where cpu and memory cgroup hierarchies are mounted
+	CgroupRoot string
+
+	// Maximum number of packages from a batch that are compiled at the same time
+	MaxParallelPackages int
+}
+
+func (o Options) timeout() time.Duration {
+	return time.Duration(o.TimeoutSeconds) * time.Second
+}
+
 type CompileDirProvider interface {
 	CompileDir() string
 }
 
+const compilerLogTag = "concreteCompiler"
+
 type concreteCompiler struct {
 	compressor         boshcmd.Compressor
 	blobstore          boshblob.Blobstore
@@ -25,6 +64,9 @@ type concreteCompiler struct {
 	compileDirProvider CompileDirProvider
 	packageApplier     boshpa.PackageApplier
 	packagesBc         boshbc.BundleCollection
+	packagingRunner    packagingRunner
+	options            Options
+	logger             boshlog.Logger
 }
 
 func NewConcreteCompiler(
@@ -35,7 +77,29 @@ func NewConcreteCompiler(
 	compileDirProvider CompileDirProvider,
 	packageApplier boshpa.PackageApplier,
 	packagesBc boshbc.BundleCollection,
+	options Options,
+	logger boshlog.Logger,
 ) (c concreteCompiler) {
+	if options.User == "" {
+		options.User = DefaultUser
+	}
+
+	if options.CPUShares == 0 {
+		options.CPUShares = DefaultCPUShares
+	}
+
+	if options.TimeoutSeconds <= 0 {
+		options.TimeoutSeconds = DefaultTimeoutSeconds
+	}
+
+	if options.CgroupRoot == "" {
+		options.CgroupRoot = DefaultCgroupRoot
+	}
+
+	if options.MaxParallelPackages < 1 {
+		options.MaxParallelPackages = DefaultMaxParallelPackages
+	}
+
 	c.compressor = compressor
 	c.blobstore = blobstore
 	c.fs = fs
@@ -43,26 +107,35 @@ func NewConcreteCompiler(
 	c.compileDirProvider = compileDirProvider
 	c.packageApplier = packageApplier
 	c.packagesBc = packagesBc
+	c.packagingRunner = newPackagingRunner(fs, runner, options, logger)
+	c.options = options
+	c.logger = logger
 	return
 }
 
-func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (string, string, error) {
+func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (string, string, string, error) {
 	err := c.packageApplier.KeepOnly([]boshmodels.Package{})
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Removing packages")
+		return "", "", "", bosherr.WrapError(err, "Removing packages")
 	}
 
+	return c.compile(pkg, deps, false)
+}
+
+// compile optionally keeps compiled package installed
+// so that it can be used as a dependency without downloading it
+func (c concreteCompiler) compile(pkg Package, deps []boshmodels.Package, keepCompiled bool) (string, string, string, error) {
 	for _, dep := range deps {
 		err := c.packageApplier.Apply(dep)
 		if err != nil {
-			return "", "", bosherr.WrapError(err, "Installing dependent package: '%s'", dep.Name)
+			return "", "", "", bosherr.WrapError(err, "Installing dependent package: '%s'", dep.Name)
 		}
 	}
 
 	compilePath := filepath.Join(c.compileDirProvider.CompileDir(), pkg.Name)
-	err = c.fetchAndUncompress(pkg, compilePath)
+	err := c.fetchAndUncompress(pkg, compilePath)
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Fetching package %s", pkg.Name)
+		return "", "", "", bosherr.WrapError(err, "Fetching package %s", pkg.Name)
 	}
 
 	compiledPkg := boshmodels.Package{
@@ -72,61 +145,79 @@ func (c concreteCompiler) Compile(pkg Package, deps []boshmodels.Package) (strin
 
 	compiledPkgBundle, err := c.packagesBc.Get(compiledPkg)
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Getting bundle for new package")
+		return "", "", "", bosherr.WrapError(err, "Getting bundle for new package")
 	}
 
 	_, installPath, err := compiledPkgBundle.InstallWithoutContents()
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Setting up new package bundle")
+		return "", "", "", bosherr.WrapError(err, "Setting up new package bundle")
 	}
 
 	_, enablePath, err := compiledPkgBundle.Enable()
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Enabling new package bundle")
+		return "", "", "", bosherr.WrapError(err, "Enabling new package bundle")
 	}
 
+	var logBlobID string
+
 	scriptPath := filepath.Join(compilePath, "packaging")
 
 	if c.fs.FileExists(scriptPath) {
-		command := boshsys.Command{
-			Name: "bash",
-			Args: []string{"-x", "packaging"},
-			Env: map[string]string{
-				"BOSH_COMPILE_TARGET":  compilePath,
-				"BOSH_INSTALL_TARGET":  enablePath,
-				"BOSH_PACKAGE_NAME":    pkg.Name,
-				"BOSH_PACKAGE_VERSION": pkg.Version,
-			},
-			WorkingDir: compilePath,
-		}
+		logPath := filepath.Join(c.compileDirProvider.CompileDir(), pkg.Name+"-build.log")
+
+		err = c.packagingRunner.Run(pkg, compilePath, installPath, enablePath, logPath)
+
+		// Build log is most useful when packaging fails
+		logBlobID = c.uploadBuildLog(logPath)
 
-		_, _, _, err = c.runner.RunComplexCommand(command)
 		if err != nil {
-			return "", "", bosherr.WrapError(err, "Running packaging script")
+			return "", "", logBlobID, bosherr.WrapError(err, "Running packaging script (build log blob %s)", logBlobID)
 		}
 	}
 
 	tmpPackageTar, err := c.compressor.CompressFilesInDir(installPath)
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Compressing compiled package")
+		return "", "", logBlobID, bosherr.WrapError(err, "Compressing compiled package")
 	}
 
 	uploadedBlobID, sha1, err := c.blobstore.Create(tmpPackageTar)
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Uploading compiled package")
+		return "", "", logBlobID, bosherr.WrapError(err, "Uploading compiled package")
+	}
+
+	if keepCompiled {
+		return uploadedBlobID, sha1, logBlobID, nil
 	}
 
 	err = compiledPkgBundle.Disable()
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Disabling compiled package")
+		return "", "", logBlobID, bosherr.WrapError(err, "Disabling compiled package")
 	}
 
 	err = compiledPkgBundle.Uninstall()
 	if err != nil {
-		return "", "", bosherr.WrapError(err, "Uninstalling compiled package")
+		return "", "", logBlobID, bosherr.WrapError(err, "Uninstalling compiled package")
+	}
+
+	return uploadedBlobID, sha1, logBlobID, nil
+}
+
+// uploadBuildLog returns empty blob ID when build log cannot be uploaded
+// so that failing to upload it does not hide packaging result
+func (c concreteCompiler) uploadBuildLog(logPath string) string {
+	if !c.fs.FileExists(logPath) {
+		return ""
+	}
+
+	defer c.fs.RemoveAll(logPath)
+
+	logBlobID, _, err := c.blobstore.Create(logPath)
+	if err != nil {
+		c.logger.Error(compilerLogTag, "Failed to upload build log %s: %s", logPath, err.Error())
+		return ""
 	}
 
-	return uploadedBlobID, sha1, nil
+	return logBlobID
 }
 
 func (c concreteCompiler) fetchAndUncompress(pkg Package, targetDir string) error {
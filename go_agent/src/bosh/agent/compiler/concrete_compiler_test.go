@@ -3,6 +3,7 @@ package compiler_test
 import (
 	"errors"
 	"os"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -12,6 +13,7 @@ import (
 	fakepa "bosh/agent/applier/packageapplier/fakes"
 	. "bosh/agent/compiler"
 	fakeblobstore "bosh/blobstore/fakes"
+	boshlog "bosh/logger"
 	fakecmd "bosh/platform/commands/fakes"
 	boshsys "bosh/system"
 	fakesys "bosh/system/fakes"
@@ -63,6 +65,7 @@ func init() {
 			runner         *fakesys.FakeCmdRunner
 			packageApplier *fakepa.FakePackageApplier
 			packagesBc     *fakebc.FakeBundleCollection
+			options        Options
 		)
 
 		BeforeEach(func() {
@@ -72,7 +75,16 @@ func init() {
 			runner = fakesys.NewFakeCmdRunner()
 			packageApplier = fakepa.NewFakePackageApplier()
 			packagesBc = fakebc.NewFakeBundleCollection()
+			options = Options{
+				User:           "fake-user",
+				CPUShares:      100,
+				MemoryLimit:    1024,
+				TimeoutSeconds: 60,
+				CgroupRoot:     "/fake-cgroup",
+			}
+		})
 
+		JustBeforeEach(func() {
 			compiler = NewConcreteCompiler(
 				compressor,
 				blobstore,
@@ -81,6 +93,8 @@ func init() {
 				FakeCompileDirProvider{Dir: "/fake-compile-dir"},
 				packageApplier,
 				packagesBc,
+				options,
+				boshlog.NewLogger(boshlog.LevelNone),
 			)
 		})
 
@@ -111,7 +125,7 @@ func init() {
 				blobstore.CreateBlobID = "fake-blob-id"
 				blobstore.CreateFingerprint = "fake-blob-sha1"
 
-				blobID, sha1, err := compiler.Compile(pkg, pkgDeps)
+				blobID, sha1, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(blobID).To(Equal("fake-blob-id"))
@@ -119,7 +133,7 @@ func init() {
 			})
 
 			It("cleans up all packages before applying dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.ActionsCalled).To(Equal([]string{"KeepOnly", "Apply", "Apply"}))
 				Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
@@ -128,13 +142,13 @@ func init() {
 			It("returns an error if cleaning up packages fails", func() {
 				packageApplier.KeepOnlyErr = errors.New("fake-keep-only-error")
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-keep-only-error"))
 			})
 
 			It("fetches source package from blobstore", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(blobstore.GetBlobIDs[0]).To(Equal("blobstore_id"))
@@ -144,7 +158,7 @@ func init() {
 			It("returns an error if removing compile target directory during uncompression fails", func() {
 				fs.RegisterRemoveAllError("/fake-compile-dir/pkg_name", errors.New("fake-remove-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -152,7 +166,7 @@ func init() {
 			It("returns an error if creating compile target directory during uncompression fails", func() {
 				fs.RegisterMkdirAllError("/fake-compile-dir/pkg_name", errors.New("fake-mkdir-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
@@ -160,7 +174,7 @@ func init() {
 			It("returns an error if removing temporary compile target directory during uncompression fails", func() {
 				fs.RegisterRemoveAllError("/fake-compile-dir/pkg_name-bosh-agent-unpack", errors.New("fake-remove-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-remove-error"))
 			})
@@ -168,19 +182,19 @@ func init() {
 			It("returns an error if creating temporary compile target directory during uncompression fails", func() {
 				fs.RegisterMkdirAllError("/fake-compile-dir/pkg_name-bosh-agent-unpack", errors.New("fake-mkdir-error"))
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mkdir-error"))
 			})
 
 			It("installs dependent packages", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(packageApplier.AppliedPackages).To(Equal(pkgDeps))
 			})
 
 			It("extracts source package to compile dir", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fs.FileExists("/fake-compile-dir/pkg_name")).To(BeTrue())
@@ -192,7 +206,7 @@ func init() {
 			})
 
 			It("installs, enables and later cleans up bundle", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(bundle.ActionsCalled).To(Equal([]string{
 					"InstallWithoutContents",
@@ -203,37 +217,154 @@ func init() {
 			})
 
 			It("compresses compiled package", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(compressor.CompressFilesInDirDir).To(Equal("/fake-dir/data/packages/pkg_name/pkg_version"))
 			})
 
-			It("runs packaging script when packaging script exists", func() {
-				compressor.DecompressFileToDirCallBack = func() {
-					fs.WriteFileString("/fake-compile-dir/pkg_name/packaging", "hi")
-				}
+			Context("when packaging script exists", func() {
+				var (
+					packagingScript  string
+					packagingProcess *fakesys.FakeProcess
+				)
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
-				Expect(err).ToNot(HaveOccurred())
+				BeforeEach(func() {
+					compressor.DecompressFileToDirCallBack = func() {
+						fs.WriteFileString("/fake-compile-dir/pkg_name/packaging", "hi")
+					}
 
-				expectedCmd := boshsys.Command{
-					Name: "bash",
-					Args: []string{"-x", "packaging"},
-					Env: map[string]string{
-						"BOSH_COMPILE_TARGET":  "/fake-compile-dir/pkg_name",
-						"BOSH_INSTALL_TARGET":  "/fake-dir/packages/pkg_name",
-						"BOSH_PACKAGE_NAME":    "pkg_name",
-						"BOSH_PACKAGE_VERSION": "pkg_version",
-					},
-					WorkingDir: "/fake-compile-dir/pkg_name",
-				}
+					packagingScript = "exec 2>&1" +
+						" && echo $$ > '/fake-cgroup/cpu/bosh-compile-pkg_name/tasks'" +
+						" && echo $$ > '/fake-cgroup/memory/bosh-compile-pkg_name/tasks'" +
+						" && exec su -m fake-user -s /bin/bash -c 'bash -x packaging'"
+
+					packagingProcess = &fakesys.FakeProcess{
+						WaitResult: boshsys.Result{Stdout: "fake-build-output", Stderr: "fake-build-error"},
+					}
+					runner.AddProcess("bash -c "+packagingScript, packagingProcess)
+
+					blobstore.CreateBlobID = "fake-blob-id"
+				})
+
+				It("runs packaging script as compile user inside cgroups", func() {
+					_, _, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					expectedCmd := boshsys.Command{
+						Name: "bash",
+						Args: []string{"-c", packagingScript},
+						Env: map[string]string{
+							"BOSH_COMPILE_TARGET":  "/fake-compile-dir/pkg_name",
+							"BOSH_INSTALL_TARGET":  "/fake-dir/packages/pkg_name",
+							"BOSH_PACKAGE_NAME":    "pkg_name",
+							"BOSH_PACKAGE_VERSION": "pkg_version",
+						},
+						WorkingDir: "/fake-compile-dir/pkg_name",
+					}
+
+					Expect(len(runner.RunComplexCommands)).To(Equal(1))
+					Expect(runner.RunComplexCommands[0]).To(Equal(expectedCmd))
+				})
+
+				It("gives compile user ownership of compile and install dirs while packaging", func() {
+					_, _, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(runner.RunCommands).To(Equal([][]string{
+						{"id", "fake-user"},
+						{"chown", "-R", "fake-user", "/fake-compile-dir/pkg_name", "/fake-dir/data/packages/pkg_name/pkg_version"},
+						{"chown", "-R", "root:root", "/fake-dir/data/packages/pkg_name/pkg_version"},
+					}))
+				})
+
+				It("creates compile user when it does not exist", func() {
+					runner.AddCmdResult("id fake-user", fakesys.FakeCmdResult{Error: errors.New("fake-id-error")})
+
+					_, _, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(runner.RunCommands[1]).To(Equal([]string{"useradd", "--system", "--shell", "/bin/bash", "fake-user"}))
+				})
+
+				It("sets cgroup limits", func() {
+					// Keep cgroups around to inspect them
+					fs.RegisterRemoveAllError("/fake-cgroup/cpu/bosh-compile-pkg_name", errors.New("fake-remove-error"))
+					fs.RegisterRemoveAllError("/fake-cgroup/memory/bosh-compile-pkg_name", errors.New("fake-remove-error"))
+
+					_, _, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					cpuShares, err := fs.ReadFileString("/fake-cgroup/cpu/bosh-compile-pkg_name/cpu.shares")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(cpuShares).To(Equal("100"))
+
+					memoryLimit, err := fs.ReadFileString("/fake-cgroup/memory/bosh-compile-pkg_name/memory.limit_in_bytes")
+					Expect(err).ToNot(HaveOccurred())
+					Expect(memoryLimit).To(Equal("1024"))
+				})
 
-				Expect(len(runner.RunComplexCommands)).To(Equal(1))
-				Expect(runner.RunComplexCommands[0]).To(Equal(expectedCmd))
+				It("removes cgroups after packaging", func() {
+					_, _, _, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(fs.FileExists("/fake-cgroup/cpu/bosh-compile-pkg_name")).To(BeFalse())
+					Expect(fs.FileExists("/fake-cgroup/memory/bosh-compile-pkg_name")).To(BeFalse())
+				})
+
+				It("uploads build log and returns its blob id", func() {
+					blobID, _, logBlobID, err := compiler.Compile(pkg, pkgDeps)
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(blobID).To(Equal("fake-blob-id"))
+					Expect(logBlobID).To(Equal("fake-blob-id"))
+					Expect(blobstore.CreateFileNames[0]).To(Equal("/fake-compile-dir/pkg_name-build.log"))
+					Expect(fs.FileExists("/fake-compile-dir/pkg_name-build.log")).To(BeFalse())
+				})
+
+				Context("when packaging script fails", func() {
+					BeforeEach(func() {
+						packagingProcess.WaitResult = boshsys.Result{
+							Stdout:     "fake-build-output",
+							ExitStatus: 1,
+							Error:      errors.New("fake-packaging-error"),
+						}
+					})
+
+					It("returns error with build log and its blob id", func() {
+						_, _, logBlobID, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-packaging-error"))
+						Expect(err.Error()).To(ContainSubstring("fake-build-output"))
+						Expect(err.Error()).To(ContainSubstring("build log blob fake-blob-id"))
+
+						Expect(logBlobID).To(Equal("fake-blob-id"))
+						Expect(blobstore.CreateFileNames).To(Equal([]string{"/fake-compile-dir/pkg_name-build.log"}))
+					})
+				})
+
+				Context("when packaging script does not finish before timeout", func() {
+					BeforeEach(func() {
+						options.TimeoutSeconds = 1
+
+						packagingProcess.TerminatedNicelyCallBack = func(p *fakesys.FakeProcess) {
+							p.WaitCh <- boshsys.Result{Stdout: "fake-build-output", ExitStatus: -1}
+						}
+					})
+
+					It("kills packaging script and returns error", func() {
+						_, _, logBlobID, err := compiler.Compile(pkg, pkgDeps)
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("did not finish within 1s"))
+
+						Expect(packagingProcess.TerminatedNicely).To(BeTrue())
+						Expect(packagingProcess.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+						Expect(logBlobID).To(Equal("fake-blob-id"))
+					})
+				})
 			})
 
 			It("does not run packaging script when script does not exist", func() {
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(runner.RunCommands).To(BeEmpty())
 			})
@@ -241,10 +372,134 @@ func init() {
 			It("uploads compressed package", func() {
 				compressor.CompressFilesInDirTarballPath = "/tmp/foo"
 
-				_, _, err := compiler.Compile(pkg, pkgDeps)
+				_, _, _, err := compiler.Compile(pkg, pkgDeps)
 				Expect(err).ToNot(HaveOccurred())
 				Expect(blobstore.CreateFileName).To(Equal("/tmp/foo"))
 			})
 		})
+
+		Describe("CompileBatch", func() {
+			var (
+				pkgs []BatchPackage
+			)
+
+			BeforeEach(func() {
+				// Fakes are not safe for concurrent use
+				options.MaxParallelPackages = 1
+
+				blobstore.CreateBlobID = "fake-blob-id"
+				blobstore.CreateFingerprint = "fake-blob-sha1"
+
+				pkgs = []BatchPackage{
+					{
+						Package: Package{Name: "fake-pkg-3", Version: "fake-version-3", BlobstoreID: "fake-blob-3"},
+						Dependencies: Dependencies{
+							"fake-pkg-2": Package{Name: "fake-pkg-2", Version: "fake-version-2"},
+							"fake-external-pkg": Package{
+								Name:        "fake-external-pkg",
+								Version:     "fake-external-version",
+								BlobstoreID: "fake-external-blob",
+								Sha1:        "fake-external-sha1",
+							},
+						},
+					},
+					{
+						Package: Package{Name: "fake-pkg-2", Version: "fake-version-2", BlobstoreID: "fake-blob-2"},
+						Dependencies: Dependencies{
+							"fake-pkg-1": Package{Name: "fake-pkg-1", Version: "fake-version-1"},
+						},
+					},
+					{
+						Package: Package{Name: "fake-pkg-1", Version: "fake-version-1", BlobstoreID: "fake-blob-1"},
+					},
+				}
+			})
+
+			It("compiles packages in dependency order and returns results by package name", func() {
+				results, err := compiler.CompileBatch(pkgs)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(blobstore.GetBlobIDs).To(Equal([]string{"fake-blob-1", "fake-blob-2", "fake-blob-3"}))
+
+				Expect(results).To(Equal(map[string]BatchResult{
+					"fake-pkg-1": BatchResult{BlobstoreID: "fake-blob-id", Sha1: "fake-blob-sha1"},
+					"fake-pkg-2": BatchResult{BlobstoreID: "fake-blob-id", Sha1: "fake-blob-sha1"},
+					"fake-pkg-3": BatchResult{BlobstoreID: "fake-blob-id", Sha1: "fake-blob-sha1"},
+				}))
+			})
+
+			It("uses packages compiled in the batch as dependencies without downloading them", func() {
+				_, err := compiler.CompileBatch(pkgs)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageApplier.AppliedPackages).To(Equal([]boshmodels.Package{
+					{Name: "fake-pkg-1", Version: "fake-version-1"},
+					{
+						Name:    "fake-external-pkg",
+						Version: "fake-external-version",
+						Source: boshmodels.Source{
+							Sha1:        "fake-external-sha1",
+							BlobstoreID: "fake-external-blob",
+						},
+					},
+					{Name: "fake-pkg-2", Version: "fake-version-2"},
+				}))
+
+				pkg1Bundle := packagesBc.FakeGet(boshmodels.Package{Name: "fake-pkg-1", Version: "fake-version-1"})
+				Expect(pkg1Bundle.ActionsCalled).To(Equal([]string{"InstallWithoutContents", "Enable"}))
+			})
+
+			It("removes all packages before and after compiling the batch", func() {
+				_, err := compiler.CompileBatch(pkgs)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(packageApplier.ActionsCalled[0]).To(Equal("KeepOnly"))
+				Expect(packageApplier.ActionsCalled[len(packageApplier.ActionsCalled)-1]).To(Equal("KeepOnly"))
+				Expect(packageApplier.KeptOnlyPackages).To(BeEmpty())
+			})
+
+			It("reports failure of a package and does not compile packages that depend on it", func() {
+				pkg2Bundle := packagesBc.FakeGet(boshmodels.Package{Name: "fake-pkg-2", Version: "fake-version-2"})
+				pkg2Bundle.InstallError = errors.New("fake-install-error")
+
+				results, err := compiler.CompileBatch(pkgs)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(results["fake-pkg-1"]).To(Equal(BatchResult{BlobstoreID: "fake-blob-id", Sha1: "fake-blob-sha1"}))
+				Expect(results["fake-pkg-2"].Error).To(ContainSubstring("fake-install-error"))
+				Expect(results["fake-pkg-3"].Error).To(ContainSubstring("fake-pkg-2"))
+
+				Expect(blobstore.GetBlobIDs).To(Equal([]string{"fake-blob-1", "fake-blob-2"}))
+			})
+
+			It("returns error when packages have cyclic dependencies", func() {
+				pkgs[2].Dependencies = Dependencies{
+					"fake-pkg-3": Package{Name: "fake-pkg-3", Version: "fake-version-3"},
+				}
+
+				_, err := compiler.CompileBatch(pkgs)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Packages have cyclic dependencies: [fake-pkg-1 fake-pkg-2 fake-pkg-3]"))
+				Expect(blobstore.GetBlobIDs).To(BeEmpty())
+			})
+
+			It("returns error when dependency is not in batch and does not have blobstore id", func() {
+				pkgs[2].Dependencies = Dependencies{
+					"fake-unknown-pkg": Package{Name: "fake-unknown-pkg", Version: "fake-unknown-version"},
+				}
+
+				_, err := compiler.CompileBatch(pkgs)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Dependency fake-unknown-pkg of package fake-pkg-1"))
+			})
+
+			It("returns error when package is included more than once", func() {
+				pkgs = append(pkgs, pkgs[2])
+
+				_, err := compiler.CompileBatch(pkgs)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Package fake-pkg-1 is included more than once"))
+			})
+		})
 	})
 }
@@ -6,11 +6,16 @@ import (
 )
 
 type FakeCompiler struct {
-	CompilePkg    boshcomp.Package
-	CompileDeps   []boshmodels.Package
-	CompileBlobID string
-	CompileSha1   string
-	CompileErr    error
+	CompilePkg       boshcomp.Package
+	CompileDeps      []boshmodels.Package
+	CompileBlobID    string
+	CompileSha1      string
+	CompileLogBlobID string
+	CompileErr       error
+
+	CompileBatchPkgs    []boshcomp.BatchPackage
+	CompileBatchResults map[string]boshcomp.BatchResult
+	CompileBatchErr     error
 }
 
 func NewFakeCompiler() (c *FakeCompiler) {
@@ -18,11 +23,17 @@ func NewFakeCompiler() (c *FakeCompiler) {
 	return
 }
 
-func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package) (blobID, sha1 string, err error) {
+func (c *FakeCompiler) Compile(pkg boshcomp.Package, deps []boshmodels.Package) (blobID, sha1, logBlobID string, err error) {
 	c.CompilePkg = pkg
 	c.CompileDeps = deps
 	blobID = c.CompileBlobID
 	sha1 = c.CompileSha1
+	logBlobID = c.CompileLogBlobID
 	err = c.CompileErr
 	return
 }
+
+func (c *FakeCompiler) CompileBatch(pkgs []boshcomp.BatchPackage) (map[string]boshcomp.BatchResult, error) {
+	c.CompileBatchPkgs = pkgs
+	return c.CompileBatchResults, c.CompileBatchErr
+}
@@ -0,0 +1,216 @@
+package compiler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const (
+	// Only the end of the build log is included into errors
+	// since full build log is uploaded to the blobstore
+	maxBuildLogInError = 1024
+
+	packagingKillGracePeriod = 10 * time.Second
+)
+
+// packagingRunner runs packaging scripts as an unprivileged user
+// inside cpu and memory cgroups so that they cannot exhaust compile VM.
+type packagingRunner struct {
+	fs      boshsys.FileSystem
+	runner  boshsys.CmdRunner
+	options Options
+	logger  boshlog.Logger
+	logTag  string
+}
+
+func newPackagingRunner(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	options Options,
+	logger boshlog.Logger,
+) packagingRunner {
+	return packagingRunner{
+		fs:      fs,
+		runner:  runner,
+		options: options,
+		logger:  logger,
+		logTag:  "packagingRunner",
+	}
+}
+
+// Run writes combined stdout and stderr of packaging script to logPath
+// even when packaging script fails.
+func (r packagingRunner) Run(pkg Package, compilePath, installPath, enablePath, logPath string) error {
+	err := r.ensureUser()
+	if err != nil {
+		return err
+	}
+
+	// Compile user needs to write into compile and install dirs
+	_, _, _, err = r.runner.RunCommand("chown", "-R", r.options.User, compilePath, installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Changing owner of compile and install dirs")
+	}
+
+	cgroupDirs, err := r.createCgroups(pkg)
+	defer r.removeCgroups(cgroupDirs)
+	if err != nil {
+		return err
+	}
+
+	var script []string
+
+	// Combined output preserves ordering of messages in the build log
+	script = append(script, "exec 2>&1")
+
+	for _, dir := range cgroupDirs {
+		script = append(script, fmt.Sprintf("echo $$ > '%s'", filepath.Join(dir, "tasks")))
+	}
+
+	script = append(script, fmt.Sprintf("exec su -m %s -s /bin/bash -c 'bash -x packaging'", r.options.User))
+
+	command := boshsys.Command{
+		Name: "bash",
+		Args: []string{"-c", strings.Join(script, " && ")},
+		Env: map[string]string{
+			"BOSH_COMPILE_TARGET":  compilePath,
+			"BOSH_INSTALL_TARGET":  enablePath,
+			"BOSH_PACKAGE_NAME":    pkg.Name,
+			"BOSH_PACKAGE_VERSION": pkg.Version,
+		},
+		WorkingDir: compilePath,
+	}
+
+	result, timedOut, err := r.runWithTimeout(command)
+	if err != nil {
+		return err
+	}
+
+	buildLog := result.Stdout + result.Stderr
+
+	err = r.fs.WriteFileString(logPath, buildLog)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing build log")
+	}
+
+	// Compiled package is extracted as root on other VMs
+	// which would otherwise preserve compile user's ownership
+	_, _, _, err = r.runner.RunCommand("chown", "-R", "root:root", installPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Changing owner of install dir")
+	}
+
+	if timedOut {
+		return bosherr.New("Packaging script did not finish within %s; build log: %s", r.options.timeout(), tail(buildLog))
+	}
+
+	if result.Error != nil {
+		return bosherr.WrapError(result.Error, "Packaging script failed with exit code %d; build log: %s", result.ExitStatus, tail(buildLog))
+	}
+
+	return nil
+}
+
+func (r packagingRunner) ensureUser() error {
+	_, _, _, err := r.runner.RunCommand("id", r.options.User)
+	if err == nil {
+		return nil
+	}
+
+	_, _, _, err = r.runner.RunCommand("useradd", "--system", "--shell", "/bin/bash", r.options.User)
+	if err != nil {
+		return bosherr.WrapError(err, "Creating compile user %s", r.options.User)
+	}
+
+	return nil
+}
+
+func (r packagingRunner) createCgroups(pkg Package) ([]string, error) {
+	var dirs []string
+
+	cgroupName := "bosh-compile-" + pkg.Name
+
+	limits := []struct {
+		subsystem string
+		file      string
+		value     int64
+	}{
+		{subsystem: "cpu", file: "cpu.shares", value: int64(r.options.CPUShares)},
+		{subsystem: "memory", file: "memory.limit_in_bytes", value: r.options.MemoryLimit},
+	}
+
+	for _, limit := range limits {
+		if limit.value <= 0 {
+			continue
+		}
+
+		dir := filepath.Join(r.options.CgroupRoot, limit.subsystem, cgroupName)
+
+		err := r.fs.MkdirAll(dir, os.FileMode(0755))
+		if err != nil {
+			return dirs, bosherr.WrapError(err, "Creating %s cgroup", limit.subsystem)
+		}
+
+		dirs = append(dirs, dir)
+
+		err = r.fs.WriteFileString(filepath.Join(dir, limit.file), strconv.FormatInt(limit.value, 10))
+		if err != nil {
+			return dirs, bosherr.WrapError(err, "Setting %s", limit.file)
+		}
+	}
+
+	return dirs, nil
+}
+
+// removeCgroups ignores errors since left over cgroups
+// are reused next time same package is compiled
+func (r packagingRunner) removeCgroups(dirs []string) {
+	for _, dir := range dirs {
+		err := r.fs.RemoveAll(dir)
+		if err != nil {
+			r.logger.Error(r.logTag, "Failed to remove cgroup %s: %s", dir, err.Error())
+		}
+	}
+}
+
+func (r packagingRunner) runWithTimeout(command boshsys.Command) (boshsys.Result, bool, error) {
+	var result boshsys.Result
+	var timedOut bool
+
+	process, err := r.runner.RunComplexCommandAsync(command)
+	if err != nil {
+		return result, false, bosherr.WrapError(err, "Running packaging script")
+	}
+
+	timer := time.NewTimer(r.options.timeout())
+	defer timer.Stop()
+
+	// Can only wait once on a process
+	for processExitedCh := process.Wait(); processExitedCh != nil; {
+		select {
+		case result = <-processExitedCh:
+			processExitedCh = nil
+		case <-timer.C:
+			timedOut = true
+			// Ignore possible TerminateNicely error since packaging is reported as failed anyway
+			process.TerminateNicely(packagingKillGracePeriod)
+		}
+	}
+
+	return result, timedOut, nil
+}
+
+func tail(output string) string {
+	if len(output) > maxBuildLogInError {
+		return "..." + output[len(output)-maxBuildLogInError:]
+	}
+	return output
+}
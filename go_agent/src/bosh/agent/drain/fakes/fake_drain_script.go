@@ -10,6 +10,10 @@ type FakeDrainScript struct {
 	RunExitStatus int
 	RunError      error
 	RunParams     boshdrain.DrainScriptParams
+
+	// Consumed by subsequent runs before falling back to RunExitStatus
+	RunExitStatuses []int
+	RunParamsList   []boshdrain.DrainScriptParams
 }
 
 func NewFakeDrainScript() (script *FakeDrainScript) {
@@ -30,7 +34,14 @@ func (script *FakeDrainScript) Path() string {
 func (script *FakeDrainScript) Run(params boshdrain.DrainScriptParams) (value int, err error) {
 	script.DidRun = true
 	script.RunParams = params
+	script.RunParamsList = append(script.RunParamsList, params)
 	value = script.RunExitStatus
+
+	if len(script.RunExitStatuses) > 0 {
+		value = script.RunExitStatuses[0]
+		script.RunExitStatuses = script.RunExitStatuses[1:]
+	}
+
 	err = script.RunError
 	return
 }
@@ -1,22 +1,43 @@
 package fakes
 
 import (
+	"sync"
+
 	boshdrain "bosh/agent/drain"
 )
 
 type FakeDrainScriptProvider struct {
-	NewDrainScriptTemplateName string
-	NewDrainScriptDrainScript  *FakeDrainScript
+	lock sync.Mutex
+
+	NewDrainScriptTemplateName  string
+	NewDrainScriptTemplateNames []string
+
+	// Returned for templates that do not have their own drain script
+	NewDrainScriptDrainScript *FakeDrainScript
+
+	// Keyed by template name
+	DrainScripts map[string]*FakeDrainScript
 }
 
 func NewFakeDrainScriptProvider() (provider *FakeDrainScriptProvider) {
-	provider = &FakeDrainScriptProvider{}
+	provider = &FakeDrainScriptProvider{
+		DrainScripts: map[string]*FakeDrainScript{},
+	}
 	provider.NewDrainScriptDrainScript = NewFakeDrainScript()
 	return
 }
 
 func (p *FakeDrainScriptProvider) NewDrainScript(templateName string) (drainScript boshdrain.DrainScript) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
 	p.NewDrainScriptTemplateName = templateName
+	p.NewDrainScriptTemplateNames = append(p.NewDrainScriptTemplateNames, templateName)
+
+	if script, found := p.DrainScripts[templateName]; found {
+		return script
+	}
+
 	drainScript = p.NewDrainScriptDrainScript
 	return
 }
@@ -0,0 +1,20 @@
+package drain
+
+import (
+	"time"
+)
+
+const DefaultTimeoutSeconds = 60 * 60
+
+type Options struct {
+	// Dynamic drain (drain script returning negative values)
+	// fails if it does not finish within this many seconds
+	TimeoutSeconds int
+}
+
+func (o Options) Timeout() time.Duration {
+	if o.TimeoutSeconds <= 0 {
+		return DefaultTimeoutSeconds * time.Second
+	}
+	return time.Duration(o.TimeoutSeconds) * time.Second
+}
@@ -1,27 +1,36 @@
 package drain
 
 import (
+	"sort"
+
 	boshas "bosh/agent/applier/applyspec"
 )
 
 type updateDrainParams struct {
-	oldSpec boshas.V1ApplySpec
-	newSpec boshas.V1ApplySpec
+	oldSpec boshas.V2ApplySpec
+	newSpec boshas.V2ApplySpec
+	jobName string
 }
 
-func NewUpdateDrainParams(oldSpec, newSpec boshas.V1ApplySpec) (params updateDrainParams) {
+// NewUpdateDrainParams returns params for the drain script of the job
+// with given name since colocated jobs might change independently.
+func NewUpdateDrainParams(oldSpec, newSpec boshas.V2ApplySpec, jobName string) (params updateDrainParams) {
 	params = updateDrainParams{
 		oldSpec: oldSpec,
 		newSpec: newSpec,
+		jobName: jobName,
 	}
 	return
 }
 
 func (p updateDrainParams) JobChange() string {
+	oldJobSpec, oldFound := p.oldSpec.JobSpecByName(p.jobName)
+	newJobSpec, _ := p.newSpec.JobSpecByName(p.jobName)
+
 	switch {
-	case len(p.oldSpec.Jobs()) == 0:
+	case len(p.oldSpec.Jobs()) == 0 || !oldFound:
 		return "job_new"
-	case p.oldSpec.JobSpec.Sha1 == p.newSpec.JobSpec.Sha1:
+	case oldJobSpec.Sha1 == newJobSpec.Sha1:
 		return "job_unchanged"
 	default:
 		return "job_changed"
@@ -40,8 +49,11 @@ func (p updateDrainParams) HashChange() string {
 }
 
 func (p updateDrainParams) UpdatedPackages() (pkgs []string) {
-	for _, pkg := range p.newSpec.PackageSpecs {
-		currentPkg, found := p.oldSpec.PackageSpecs[pkg.Name]
+	oldJobSpec, _ := p.oldSpec.JobSpecByName(p.jobName)
+	newJobSpec, _ := p.newSpec.JobSpecByName(p.jobName)
+
+	for _, pkg := range newJobSpec.PackageSpecs {
+		currentPkg, found := oldJobSpec.PackageSpecs[pkg.Name]
 		switch {
 		case !found:
 			pkgs = append(pkgs, pkg.Name)
@@ -49,5 +61,8 @@ func (p updateDrainParams) UpdatedPackages() (pkgs []string) {
 			pkgs = append(pkgs, pkg.Name)
 		}
 	}
+
+	sort.Strings(pkgs)
+
 	return
 }
@@ -9,9 +9,15 @@ import (
 )
 
 func init() {
-	Describe("Testing with Ginkgo", func() {
-		It("update packages", func() {
+	Describe("updateDrainParams", func() {
+		buildSpec := func(configHash string, jobSpecs ...boshas.V2JobSpec) boshas.V2ApplySpec {
+			return boshas.V2ApplySpec{
+				V1ApplySpec: boshas.V1ApplySpec{ConfigurationHash: configHash},
+				JobSpecs:    jobSpecs,
+			}
+		}
 
+		It("update packages", func() {
 			oldPkgs := map[string]boshas.PackageSpec{
 				"foo": boshas.PackageSpec{
 					Name: "foo",
@@ -38,15 +44,95 @@ func init() {
 			}
 
 			oldSpec := boshas.V1ApplySpec{
+				JobSpec: boshas.JobSpec{
+					JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}},
+				},
 				PackageSpecs: oldPkgs,
 			}
 			newSpec := boshas.V1ApplySpec{
+				JobSpec: boshas.JobSpec{
+					JobTemplateSpecs: []boshas.JobTemplateSpec{{Name: "fake-job"}},
+				},
 				PackageSpecs: newPkgs,
 			}
 
-			params := NewUpdateDrainParams(oldSpec, newSpec)
+			params := NewUpdateDrainParams(oldSpec.ToV2(), newSpec.ToV2(), "fake-job")
+
+			Expect(params.UpdatedPackages()).To(Equal([]string{"baz", "foo"}))
+		})
+
+		It("only includes updated packages that job depends on", func() {
+			oldSpec := buildSpec("",
+				boshas.V2JobSpec{Name: "fake-job-1"},
+				boshas.V2JobSpec{Name: "fake-job-2"},
+			)
+			newSpec := buildSpec("",
+				boshas.V2JobSpec{
+					Name:         "fake-job-1",
+					PackageSpecs: map[string]boshas.PackageSpec{"foo": boshas.PackageSpec{Name: "foo"}},
+				},
+				boshas.V2JobSpec{
+					Name:         "fake-job-2",
+					PackageSpecs: map[string]boshas.PackageSpec{"bar": boshas.PackageSpec{Name: "bar"}},
+				},
+			)
+
+			params := NewUpdateDrainParams(oldSpec, newSpec, "fake-job-2")
+			Expect(params.UpdatedPackages()).To(Equal([]string{"bar"}))
+		})
+
+		Describe("JobChange", func() {
+			It("returns job_new when old spec does not have jobs", func() {
+				params := NewUpdateDrainParams(buildSpec(""), buildSpec("", boshas.V2JobSpec{Name: "fake-job"}), "fake-job")
+				Expect(params.JobChange()).To(Equal("job_new"))
+			})
+
+			It("returns job_new when old spec does not have the job", func() {
+				oldSpec := buildSpec("", boshas.V2JobSpec{Name: "fake-other-job"})
+				newSpec := buildSpec("", boshas.V2JobSpec{Name: "fake-job"})
+
+				params := NewUpdateDrainParams(oldSpec, newSpec, "fake-job")
+				Expect(params.JobChange()).To(Equal("job_new"))
+			})
+
+			It("returns job_unchanged when job sha1 is the same", func() {
+				oldSpec := buildSpec("", boshas.V2JobSpec{Name: "fake-job", Sha1: "fake-sha1"})
+				newSpec := buildSpec("", boshas.V2JobSpec{Name: "fake-job", Sha1: "fake-sha1"})
+
+				params := NewUpdateDrainParams(oldSpec, newSpec, "fake-job")
+				Expect(params.JobChange()).To(Equal("job_unchanged"))
+			})
+
+			It("returns job_changed when job sha1 is different", func() {
+				oldSpec := buildSpec("",
+					boshas.V2JobSpec{Name: "fake-job", Sha1: "fake-old-sha1"},
+					boshas.V2JobSpec{Name: "fake-other-job", Sha1: "fake-sha1"},
+				)
+				newSpec := buildSpec("",
+					boshas.V2JobSpec{Name: "fake-job", Sha1: "fake-new-sha1"},
+					boshas.V2JobSpec{Name: "fake-other-job", Sha1: "fake-sha1"},
+				)
+
+				Expect(NewUpdateDrainParams(oldSpec, newSpec, "fake-job").JobChange()).To(Equal("job_changed"))
+				Expect(NewUpdateDrainParams(oldSpec, newSpec, "fake-other-job").JobChange()).To(Equal("job_unchanged"))
+			})
+		})
+
+		Describe("HashChange", func() {
+			It("returns hash_new when old spec does not have configuration hash", func() {
+				params := NewUpdateDrainParams(buildSpec(""), buildSpec("fake-hash"), "fake-job")
+				Expect(params.HashChange()).To(Equal("hash_new"))
+			})
+
+			It("returns hash_unchanged when configuration hash is the same", func() {
+				params := NewUpdateDrainParams(buildSpec("fake-hash"), buildSpec("fake-hash"), "fake-job")
+				Expect(params.HashChange()).To(Equal("hash_unchanged"))
+			})
 
-			Expect(params.UpdatedPackages()).To(Equal([]string{"foo", "baz"}))
+			It("returns hash_changed when configuration hash is different", func() {
+				params := NewUpdateDrainParams(buildSpec("fake-old-hash"), buildSpec("fake-new-hash"), "fake-job")
+				Expect(params.HashChange()).To(Equal("hash_changed"))
+			})
 		})
 	})
 }
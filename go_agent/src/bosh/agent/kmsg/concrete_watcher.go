@@ -0,0 +1,245 @@
+package kmsg
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	boshalert "bosh/agent/alert"
+	bosherr "bosh/errors"
+	boshjobsuper "bosh/jobsupervisor"
+	boshlog "bosh/logger"
+)
+
+const (
+	concreteWatcherLogTag = "kmsgWatcher"
+
+	DefaultReopenDelay = 1 * time.Second
+	maxReopenDelay     = 5 * time.Minute
+)
+
+var (
+	// e.g. "oom-kill:constraint=CONSTRAINT_MEMCG,...,task_memcg=/system.slice/redis.service,task=redis-server,pid=1234,uid=0"
+	oomKillCgroupRegexp = regexp.MustCompile(`oom-kill:.*task_memcg=([^,\s]+)`)
+
+	// e.g. "Task in /system.slice/redis.service killed as a result of limit of /system.slice/redis.service" (older kernels)
+	oomTaskCgroupRegexp = regexp.MustCompile(`Task in (\S+) killed as a result of limit`)
+
+	// e.g. "Killed process 1234 (redis-server) total-vm:1048576kB, anon-rss:524288kB, file-rss:0kB"
+	oomKilledRegexp = regexp.MustCompile(`Killed process (\d+) \(([^)]*)\)`)
+	oomRSSRegexp    = regexp.MustCompile(`(?:anon|file|shmem)-rss:(\d+)kB`)
+
+	// e.g. "redis-server[1234]: segfault at 0 ip 00007f8b0c1d2e3f sp 00007ffd4c3b2a10 error 4 in libc.so.6"
+	segfaultRegexp = regexp.MustCompile(`^(.+?)\[(\d+)\]: segfault at`)
+)
+
+type concreteWatcher struct {
+	openReader    ReaderOpener
+	jobSupervisor boshjobsuper.JobSupervisor
+	logger        boshlog.Logger
+
+	// Reader is reopened after this delay when reading fails;
+	// delay doubles while reader keeps failing right away
+	reopenDelay time.Duration
+
+	// Cgroup is logged before matching Killed process message
+	oomCgroup string
+}
+
+func NewWatcher(
+	openReader ReaderOpener,
+	jobSupervisor boshjobsuper.JobSupervisor,
+	reopenDelay time.Duration,
+	logger boshlog.Logger,
+) Watcher {
+	return &concreteWatcher{
+		openReader:    openReader,
+		jobSupervisor: jobSupervisor,
+		logger:        logger,
+		reopenDelay:   reopenDelay,
+	}
+}
+
+func (w *concreteWatcher) Watch(handler EventHandler) error {
+	reader, err := w.openReader()
+	if err != nil {
+		return bosherr.WrapError(err, "Opening kernel messages")
+	}
+
+	delay := w.reopenDelay
+
+	for {
+		gotMessages, err := w.readMessages(reader, handler)
+		reader.Close()
+
+		if err == nil {
+			return nil
+		}
+
+		if gotMessages {
+			delay = w.reopenDelay
+		}
+
+		w.logger.Error(concreteWatcherLogTag, "Reading kernel messages, reopening in %s: %s", delay, err.Error())
+
+		reader, delay = w.reopen(delay)
+	}
+}
+
+// readMessages returns nil error once reader is exhausted
+func (w *concreteWatcher) readMessages(reader io.Reader, handler EventHandler) (bool, error) {
+	bufReader := bufio.NewReader(reader)
+	gotMessages := false
+
+	for {
+		line, err := bufReader.ReadString('\n')
+		if len(line) > 0 {
+			gotMessages = true
+			w.handleLine(strings.TrimSuffix(line, "\n"), handler)
+		}
+
+		if err == io.EOF {
+			return gotMessages, nil
+		}
+
+		if isOverrun(err) {
+			w.logger.Error(concreteWatcherLogTag, "Kernel messages were overwritten before they were read")
+
+			// Lost messages might have included matching Killed process message
+			w.oomCgroup = ""
+
+			continue
+		}
+
+		if err != nil {
+			return gotMessages, err
+		}
+	}
+}
+
+func (w *concreteWatcher) reopen(delay time.Duration) (io.ReadCloser, time.Duration) {
+	for {
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxReopenDelay {
+			delay = maxReopenDelay
+		}
+
+		reader, err := w.openReader()
+		if err == nil {
+			return reader, delay
+		}
+
+		w.logger.Error(concreteWatcherLogTag, "Reopening kernel messages, retrying in %s: %s", delay, err.Error())
+	}
+}
+
+func (w *concreteWatcher) handleLine(line string, handler EventHandler) {
+	event, found := w.parseLine(line)
+	if !found {
+		return
+	}
+
+	event.Time = time.Now()
+	w.resolveService(&event)
+
+	w.logger.Info(concreteWatcherLogTag, "Kernel reported %s of process %d (%s)", event.Type, event.Pid, event.Command)
+
+	err := handler(event)
+	if err != nil {
+		w.logger.Error(concreteWatcherLogTag, "Handling kernel event: %s", err.Error())
+	}
+}
+
+func (w *concreteWatcher) parseLine(line string) (boshalert.KernelEvent, bool) {
+	// Continuation lines of /dev/kmsg records carry key=value pairs
+	if strings.HasPrefix(line, " ") {
+		return boshalert.KernelEvent{}, false
+	}
+
+	message := line
+
+	// Records from /dev/kmsg are prefixed with "priority,sequence,timestamp,flags;"
+	parts := strings.SplitN(line, ";", 2)
+	if len(parts) == 2 && strings.Count(parts[0], ",") >= 2 {
+		message = parts[1]
+	}
+
+	if matches := oomKillCgroupRegexp.FindStringSubmatch(message); matches != nil {
+		w.oomCgroup = matches[1]
+		return boshalert.KernelEvent{}, false
+	}
+
+	if matches := oomTaskCgroupRegexp.FindStringSubmatch(message); matches != nil {
+		w.oomCgroup = matches[1]
+		return boshalert.KernelEvent{}, false
+	}
+
+	if matches := oomKilledRegexp.FindStringSubmatch(message); matches != nil {
+		event := boshalert.KernelEvent{
+			Type:    boshalert.KernelEventOOMKill,
+			Pid:     parseInt(matches[1]),
+			Command: matches[2],
+			Cgroup:  w.oomCgroup,
+			Message: message,
+		}
+
+		for _, rssMatches := range oomRSSRegexp.FindAllStringSubmatch(message, -1) {
+			event.RSSKb += parseInt(rssMatches[1])
+		}
+
+		w.oomCgroup = ""
+
+		return event, true
+	}
+
+	if matches := segfaultRegexp.FindStringSubmatch(message); matches != nil {
+		return boshalert.KernelEvent{
+			Type:    boshalert.KernelEventSegfault,
+			Pid:     parseInt(matches[2]),
+			Command: matches[1],
+			Message: message,
+		}, true
+	}
+
+	return boshalert.KernelEvent{}, false
+}
+
+// resolveService finds job supervisor process by pid,
+// then by command name and finally by systemd cgroup unit name
+func (w *concreteWatcher) resolveService(event *boshalert.KernelEvent) {
+	processes, err := w.jobSupervisor.Processes()
+	if err != nil {
+		w.logger.Error(concreteWatcherLogTag, "Getting processes to resolve service: %s", err.Error())
+		return
+	}
+
+	for _, process := range processes {
+		if process.Pid == event.Pid {
+			event.Service = process.Name
+			return
+		}
+	}
+
+	cgroupUnit := strings.TrimSuffix(path.Base(event.Cgroup), ".service")
+
+	for _, process := range processes {
+		if process.Name == event.Command || (event.Cgroup != "" && process.Name == cgroupUnit) {
+			event.Service = process.Name
+			return
+		}
+	}
+}
+
+func parseInt(value string) int {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return number
+}
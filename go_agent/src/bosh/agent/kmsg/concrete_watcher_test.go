@@ -0,0 +1,263 @@
+package kmsg_test
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "bosh/agent/alert"
+	. "bosh/agent/kmsg"
+	boshjobsuper "bosh/jobsupervisor"
+	fakejobsuper "bosh/jobsupervisor/fakes"
+	boshlog "bosh/logger"
+)
+
+// fakeKmsgReader returns one result per Read like /dev/kmsg does
+type fakeKmsgReader struct {
+	reads []fakeKmsgRead
+}
+
+type fakeKmsgRead struct {
+	message string
+	err     error
+}
+
+func (r *fakeKmsgReader) Read(p []byte) (int, error) {
+	if len(r.reads) == 0 {
+		return 0, io.EOF
+	}
+
+	read := r.reads[0]
+	r.reads = r.reads[1:]
+
+	return copy(p, read.message), read.err
+}
+
+func (r *fakeKmsgReader) Close() error { return nil }
+
+func init() {
+	Describe("concreteWatcher", func() {
+		var (
+			kernelMessages string
+			openErr        error
+			openReader     ReaderOpener
+			jobSupervisor  *fakejobsuper.FakeJobSupervisor
+			watcher        Watcher
+			events         []boshalert.KernelEvent
+		)
+
+		BeforeEach(func() {
+			kernelMessages = ""
+			openErr = nil
+			jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+			events = nil
+
+			openReader = func() (io.ReadCloser, error) {
+				return ioutil.NopCloser(strings.NewReader(kernelMessages)), openErr
+			}
+		})
+
+		JustBeforeEach(func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			watcher = NewWatcher(openReader, jobSupervisor, 1*time.Millisecond, logger)
+		})
+
+		segfaultMessage := func(pid string) string {
+			return "6,2001,6000000,-;nats[" + pid + "]: segfault at 0 ip 00007f8b0c1d2e3f sp 00007ffd4c3b2a10 error 4 in libc.so.6\n"
+		}
+
+		// Returns given readers or errors on each open
+		openInSequence := func(results ...interface{}) (ReaderOpener, *int) {
+			opened := 0
+
+			return func() (io.ReadCloser, error) {
+				result := results[opened]
+				opened++
+
+				if err, ok := result.(error); ok {
+					return nil, err
+				}
+
+				return result.(io.ReadCloser), nil
+			}, &opened
+		}
+
+		watch := func() error {
+			return watcher.Watch(func(event boshalert.KernelEvent) error {
+				events = append(events, event)
+				return nil
+			})
+		}
+
+		Describe("Watch", func() {
+			Context("when process is killed by OOM killer", func() {
+				BeforeEach(func() {
+					kernelMessages = strings.Join([]string{
+						"6,1001,5000000,-;redis-server invoked oom-killer: gfp_mask=0x100cca(GFP_HIGHUSER_MOVABLE), order=0, oom_score_adj=0",
+						" SUBSYSTEM=memory",
+						"6,1002,5000001,-;oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=/,mems_allowed=0,oom_memcg=/system.slice/redis.service,task_memcg=/system.slice/redis.service,task=redis-server,pid=1234,uid=0",
+						"3,1003,5000002,-;Memory cgroup out of memory: Killed process 1234 (redis-server) total-vm:1048576kB, anon-rss:524288kB, file-rss:1024kB, shmem-rss:0kB, UID:0 pgtables:1200kB oom_score_adj:0",
+					}, "\n")
+
+					jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+						{Name: "nats", Pid: 99},
+						{Name: "redis", Pid: 1234},
+					}
+				})
+
+				It("reports killed process with rss, cgroup and service", func() {
+					err := watch()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(events).To(HaveLen(1))
+					Expect(events[0].Type).To(Equal(boshalert.KernelEventOOMKill))
+					Expect(events[0].Pid).To(Equal(1234))
+					Expect(events[0].Command).To(Equal("redis-server"))
+					Expect(events[0].Service).To(Equal("redis"))
+					Expect(events[0].RSSKb).To(Equal(525312))
+					Expect(events[0].Cgroup).To(Equal("/system.slice/redis.service"))
+					Expect(events[0].Message).To(ContainSubstring("Killed process 1234 (redis-server)"))
+					Expect(events[0].Time.IsZero()).To(BeFalse())
+				})
+			})
+
+			Context("when older kernel reports OOM kill", func() {
+				BeforeEach(func() {
+					kernelMessages = strings.Join([]string{
+						"Task in /docker/abc killed as a result of limit of /docker/abc",
+						"Out of memory: Kill process 4321 (java) score 900 or sacrifice child",
+						"Killed process 4321 (java) total-vm:2048kB, anon-rss:1024kB, file-rss:16kB",
+					}, "\n")
+				})
+
+				It("reports killed process once", func() {
+					err := watch()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(events).To(HaveLen(1))
+					Expect(events[0].Pid).To(Equal(4321))
+					Expect(events[0].Command).To(Equal("java"))
+					Expect(events[0].RSSKb).To(Equal(1040))
+					Expect(events[0].Cgroup).To(Equal("/docker/abc"))
+				})
+			})
+
+			Context("when process segfaults", func() {
+				BeforeEach(func() {
+					kernelMessages = "6,2001,6000000,-;nats[555]: segfault at 0 ip 00007f8b0c1d2e3f sp 00007ffd4c3b2a10 error 4 in libc.so.6[7f8b0c000000+1bc000]\n"
+
+					jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+						{Name: "nats", Pid: 99},
+					}
+				})
+
+				It("reports crashed process and finds service by command", func() {
+					err := watch()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(events).To(HaveLen(1))
+					Expect(events[0].Type).To(Equal(boshalert.KernelEventSegfault))
+					Expect(events[0].Pid).To(Equal(555))
+					Expect(events[0].Command).To(Equal("nats"))
+					Expect(events[0].Service).To(Equal("nats"))
+				})
+			})
+
+			It("finds service by systemd cgroup unit name", func() {
+				kernelMessages = strings.Join([]string{
+					"oom-kill:constraint=CONSTRAINT_MEMCG,task_memcg=/system.slice/worker.service,task=ruby,pid=77,uid=0",
+					"Killed process 77 (ruby) total-vm:2048kB, anon-rss:1024kB, file-rss:0kB",
+				}, "\n")
+
+				jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{{Name: "worker", Pid: 70}}
+
+				err := watch()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(events).To(HaveLen(1))
+				Expect(events[0].Service).To(Equal("worker"))
+			})
+
+			It("leaves service empty when process cannot be resolved", func() {
+				kernelMessages = "Killed process 77 (ruby) total-vm:2048kB, anon-rss:1024kB, file-rss:0kB"
+				jobSupervisor.ProcessesErr = errors.New("fake-processes-err")
+
+				err := watch()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(events).To(HaveLen(1))
+				Expect(events[0].Service).To(BeEmpty())
+			})
+
+			It("ignores unrelated kernel messages", func() {
+				kernelMessages = "6,3001,7000000,-;eth0: link up\n4,3002,7000001,-;EXT4-fs (sda1): mounted filesystem\n"
+
+				err := watch()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(events).To(BeEmpty())
+			})
+
+			Context("when kernel messages were overwritten before they were read", func() {
+				var opened *int
+
+				BeforeEach(func() {
+					openReader, opened = openInSequence(&fakeKmsgReader{reads: []fakeKmsgRead{
+						{message: segfaultMessage("1")},
+						{err: &os.PathError{Op: "read", Path: "/dev/kmsg", Err: syscall.EPIPE}},
+						{message: segfaultMessage("2")},
+					}})
+				})
+
+				It("keeps reading remaining messages", func() {
+					err := watch()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(events).To(HaveLen(2))
+					Expect(events[0].Pid).To(Equal(1))
+					Expect(events[1].Pid).To(Equal(2))
+					Expect(*opened).To(Equal(1))
+				})
+			})
+
+			Context("when reading kernel messages fails", func() {
+				var opened *int
+
+				BeforeEach(func() {
+					openReader, opened = openInSequence(
+						&fakeKmsgReader{reads: []fakeKmsgRead{
+							{message: segfaultMessage("1")},
+							{err: errors.New("fake-read-err")},
+						}},
+						errors.New("fake-open-err"),
+						&fakeKmsgReader{reads: []fakeKmsgRead{
+							{message: segfaultMessage("2")},
+						}},
+					)
+				})
+
+				It("reopens kernel messages until they can be read again", func() {
+					err := watch()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(events).To(HaveLen(2))
+					Expect(events[0].Pid).To(Equal(1))
+					Expect(events[1].Pid).To(Equal(2))
+					Expect(*opened).To(Equal(3))
+				})
+			})
+
+			It("returns error when kernel messages cannot be opened", func() {
+				openErr = errors.New("fake-open-err")
+
+				err := watch()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-open-err"))
+			})
+		})
+	})
+}
@@ -0,0 +1,41 @@
+package kmsg
+
+import (
+	"io"
+	"os"
+	"syscall"
+
+	bosherr "bosh/errors"
+)
+
+const devKmsgPath = "/dev/kmsg"
+
+// NewDevKmsgOpener opens kernel log buffer skipping messages
+// logged before agent started so that they are not reported again
+func NewDevKmsgOpener() ReaderOpener {
+	return func() (io.ReadCloser, error) {
+		file, err := os.Open(devKmsgPath)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Opening %s", devKmsgPath)
+		}
+
+		_, err = file.Seek(0, os.SEEK_END)
+		if err != nil {
+			file.Close()
+			return nil, bosherr.WrapError(err, "Seeking to the end of %s", devKmsgPath)
+		}
+
+		return file, nil
+	}
+}
+
+// isOverrun checks for error returned by /dev/kmsg when messages were
+// overwritten in kernel log buffer before they were read; next read
+// continues with the oldest message still in the buffer
+func isOverrun(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+
+	return err == syscall.EPIPE
+}
@@ -0,0 +1,27 @@
+package fakes
+
+import (
+	boshalert "bosh/agent/alert"
+	boshkmsg "bosh/agent/kmsg"
+)
+
+type FakeWatcher struct {
+	// Events are passed to handler when Watch is called
+	Events   []boshalert.KernelEvent
+	WatchErr error
+}
+
+func NewFakeWatcher() *FakeWatcher {
+	return &FakeWatcher{}
+}
+
+func (w *FakeWatcher) Watch(handler boshkmsg.EventHandler) error {
+	for _, event := range w.Events {
+		err := handler(event)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.WatchErr
+}
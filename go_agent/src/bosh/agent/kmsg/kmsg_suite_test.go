@@ -0,0 +1,13 @@
+package kmsg_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestKmsg(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Kmsg Suite")
+}
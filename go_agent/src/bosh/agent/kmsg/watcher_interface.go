@@ -0,0 +1,19 @@
+package kmsg
+
+import (
+	"io"
+
+	boshalert "bosh/agent/alert"
+)
+
+type EventHandler func(event boshalert.KernelEvent) error
+
+// Watcher reports processes killed by the OOM killer or crashed with a segfault
+type Watcher interface {
+	// Watch blocks reading kernel messages until reader is exhausted;
+	// reader is reopened when reading fails
+	Watch(handler EventHandler) error
+}
+
+// ReaderOpener opens stream of kernel messages, one message per line
+type ReaderOpener func() (io.ReadCloser, error)
@@ -0,0 +1,105 @@
+package script
+
+import (
+	"time"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const (
+	// Only the end of the output is included into errors
+	// since full output is logged
+	maxOutputInError = 1024
+
+	scriptKillGracePeriod = 10 * time.Second
+)
+
+type ConcreteScript struct {
+	fs      boshsys.FileSystem
+	runner  boshsys.CmdRunner
+	path    string
+	timeout time.Duration
+	logger  boshlog.Logger
+	logTag  string
+}
+
+func NewConcreteScript(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	path string,
+	timeout time.Duration,
+	logger boshlog.Logger,
+) ConcreteScript {
+	return ConcreteScript{
+		fs:      fs,
+		runner:  runner,
+		path:    path,
+		timeout: timeout,
+		logger:  logger,
+		logTag:  "script",
+	}
+}
+
+func (s ConcreteScript) Exists() bool {
+	return s.fs.FileExists(s.path)
+}
+
+func (s ConcreteScript) Path() string {
+	return s.path
+}
+
+func (s ConcreteScript) Run() error {
+	command := boshsys.Command{
+		Name: s.path,
+		Env: map[string]string{
+			"PATH": "/usr/sbin:/usr/bin:/sbin:/bin",
+		},
+	}
+
+	process, err := s.runner.RunComplexCommandAsync(command)
+	if err != nil {
+		return bosherr.WrapError(err, "Running script %s", s.path)
+	}
+
+	var result boshsys.Result
+	var timedOut bool
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+
+	// Can only wait once on a process
+	for processExitedCh := process.Wait(); processExitedCh != nil; {
+		select {
+		case result = <-processExitedCh:
+			processExitedCh = nil
+		case <-timer.C:
+			timedOut = true
+			// Ignore possible TerminateNicely error since script is reported as failed anyway
+			process.TerminateNicely(scriptKillGracePeriod)
+		}
+	}
+
+	s.logger.Info(s.logTag, "Script %s exited with %d; stdout: %s, stderr: %s",
+		s.path, result.ExitStatus, result.Stdout, result.Stderr)
+
+	if timedOut {
+		return bosherr.New("Script %s did not finish within %s; stdout: %s, stderr: %s",
+			s.path, s.timeout, tail(result.Stdout), tail(result.Stderr))
+	}
+
+	if result.Error != nil {
+		return bosherr.WrapError(result.Error, "Script %s failed with exit code %d; stdout: %s, stderr: %s",
+			s.path, result.ExitStatus, tail(result.Stdout), tail(result.Stderr))
+	}
+
+	return nil
+}
+
+func tail(output string) string {
+	if len(output) > maxOutputInError {
+		return "..." + output[len(output)-maxOutputInError:]
+	}
+	return output
+}
@@ -0,0 +1,41 @@
+package script
+
+import (
+	"path/filepath"
+	"time"
+
+	boshlog "bosh/logger"
+	boshdirs "bosh/settings/directories"
+	boshsys "bosh/system"
+)
+
+const DefaultTimeout = 5 * time.Minute
+
+type ConcreteScriptProvider struct {
+	cmdRunner   boshsys.CmdRunner
+	fs          boshsys.FileSystem
+	dirProvider boshdirs.DirectoriesProvider
+	timeout     time.Duration
+	logger      boshlog.Logger
+}
+
+func NewConcreteScriptProvider(
+	cmdRunner boshsys.CmdRunner,
+	fs boshsys.FileSystem,
+	dirProvider boshdirs.DirectoriesProvider,
+	timeout time.Duration,
+	logger boshlog.Logger,
+) ConcreteScriptProvider {
+	return ConcreteScriptProvider{
+		cmdRunner:   cmdRunner,
+		fs:          fs,
+		dirProvider: dirProvider,
+		timeout:     timeout,
+		logger:      logger,
+	}
+}
+
+func (p ConcreteScriptProvider) NewScript(templateName, scriptName string) Script {
+	scriptPath := filepath.Join(p.dirProvider.JobsDir(), templateName, "bin", scriptName)
+	return NewConcreteScript(p.fs, p.cmdRunner, scriptPath, p.timeout, p.logger)
+}
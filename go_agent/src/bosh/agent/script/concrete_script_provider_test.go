@@ -0,0 +1,29 @@
+package script_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/script"
+	boshlog "bosh/logger"
+	boshdir "bosh/settings/directories"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("ConcreteScriptProvider", func() {
+	Describe("NewScript", func() {
+		It("returns script from job's bin directory", func() {
+			runner := fakesys.NewFakeCmdRunner()
+			fs := fakesys.NewFakeFileSystem()
+			dirProvider := boshdir.NewDirectoriesProvider("/var/vcap")
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+
+			scriptProvider := NewConcreteScriptProvider(runner, fs, dirProvider, time.Minute, logger)
+			script := scriptProvider.NewScript("foo", "pre-start")
+
+			Expect(script.Path()).To(Equal("/var/vcap/jobs/foo/bin/pre-start"))
+		})
+	})
+})
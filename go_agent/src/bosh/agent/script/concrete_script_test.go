@@ -0,0 +1,107 @@
+package script_test
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/agent/script"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("ConcreteScript", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		runner *fakesys.FakeCmdRunner
+		script ConcreteScript
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		script = NewConcreteScript(fs, runner, "/fake/pre-start", 50*time.Millisecond, logger)
+	})
+
+	Describe("Exists", func() {
+		It("returns true when script exists", func() {
+			fs.WriteFileString("/fake/pre-start", "")
+			Expect(script.Exists()).To(BeTrue())
+		})
+
+		It("returns false when script does not exist", func() {
+			Expect(script.Exists()).To(BeFalse())
+		})
+	})
+
+	Describe("Run", func() {
+		It("runs script with restricted PATH", func() {
+			runner.AddProcess("/fake/pre-start", &fakesys.FakeProcess{})
+
+			err := script.Run()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(runner.RunComplexCommands).To(Equal([]boshsys.Command{
+				{
+					Name: "/fake/pre-start",
+					Env:  map[string]string{"PATH": "/usr/sbin:/usr/bin:/sbin:/bin"},
+				},
+			}))
+		})
+
+		It("returns error with exit code and output when script fails", func() {
+			runner.AddProcess("/fake/pre-start", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{
+					Stdout:     "fake-stdout",
+					Stderr:     "fake-stderr",
+					ExitStatus: 1,
+					Error:      errors.New("fake-exit-error"),
+				},
+			})
+
+			err := script.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("exit code 1"))
+			Expect(err.Error()).To(ContainSubstring("fake-stdout"))
+			Expect(err.Error()).To(ContainSubstring("fake-stderr"))
+			Expect(err.Error()).To(ContainSubstring("fake-exit-error"))
+		})
+
+		It("includes only the end of long output in error", func() {
+			runner.AddProcess("/fake/pre-start", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{
+					Stdout:     "fake-beginning" + strings.Repeat("x", 2000) + "fake-end",
+					ExitStatus: 1,
+					Error:      errors.New("fake-exit-error"),
+				},
+			})
+
+			err := script.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-end"))
+			Expect(err.Error()).ToNot(ContainSubstring("fake-beginning"))
+		})
+
+		It("terminates script and returns error when script does not finish within timeout", func() {
+			process := &fakesys.FakeProcess{
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{Stdout: "fake-stdout", ExitStatus: -1}
+				},
+			}
+			runner.AddProcess("/fake/pre-start", process)
+
+			err := script.Run()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("did not finish within 50ms"))
+			Expect(err.Error()).To(ContainSubstring("fake-stdout"))
+
+			Expect(process.TerminatedNicely).To(BeTrue())
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+		})
+	})
+})
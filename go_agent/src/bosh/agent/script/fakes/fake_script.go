@@ -0,0 +1,26 @@
+package fakes
+
+type FakeScript struct {
+	ExistsBool bool
+	PathPath   string
+
+	DidRun   bool
+	RunError error
+}
+
+func NewFakeScript() *FakeScript {
+	return &FakeScript{}
+}
+
+func (s *FakeScript) Exists() bool {
+	return s.ExistsBool
+}
+
+func (s *FakeScript) Path() string {
+	return s.PathPath
+}
+
+func (s *FakeScript) Run() error {
+	s.DidRun = true
+	return s.RunError
+}
@@ -0,0 +1,32 @@
+package fakes
+
+import (
+	boshscript "bosh/agent/script"
+)
+
+type FakeScriptProvider struct {
+	// Keyed by template name and script name e.g. "fake-job/pre-start"
+	Scripts map[string]*FakeScript
+
+	NewScriptNames []string
+}
+
+func NewFakeScriptProvider() *FakeScriptProvider {
+	return &FakeScriptProvider{
+		Scripts: map[string]*FakeScript{},
+	}
+}
+
+func (p *FakeScriptProvider) NewScript(templateName, scriptName string) boshscript.Script {
+	name := templateName + "/" + scriptName
+	p.NewScriptNames = append(p.NewScriptNames, name)
+
+	script, found := p.Scripts[name]
+	if !found {
+		script = NewFakeScript()
+		script.PathPath = "/fake-jobs-dir/" + name
+		p.Scripts[name] = script
+	}
+
+	return script
+}
@@ -0,0 +1,10 @@
+package script
+
+type Script interface {
+	Exists() bool
+	Path() string
+
+	// Run returns error when script exits with non-zero exit status
+	// or does not finish within its timeout.
+	Run() error
+}
@@ -0,0 +1,6 @@
+package script
+
+type ScriptProvider interface {
+	// e.g. NewScript("fake-job", "pre-start") for /var/vcap/jobs/fake-job/bin/pre-start
+	NewScript(templateName, scriptName string) Script
+}
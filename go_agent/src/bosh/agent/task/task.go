@@ -6,6 +6,9 @@ type TaskCancelFunc func(task Task) error
 
 type TaskEndFunc func(task Task)
 
+// TaskProgressFunc describes how far along running task is
+type TaskProgressFunc func() interface{}
+
 type TaskState string
 
 const (
@@ -23,6 +26,9 @@ type Task struct {
 	TaskFunc    TaskFunc
 	CancelFunc  TaskCancelFunc
 	TaskEndFunc TaskEndFunc
+
+	// Optional; set before task is started
+	ProgressFunc TaskProgressFunc
 }
 
 func (t Task) Cancel() error {
@@ -32,7 +38,17 @@ func (t Task) Cancel() error {
 	return nil
 }
 
+func (t Task) Progress() interface{} {
+	if t.ProgressFunc != nil {
+		return t.ProgressFunc()
+	}
+	return nil
+}
+
 type TaskStateValue struct {
 	AgentTaskID string    `json:"agent_task_id"`
 	State       TaskState `json:"state"`
+
+	// Only included for running tasks that report progress
+	Progress interface{} `json:"progress,omitempty"`
 }
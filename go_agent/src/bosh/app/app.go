@@ -14,6 +14,8 @@ import (
 	boshpa "bosh/agent/applier/packageapplier"
 	boshcomp "bosh/agent/compiler"
 	boshdrain "bosh/agent/drain"
+	boshkmsg "bosh/agent/kmsg"
+	boshscript "bosh/agent/script"
 	boshtask "bosh/agent/task"
 	boshblob "bosh/blobstore"
 	boshboot "bosh/bootstrap"
@@ -23,17 +25,23 @@ import (
 	boshmonit "bosh/jobsupervisor/monit"
 	boshlog "bosh/logger"
 	boshmbus "bosh/mbus"
+	boshmetrics "bosh/metrics"
 	boshnotif "bosh/notification"
 	boshplatform "bosh/platform"
+	boshvitals "bosh/platform/vitals"
 	boshsettings "bosh/settings"
 	boshdirs "bosh/settings/directories"
 	boshsys "bosh/system"
 	boshuuid "bosh/uuid"
 )
 
+const appLogTag = "App"
+
 type app struct {
 	logger         boshlog.Logger
 	agent          boshagent.Agent
+	metricsServer  boshmetrics.Server
+	vitalsHistory  boshvitals.History
 	platform       boshplatform.Platform
 	infrastructure boshinf.Infrastructure
 }
@@ -86,7 +94,9 @@ func (app *app) Setup(args []string) error {
 		return bosherr.WrapError(err, "Running bootstrap")
 	}
 
-	mbusHandlerProvider := boshmbus.NewHandlerProvider(settingsService, app.logger)
+	metricsRegistry := boshmetrics.NewRegistry()
+
+	mbusHandlerProvider := boshmbus.NewHandlerProvider(settingsService, app.logger, metricsRegistry)
 
 	mbusHandler, err := mbusHandlerProvider.Get(app.platform, dirProvider)
 	if err != nil {
@@ -100,6 +110,17 @@ func (app *app) Setup(args []string) error {
 		return bosherr.WrapError(err, "Getting blobstore")
 	}
 
+	// Meter blobstore before caching so that only actual transfers are counted
+	blobstore = boshblob.NewMetered(blobstore, app.platform.GetFs(), metricsRegistry, app.logger)
+
+	blobCache := boshblob.NewSha1Cache(
+		blobstore,
+		filepath.Join(dirProvider.DataDir(), "blob_cache"),
+		config.BlobCache,
+		app.platform.GetFs(),
+		app.logger,
+	)
+
 	monitClientProvider := boshmonit.NewProvider(app.platform, app.logger)
 
 	monitClient, err := monitClientProvider.Get()
@@ -120,9 +141,26 @@ func (app *app) Setup(args []string) error {
 		return bosherr.WrapError(err, "Getting job supervisor")
 	}
 
+	jobSupervisor = boshjobsuper.NewHealthCheckingJobSupervisor(
+		jobSupervisor,
+		app.platform.GetFs(),
+		app.platform.GetRunner(),
+		app.logger,
+		dirProvider.JobsDir(),
+		boshjobsuper.DefaultHealthProbeInterval,
+	)
+
 	notifier := boshnotif.NewNotifier(mbusHandler)
 
-	applier, compiler := app.buildApplierAndCompiler(dirProvider, blobstore, jobSupervisor)
+	scriptProvider := boshscript.NewConcreteScriptProvider(
+		app.platform.GetRunner(),
+		app.platform.GetFs(),
+		dirProvider,
+		boshscript.DefaultTimeout,
+		app.logger,
+	)
+
+	applier, planner, verifier, compiler := app.buildApplierAndCompiler(dirProvider, blobCache, jobSupervisor, scriptProvider, config.Applier, config.Compiler)
 
 	uuidGen := boshuuid.NewGenerator()
 
@@ -135,7 +173,21 @@ func (app *app) Setup(args []string) error {
 	)
 
 	specFilePath := filepath.Join(dirProvider.BoshDir(), "spec.json")
-	specService := boshas.NewConcreteV1Service(app.platform.GetFs(), specFilePath)
+	specService := boshas.NewConcreteV2Service(app.platform.GetFs(), specFilePath)
+
+	app.vitalsHistory = boshvitals.NewHistory(
+		config.VitalsHistory,
+		app.platform.GetFs(),
+		filepath.Join(dirProvider.BoshDir(), "vitals_history.json"),
+		app.logger,
+	)
+
+	// Loaded before vitals sampler starts adding new samples;
+	// agent keeps running without previously saved history
+	err = app.vitalsHistory.Load()
+	if err != nil {
+		app.logger.Error(appLogTag, "Loading vitals history: %s", err.Error())
+	}
 
 	drainScriptProvider := boshdrain.NewConcreteDrainScriptProvider(
 		app.platform.GetRunner(),
@@ -147,14 +199,20 @@ func (app *app) Setup(args []string) error {
 		settingsService,
 		app.platform,
 		app.infrastructure,
-		blobstore,
+		blobCache,
 		taskService,
 		notifier,
 		applier,
+		planner,
+		verifier,
 		compiler,
 		jobSupervisor,
 		specService,
 		drainScriptProvider,
+		config.Drain,
+		config.Start,
+		scriptProvider,
+		app.vitalsHistory,
 		app.logger,
 	)
 
@@ -166,25 +224,54 @@ func (app *app) Setup(args []string) error {
 		taskManager,
 		actionFactory,
 		actionRunner,
+		metricsRegistry,
 	)
 
 	alertBuilder := boshalert.NewBuilder(settingsService, app.logger)
 
+	alertPipeline := boshalert.NewPipeline(config.Alerts, app.logger)
+
+	thresholdChecker := boshalert.NewThresholdChecker(config.ResourceAlerts, app.logger)
+
+	vitalsSampler := boshvitals.NewSampler(
+		app.platform.GetVitalsService(),
+		app.vitalsHistory,
+		config.VitalsHistory.Interval(),
+		app.logger,
+	)
+
+	kernelWatcher := boshkmsg.NewWatcher(boshkmsg.NewDevKmsgOpener(), jobSupervisor, boshkmsg.DefaultReopenDelay, app.logger)
+
 	app.agent = boshagent.New(
 		app.logger,
 		mbusHandler,
 		app.platform,
 		actionDispatcher,
 		alertBuilder,
+		alertPipeline,
+		thresholdChecker,
+		vitalsSampler,
+		kernelWatcher,
 		jobSupervisor,
 		specService,
 		time.Minute,
 	)
 
+	app.metricsServer = boshmetrics.NewServer(
+		config.Metrics,
+		metricsRegistry,
+		app.platform.GetVitalsService(),
+		jobSupervisor,
+		app.logger,
+	)
+
 	return nil
 }
 
 func (app *app) Run() error {
+	go app.runMetricsServer()
+	go app.runVitalsHistory()
+
 	err := app.agent.Run()
 	if err != nil {
 		return bosherr.WrapError(err, "Running agent")
@@ -192,6 +279,26 @@ func (app *app) Run() error {
 	return nil
 }
 
+func (app *app) runMetricsServer() {
+	defer app.logger.HandlePanic("Metrics Server")
+
+	// Agent keeps running without metrics endpoint
+	err := app.metricsServer.Run()
+	if err != nil {
+		app.logger.Error(appLogTag, "Running metrics server: %s", err.Error())
+	}
+}
+
+func (app *app) runVitalsHistory() {
+	defer app.logger.HandlePanic("Vitals History")
+
+	// Agent keeps running without vitals history
+	err := app.vitalsHistory.Run()
+	if err != nil {
+		app.logger.Error(appLogTag, "Running vitals history: %s", err.Error())
+	}
+}
+
 func (app *app) GetPlatform() boshplatform.Platform {
 	return app.platform
 }
@@ -202,9 +309,12 @@ func (app *app) GetInfrastructure() boshinf.Infrastructure {
 
 func (app *app) buildApplierAndCompiler(
 	dirProvider boshdirs.DirectoriesProvider,
-	blobstore boshblob.Blobstore,
+	blobstore boshblob.Cache,
 	jobSupervisor boshjobsuper.JobSupervisor,
-) (boshapplier.Applier, boshcomp.Compiler) {
+	scriptProvider boshscript.ScriptProvider,
+	applierOptions boshapplier.Options,
+	compilerOptions boshcomp.Options,
+) (boshapplier.Applier, boshapplier.Planner, boshapplier.Verifier, boshcomp.Compiler) {
 	jobsBc := boshbc.NewFileBundleCollection(
 		dirProvider.DataDir(),
 		dirProvider.BaseDir(),
@@ -234,12 +344,30 @@ func (app *app) buildApplierAndCompiler(
 		app.logger,
 	)
 
+	// Applier and verifier share package applier so that
+	// they do not install same package at the same time
+	rootPackageApplier := packageApplierProvider.Root()
+
 	applier := boshapplier.NewConcreteApplier(
 		jobApplier,
-		packageApplierProvider.Root(),
+		rootPackageApplier,
 		app.platform,
 		jobSupervisor,
+		scriptProvider,
 		dirProvider,
+		app.platform.GetFs(),
+		applierOptions,
+		app.logger,
+	)
+
+	planner := boshapplier.NewConcretePlanner(jobsBc, packageApplierProvider.RootBundleCollection(), blobstore)
+
+	verifier := boshapplier.NewConcreteVerifier(
+		jobsBc,
+		packageApplierProvider.RootBundleCollection(),
+		jobApplier,
+		rootPackageApplier,
+		app.logger,
 	)
 
 	compiler := boshcomp.NewConcreteCompiler(
@@ -250,9 +378,11 @@ func (app *app) buildApplierAndCompiler(
 		dirProvider,
 		packageApplierProvider.Root(),
 		packageApplierProvider.RootBundleCollection(),
+		compilerOptions,
+		app.logger,
 	)
 
-	return applier, compiler
+	return applier, planner, verifier, compiler
 }
 
 func (app *app) loadConfig(path string) (Config, error) {
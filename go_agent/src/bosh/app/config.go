@@ -3,13 +3,30 @@ package app
 import (
 	"encoding/json"
 
+	boshaction "bosh/agent/action"
+	boshalert "bosh/agent/alert"
+	boshapplier "bosh/agent/applier"
+	boshcomp "bosh/agent/compiler"
+	boshdrain "bosh/agent/drain"
+	boshblob "bosh/blobstore"
 	bosherr "bosh/errors"
+	boshmetrics "bosh/metrics"
 	boshplatform "bosh/platform"
+	boshvitals "bosh/platform/vitals"
 	boshsys "bosh/system"
 )
 
 type Config struct {
-	Platform boshplatform.ProviderOptions
+	Platform       boshplatform.ProviderOptions
+	Applier        boshapplier.Options
+	BlobCache      boshblob.CacheOptions
+	Compiler       boshcomp.Options
+	Drain          boshdrain.Options
+	Start          boshaction.StartOptions
+	Alerts         boshalert.PipelineOptions
+	ResourceAlerts boshalert.ThresholdOptions
+	Metrics        boshmetrics.Options
+	VitalsHistory  boshvitals.HistoryOptions
 }
 
 func LoadConfigFromPath(fs boshsys.FileSystem, path string) (Config, error) {
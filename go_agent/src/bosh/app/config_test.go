@@ -6,7 +6,15 @@ import (
 
 	. "bosh/app"
 
+	boshaction "bosh/agent/action"
+	boshalert "bosh/agent/alert"
+	boshapplier "bosh/agent/applier"
+	boshcomp "bosh/agent/compiler"
+	boshdrain "bosh/agent/drain"
+	boshblob "bosh/blobstore"
+	boshmetrics "bosh/metrics"
 	boshplatform "bosh/platform"
+	boshvitals "bosh/platform/vitals"
 	fakesys "bosh/system/fakes"
 )
 
@@ -25,6 +33,43 @@ var _ = Describe("LoadConfigFromPath", func() {
 				"Linux": {
 					"UseDefaultTmpDir": true
 				}
+			},
+			"Applier": {
+				"MaxParallelDownloads": 10
+			},
+			"BlobCache": {
+				"MaxSize": 1024
+			},
+			"Compiler": {
+				"User": "fake-compile-user",
+				"MemoryLimit": 2048,
+				"TimeoutSeconds": 60
+			},
+			"Drain": {
+				"TimeoutSeconds": 600
+			},
+			"Start": {
+				"TimeoutSeconds": 120
+			},
+			"Alerts": {
+				"DedupeWindowSeconds": 30,
+				"RateLimit": 5,
+				"RateLimitWindowSeconds": 300,
+				"FlapThreshold": 3,
+				"FlapWindowSeconds": 120
+			},
+			"ResourceAlerts": {
+				"Thresholds": [
+					{"Metric": "disk.persistent.percent", "Warning": 80, "Critical": 90},
+					{"Metric": "swap.percent", "Warning": 50, "Hysteresis": 10}
+				]
+			},
+			"Metrics": {
+				"ListenAddress": "0.0.0.0:9100"
+			},
+			"VitalsHistory": {
+				"IntervalSeconds": 30,
+				"Persist": true
 			}
 		}`)
 
@@ -37,6 +82,43 @@ var _ = Describe("LoadConfigFromPath", func() {
 						UseDefaultTmpDir: true,
 					},
 				},
+				Applier: boshapplier.Options{
+					MaxParallelDownloads: 10,
+				},
+				BlobCache: boshblob.CacheOptions{
+					MaxSize: 1024,
+				},
+				Compiler: boshcomp.Options{
+					User:           "fake-compile-user",
+					MemoryLimit:    2048,
+					TimeoutSeconds: 60,
+				},
+				Drain: boshdrain.Options{
+					TimeoutSeconds: 600,
+				},
+				Start: boshaction.StartOptions{
+					TimeoutSeconds: 120,
+				},
+				Alerts: boshalert.PipelineOptions{
+					DedupeWindowSeconds:    30,
+					RateLimit:              5,
+					RateLimitWindowSeconds: 300,
+					FlapThreshold:          3,
+					FlapWindowSeconds:      120,
+				},
+				ResourceAlerts: boshalert.ThresholdOptions{
+					Thresholds: []boshalert.Threshold{
+						{Metric: "disk.persistent.percent", Warning: 80, Critical: 90},
+						{Metric: "swap.percent", Warning: 50, Hysteresis: 10},
+					},
+				},
+				Metrics: boshmetrics.Options{
+					ListenAddress: "0.0.0.0:9100",
+				},
+				VitalsHistory: boshvitals.HistoryOptions{
+					IntervalSeconds: 30,
+					Persist:         true,
+				},
 			},
 		))
 
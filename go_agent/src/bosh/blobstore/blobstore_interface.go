@@ -13,3 +13,19 @@ type Blobstore interface {
 
 	Validate() (err error)
 }
+
+// Sizer is implemented by blobstores that can tell
+// size of a blob without downloading it
+type Sizer interface {
+	Size(blobID string) (size int64, found bool)
+}
+
+// blobSize returns size of a blob when wrapped blobstore is able to tell it
+func blobSize(blobstore Blobstore, blobID string) (int64, bool) {
+	sizer, ok := blobstore.(Sizer)
+	if !ok {
+		return 0, false
+	}
+
+	return sizer.Size(blobID)
+}
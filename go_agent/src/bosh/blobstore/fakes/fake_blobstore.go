@@ -10,6 +10,7 @@ type FakeBlobstore struct {
 	CleanUpErr      error
 
 	CreateFileName    string
+	CreateFileNames   []string
 	CreateBlobID      string
 	CreateFingerprint string
 	CreateErr         error
@@ -34,6 +35,7 @@ func (bs *FakeBlobstore) CleanUp(fileName string) error {
 
 func (bs *FakeBlobstore) Create(fileName string) (string, string, error) {
 	bs.CreateFileName = fileName
+	bs.CreateFileNames = append(bs.CreateFileNames, fileName)
 	return bs.CreateBlobID, bs.CreateFingerprint, bs.CreateErr
 }
 
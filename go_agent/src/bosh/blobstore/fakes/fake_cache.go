@@ -0,0 +1,36 @@
+package fakes
+
+import (
+	boshblob "bosh/blobstore"
+)
+
+type FakeCache struct {
+	*FakeBlobstore
+
+	ContainsFingerprints map[string]bool
+
+	SizeSizes map[string]int64
+
+	StatsStats boshblob.CacheStats
+}
+
+func NewFakeCache() *FakeCache {
+	return &FakeCache{
+		FakeBlobstore:        NewFakeBlobstore(),
+		ContainsFingerprints: map[string]bool{},
+		SizeSizes:            map[string]int64{},
+	}
+}
+
+func (c *FakeCache) Contains(fingerprint string) bool {
+	return c.ContainsFingerprints[fingerprint]
+}
+
+func (c *FakeCache) Size(blobID string) (int64, bool) {
+	size, found := c.SizeSizes[blobID]
+	return size, found
+}
+
+func (c *FakeCache) Stats() boshblob.CacheStats {
+	return c.StatsStats
+}
@@ -70,6 +70,15 @@ func (blobstore local) Create(fileName string) (blobID string, fingerprint strin
 	return
 }
 
+func (blobstore local) Size(blobID string) (int64, bool) {
+	info, err := blobstore.fs.Stat(filepath.Join(blobstore.path(), blobID))
+	if err != nil {
+		return 0, false
+	}
+
+	return info.Size(), true
+}
+
 func (blobstore local) path() (path string) {
 	return blobstore.options["blobstore_path"]
 }
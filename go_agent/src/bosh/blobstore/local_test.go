@@ -91,6 +91,19 @@ func init() {
 			assert.Empty(GinkgoT(), fileName)
 			Expect(fs.FileExists(tempFile.Name())).To(BeFalse())
 		})
+		It("local size", func() {
+			fs, _, blobstore := buildLocalBlobstore()
+
+			fs.WriteFileString(fakeBlobstorePath+"/fake-blob-id", "fake contents")
+
+			size, found := blobstore.Size("fake-blob-id")
+			Expect(found).To(BeTrue())
+			Expect(size).To(Equal(int64(len("fake contents"))))
+
+			_, found = blobstore.Size("fake-missing-blob-id")
+			Expect(found).To(BeFalse())
+		})
+
 		It("local clean up", func() {
 
 			fs, _, blobstore := buildLocalBlobstore()
@@ -0,0 +1,77 @@
+package blobstore
+
+import (
+	boshlog "bosh/logger"
+	boshmetrics "bosh/metrics"
+	boshsys "bosh/system"
+)
+
+const meteredLogTag = "meteredBlobstore"
+
+// metered records sizes of blobs transferred by inner blobstore;
+// it should wrap blobstore below any caching
+type metered struct {
+	blobstore Blobstore
+	fs        boshsys.FileSystem
+	metrics   boshmetrics.Registry
+	logger    boshlog.Logger
+}
+
+func NewMetered(
+	blobstore Blobstore,
+	fs boshsys.FileSystem,
+	metrics boshmetrics.Registry,
+	logger boshlog.Logger,
+) Blobstore {
+	return metered{
+		blobstore: blobstore,
+		fs:        fs,
+		metrics:   metrics,
+		logger:    logger,
+	}
+}
+
+func (b metered) Get(blobID, fingerprint string) (string, error) {
+	fileName, err := b.blobstore.Get(blobID, fingerprint)
+	if err != nil {
+		return "", err
+	}
+
+	b.recordBytes(boshmetrics.BlobstoreDownload, fileName)
+
+	return fileName, nil
+}
+
+func (b metered) CleanUp(fileName string) error {
+	return b.blobstore.CleanUp(fileName)
+}
+
+func (b metered) Create(fileName string) (string, string, error) {
+	blobID, fingerprint, err := b.blobstore.Create(fileName)
+	if err != nil {
+		return "", "", err
+	}
+
+	b.recordBytes(boshmetrics.BlobstoreUpload, fileName)
+
+	return blobID, fingerprint, nil
+}
+
+func (b metered) Size(blobID string) (int64, bool) {
+	return blobSize(b.blobstore, blobID)
+}
+
+func (b metered) Validate() error {
+	return b.blobstore.Validate()
+}
+
+func (b metered) recordBytes(direction, fileName string) {
+	info, err := b.fs.Stat(fileName)
+	if err != nil {
+		// Failing to measure blob should not fail transfer itself
+		b.logger.Error(meteredLogTag, "Failed to stat blob %s: %s", fileName, err.Error())
+		return
+	}
+
+	b.metrics.AddBlobstoreBytes(direction, info.Size())
+}
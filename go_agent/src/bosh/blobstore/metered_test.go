@@ -0,0 +1,93 @@
+package blobstore_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshblob "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshlog "bosh/logger"
+	boshmetrics "bosh/metrics"
+	fakemetrics "bosh/metrics/fakes"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("metered", func() {
+	var (
+		innerBlobstore *fakeblob.FakeBlobstore
+		fs             *fakesys.FakeFileSystem
+		metrics        *fakemetrics.FakeRegistry
+		blobstore      boshblob.Blobstore
+	)
+
+	BeforeEach(func() {
+		innerBlobstore = fakeblob.NewFakeBlobstore()
+		fs = fakesys.NewFakeFileSystem()
+		metrics = fakemetrics.NewFakeRegistry()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		blobstore = boshblob.NewMetered(innerBlobstore, fs, metrics, logger)
+	})
+
+	Describe("Get", func() {
+		It("records size of downloaded blob", func() {
+			innerBlobstore.GetFileName = "/fake-downloaded-blob"
+			fs.WriteFileString("/fake-downloaded-blob", "fake-content")
+
+			fileName, err := blobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal("/fake-downloaded-blob"))
+
+			Expect(innerBlobstore.GetBlobIDs).To(Equal([]string{"fake-blob-id"}))
+			Expect(metrics.BlobstoreBytes[boshmetrics.BlobstoreDownload]).To(Equal(int64(12)))
+		})
+
+		It("returns error and does not record anything if inner blobstore fails", func() {
+			innerBlobstore.GetError = errors.New("fake-get-err")
+
+			_, err := blobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-err"))
+
+			Expect(metrics.BlobstoreBytes).To(BeEmpty())
+		})
+
+		It("returns downloaded blob even if its size cannot be determined", func() {
+			innerBlobstore.GetFileName = "/fake-downloaded-blob"
+			fs.StatErr = errors.New("fake-stat-err")
+
+			fileName, err := blobstore.Get("fake-blob-id", "fake-fingerprint")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal("/fake-downloaded-blob"))
+
+			Expect(metrics.BlobstoreBytes).To(BeEmpty())
+		})
+	})
+
+	Describe("Create", func() {
+		It("records size of uploaded blob", func() {
+			innerBlobstore.CreateBlobID = "fake-blob-id"
+			innerBlobstore.CreateFingerprint = "fake-fingerprint"
+			fs.WriteFileString("/fake-upload", "fake-upload-content")
+
+			blobID, fingerprint, err := blobstore.Create("/fake-upload")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+			Expect(fingerprint).To(Equal("fake-fingerprint"))
+
+			Expect(metrics.BlobstoreBytes[boshmetrics.BlobstoreUpload]).To(Equal(int64(19)))
+		})
+
+		It("returns error and does not record anything if inner blobstore fails", func() {
+			innerBlobstore.CreateErr = errors.New("fake-create-err")
+			fs.WriteFileString("/fake-upload", "fake-upload-content")
+
+			_, _, err := blobstore.Create("/fake-upload")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-create-err"))
+
+			Expect(metrics.BlobstoreBytes).To(BeEmpty())
+		})
+	})
+})
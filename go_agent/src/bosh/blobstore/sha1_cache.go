@@ -0,0 +1,435 @@
+package blobstore
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const DefaultCacheMaxSize = int64(1024 * 1024 * 1024)
+
+type CacheOptions struct {
+	// Disabled turns off caching of downloaded blobs
+	Disabled bool
+
+	// MaxSize is the maximum total size of cached blobs in bytes;
+	// defaults to DefaultCacheMaxSize
+	MaxSize int64
+}
+
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Size    int64  `json:"size"`
+	MaxSize int64  `json:"max_size"`
+}
+
+type Cache interface {
+	Blobstore
+	Sizer
+
+	// Contains checks if blob with given fingerprint would be
+	// returned from the cache without downloading it
+	Contains(fingerprint string) bool
+
+	Stats() CacheStats
+}
+
+type sha1CacheEntry struct {
+	sha1 string
+	size int64
+}
+
+// sha1Cache keeps copies of downloaded blobs keyed by their SHA1
+// so that blobs are not downloaded again when bundles are reinstalled
+// or when compilation needs the same dependencies.
+// Least recently used blobs are evicted once cache exceeds its max size.
+type sha1Cache struct {
+	blobstore Blobstore
+	cacheDir  string
+	options   CacheOptions
+	fs        boshsys.FileSystem
+	logger    boshlog.Logger
+	logTag    string
+
+	lock    sync.Mutex
+	loaded  bool
+	size    int64
+	hits    uint64
+	misses  uint64
+	entries map[string]*list.Element
+
+	// Front is most recently used
+	lru *list.List
+
+	// Blobs that are being copied into the cache
+	adding map[string]bool
+
+	// Files returned by Get that were copied out of the cache
+	tempFiles map[string]bool
+}
+
+func NewSha1Cache(
+	blobstore Blobstore,
+	cacheDir string,
+	options CacheOptions,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) Cache {
+	if options.MaxSize <= 0 {
+		options.MaxSize = DefaultCacheMaxSize
+	}
+
+	return &sha1Cache{
+		blobstore: blobstore,
+		cacheDir:  cacheDir,
+		options:   options,
+		fs:        fs,
+		logger:    logger,
+		logTag:    "sha1Cache",
+		entries:   map[string]*list.Element{},
+		lru:       list.New(),
+		adding:    map[string]bool{},
+		tempFiles: map[string]bool{},
+	}
+}
+
+func (c *sha1Cache) Get(blobID, fingerprint string) (string, error) {
+	if c.options.Disabled || fingerprint == "" {
+		return c.blobstore.Get(blobID, fingerprint)
+	}
+
+	err := c.load()
+	if err != nil {
+		c.logger.Error(c.logTag, "Skipping blob cache: %s", err.Error())
+		return c.blobstore.Get(blobID, fingerprint)
+	}
+
+	cachedPath, found := c.lookup(fingerprint)
+	if found {
+		fileName, err := c.copyFromCache(cachedPath, fingerprint)
+		if err == nil {
+			c.recordHit()
+			return fileName, nil
+		}
+
+		c.logger.Error(c.logTag, "Discarding cached blob %s: %s", fingerprint, err.Error())
+		c.remove(fingerprint)
+	}
+
+	c.recordMiss()
+
+	fileName, err := c.blobstore.Get(blobID, fingerprint)
+	if err != nil {
+		return "", err
+	}
+
+	err = c.add(fileName, fingerprint)
+	if err != nil {
+		c.logger.Error(c.logTag, "Failed to cache blob %s: %s", fingerprint, err.Error())
+	}
+
+	return fileName, nil
+}
+
+func (c *sha1Cache) CleanUp(fileName string) error {
+	c.lock.Lock()
+	_, copiedFromCache := c.tempFiles[fileName]
+	delete(c.tempFiles, fileName)
+	c.lock.Unlock()
+
+	if copiedFromCache {
+		return c.fs.RemoveAll(fileName)
+	}
+
+	return c.blobstore.CleanUp(fileName)
+}
+
+func (c *sha1Cache) Create(fileName string) (string, string, error) {
+	return c.blobstore.Create(fileName)
+}
+
+func (c *sha1Cache) Validate() error {
+	return c.blobstore.Validate()
+}
+
+func (c *sha1Cache) Contains(fingerprint string) bool {
+	if c.options.Disabled || fingerprint == "" {
+		return false
+	}
+
+	err := c.load()
+	if err != nil {
+		return false
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, found := c.entries[fingerprint]
+
+	return found
+}
+
+func (c *sha1Cache) Size(blobID string) (int64, bool) {
+	return blobSize(c.blobstore, blobID)
+}
+
+func (c *sha1Cache) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return CacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Size:    c.size,
+		MaxSize: c.options.MaxSize,
+	}
+}
+
+// load builds in-memory index from blobs cached by a previous agent run
+// using modification times to approximate previous usage order.
+func (c *sha1Cache) load() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.loaded {
+		return nil
+	}
+
+	err := c.fs.MkdirAll(c.cacheDir, os.FileMode(0700))
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blob cache dir")
+	}
+
+	paths, err := c.fs.Glob(filepath.Join(c.cacheDir, "*"))
+	if err != nil {
+		return bosherr.WrapError(err, "Globbing blob cache dir")
+	}
+
+	var infos []os.FileInfo
+
+	for _, path := range paths {
+		// Left over from interrupted copy into the cache
+		if strings.HasSuffix(path, ".tmp") {
+			c.fs.RemoveAll(path)
+			continue
+		}
+
+		info, err := c.fs.Stat(path)
+		if err != nil {
+			return bosherr.WrapError(err, "Stating cached blob %s", path)
+		}
+
+		infos = append(infos, info)
+	}
+
+	sort.Sort(byModTime(infos))
+
+	for _, info := range infos {
+		entry := &sha1CacheEntry{sha1: info.Name(), size: info.Size()}
+		c.entries[entry.sha1] = c.lru.PushFront(entry)
+		c.size += entry.size
+	}
+
+	c.evict()
+
+	c.loaded = true
+
+	return nil
+}
+
+func (c *sha1Cache) lookup(sha1 string) (string, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, found := c.entries[sha1]
+	if !found {
+		return "", false
+	}
+
+	c.lru.MoveToFront(element)
+
+	return c.path(sha1), true
+}
+
+// copyFromCache returns a copy of the cached blob since
+// callers are allowed to remove or modify returned file.
+func (c *sha1Cache) copyFromCache(cachedPath, sha1 string) (string, error) {
+	file, err := c.fs.TempFile("bosh-blobstore-cache-Get")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file")
+	}
+
+	fileName := file.Name()
+	file.Close()
+
+	err = c.fs.CopyFile(cachedPath, fileName)
+	if err != nil {
+		c.fs.RemoveAll(fileName)
+		return "", bosherr.WrapError(err, "Copying cached blob")
+	}
+
+	actualSha1, err := c.calculateSha1(fileName)
+	if err != nil {
+		c.fs.RemoveAll(fileName)
+		return "", bosherr.WrapError(err, "Calculating sha1 of cached blob")
+	}
+
+	if actualSha1 != sha1 {
+		c.fs.RemoveAll(fileName)
+		return "", bosherr.New("SHA1 mismatch. Expected %s, got %s", sha1, actualSha1)
+	}
+
+	c.lock.Lock()
+	c.tempFiles[fileName] = true
+	c.lock.Unlock()
+
+	// Modification time keeps usage order for the next agent run
+	now := time.Now()
+
+	err = c.fs.Chtimes(cachedPath, now, now)
+	if err != nil {
+		c.logger.Error(c.logTag, "Failed to update modification time of cached blob %s: %s", sha1, err.Error())
+	}
+
+	return fileName, nil
+}
+
+func (c *sha1Cache) calculateSha1(fileName string) (string, error) {
+	file, err := c.fs.OpenFile(fileName)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Opening file")
+	}
+
+	defer file.Close()
+
+	h := sha1.New()
+
+	_, err = io.Copy(h, file)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Reading file")
+	}
+
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func (c *sha1Cache) add(fileName, sha1 string) error {
+	info, err := c.fs.Stat(fileName)
+	if err != nil {
+		return bosherr.WrapError(err, "Stating blob")
+	}
+
+	if info.Size() > c.options.MaxSize {
+		return nil
+	}
+
+	c.lock.Lock()
+	_, found := c.entries[sha1]
+	if found || c.adding[sha1] {
+		c.lock.Unlock()
+		return nil
+	}
+	c.adding[sha1] = true
+	c.lock.Unlock()
+
+	defer func() {
+		c.lock.Lock()
+		delete(c.adding, sha1)
+		c.lock.Unlock()
+	}()
+
+	tmpPath := c.path(sha1) + ".tmp"
+
+	err = c.fs.CopyFile(fileName, tmpPath)
+	if err != nil {
+		c.fs.RemoveAll(tmpPath)
+		return bosherr.WrapError(err, "Copying blob into cache")
+	}
+
+	err = c.fs.Rename(tmpPath, c.path(sha1))
+	if err != nil {
+		c.fs.RemoveAll(tmpPath)
+		return bosherr.WrapError(err, "Renaming cached blob")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	entry := &sha1CacheEntry{sha1: sha1, size: info.Size()}
+	c.entries[sha1] = c.lru.PushFront(entry)
+	c.size += entry.size
+
+	c.evict()
+
+	return nil
+}
+
+func (c *sha1Cache) remove(sha1 string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, found := c.entries[sha1]
+	if found {
+		c.removeElement(element)
+	}
+}
+
+// evict must be called with lock held
+func (c *sha1Cache) evict() {
+	for c.size > c.options.MaxSize {
+		element := c.lru.Back()
+		if element == nil {
+			return
+		}
+
+		c.removeElement(element)
+	}
+}
+
+// removeElement must be called with lock held
+func (c *sha1Cache) removeElement(element *list.Element) {
+	entry := element.Value.(*sha1CacheEntry)
+
+	err := c.fs.RemoveAll(c.path(entry.sha1))
+	if err != nil {
+		c.logger.Error(c.logTag, "Failed to remove cached blob %s: %s", entry.sha1, err.Error())
+	}
+
+	c.lru.Remove(element)
+	delete(c.entries, entry.sha1)
+	c.size -= entry.size
+}
+
+func (c *sha1Cache) recordHit() {
+	c.lock.Lock()
+	c.hits++
+	c.lock.Unlock()
+}
+
+func (c *sha1Cache) recordMiss() {
+	c.lock.Lock()
+	c.misses++
+	c.lock.Unlock()
+}
+
+func (c *sha1Cache) path(sha1 string) string {
+	return filepath.Join(c.cacheDir, sha1)
+}
+
+type byModTime []os.FileInfo
+
+func (s byModTime) Len() int           { return len(s) }
+func (s byModTime) Less(i, j int) bool { return s[i].ModTime().Before(s[j].ModTime()) }
+func (s byModTime) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
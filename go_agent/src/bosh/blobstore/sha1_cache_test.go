@@ -0,0 +1,296 @@
+package blobstore_test
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/blobstore"
+	fakeblob "bosh/blobstore/fakes"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+var _ = Describe("sha1Cache", func() {
+	var (
+		tmpDir         string
+		cacheDir       string
+		innerBlobstore *fakeblob.FakeBlobstore
+		fs             boshsys.FileSystem
+		cache          Cache
+	)
+
+	writeBlob := func(name, contents string) (string, string) {
+		path := filepath.Join(tmpDir, name)
+		err := ioutil.WriteFile(path, []byte(contents), os.FileMode(0644))
+		Expect(err).ToNot(HaveOccurred())
+		return path, fmt.Sprintf("%x", sha1.Sum([]byte(contents)))
+	}
+
+	buildCache := func(options CacheOptions) {
+		cache = NewSha1Cache(innerBlobstore, cacheDir, options, fs, boshlog.NewLogger(boshlog.LevelNone))
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "sha1-cache-test")
+		Expect(err).ToNot(HaveOccurred())
+
+		cacheDir = filepath.Join(tmpDir, "blob_cache")
+		innerBlobstore = fakeblob.NewFakeBlobstore()
+		fs = boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone))
+
+		buildCache(CacheOptions{MaxSize: 10})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	Describe("Get", func() {
+		It("downloads blob from inner blobstore on first get and records a miss", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			fileName, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal(path))
+			Expect(innerBlobstore.GetBlobIDs).To(Equal([]string{"fake-blob-id"}))
+
+			Expect(cache.Stats()).To(Equal(CacheStats{Hits: 0, Misses: 1, Size: 9, MaxSize: 10}))
+		})
+
+		It("returns copy of cached blob on subsequent gets and records a hit", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).ToNot(Equal(path))
+			defer os.RemoveAll(fileName)
+
+			contents, err := ioutil.ReadFile(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("fake-blob"))
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(1))
+			Expect(cache.Stats().Hits).To(Equal(uint64(1)))
+		})
+
+		It("uses blobs cached by a previous cache instance", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			buildCache(CacheOptions{MaxSize: 10})
+
+			fileName, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(fileName)
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(1))
+			Expect(cache.Stats()).To(Equal(CacheStats{Hits: 1, Misses: 0, Size: 9, MaxSize: 10}))
+		})
+
+		It("downloads blob again when cached blob does not match its sha1", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = ioutil.WriteFile(filepath.Join(cacheDir, sha1), []byte("corrupted"), os.FileMode(0644))
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fileName).To(Equal(path))
+
+			Expect(innerBlobstore.GetBlobIDs).To(HaveLen(2))
+			Expect(cache.Stats().Misses).To(Equal(uint64(2)))
+
+			contents, err := ioutil.ReadFile(filepath.Join(cacheDir, sha1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("fake-blob"))
+		})
+
+		It("evicts least recently used blobs when cache exceeds max size", func() {
+			path1, sha1a := writeBlob("blob1", "blob-1")
+			path2, sha1b := writeBlob("blob2", "blob-2")
+
+			innerBlobstore.GetFileName = path1
+			_, err := cache.Get("fake-blob-id-1", sha1a)
+			Expect(err).ToNot(HaveOccurred())
+
+			innerBlobstore.GetFileName = path2
+			_, err = cache.Get("fake-blob-id-2", sha1b)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(filepath.Join(cacheDir, sha1a))).To(BeFalse())
+			Expect(fs.FileExists(filepath.Join(cacheDir, sha1b))).To(BeTrue())
+			Expect(cache.Stats().Size).To(Equal(int64(6)))
+		})
+
+		It("keeps usage order of cached blobs for a subsequent cache instance", func() {
+			buildCache(CacheOptions{MaxSize: 12})
+
+			path1, sha1a := writeBlob("blob1", "blob-1")
+			path2, sha1b := writeBlob("blob2", "blob-2")
+			path3, sha1c := writeBlob("blob3", "blob-3")
+
+			innerBlobstore.GetFileName = path1
+			_, err := cache.Get("fake-blob-id-1", sha1a)
+			Expect(err).ToNot(HaveOccurred())
+
+			innerBlobstore.GetFileName = path2
+			_, err = cache.Get("fake-blob-id-2", sha1b)
+			Expect(err).ToNot(HaveOccurred())
+
+			// Blobs were cached some time ago
+			err = os.Chtimes(filepath.Join(cacheDir, sha1a), time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+
+			err = os.Chtimes(filepath.Join(cacheDir, sha1b), time.Now().Add(-1*time.Hour), time.Now().Add(-1*time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := cache.Get("fake-blob-id-1", sha1a)
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(fileName)
+
+			buildCache(CacheOptions{MaxSize: 12})
+
+			innerBlobstore.GetFileName = path3
+			_, err = cache.Get("fake-blob-id-3", sha1c)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(filepath.Join(cacheDir, sha1a))).To(BeTrue())
+			Expect(fs.FileExists(filepath.Join(cacheDir, sha1b))).To(BeFalse())
+			Expect(fs.FileExists(filepath.Join(cacheDir, sha1c))).To(BeTrue())
+		})
+
+		It("does not cache blobs larger than max size", func() {
+			path, sha1 := writeBlob("blob", "fake-large-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(filepath.Join(cacheDir, sha1))).To(BeFalse())
+			Expect(cache.Stats().Size).To(Equal(int64(0)))
+		})
+
+		It("does not use cache when fingerprint is empty", func() {
+			path, _ := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cache.Stats()).To(Equal(CacheStats{MaxSize: 10}))
+		})
+
+		It("does not use cache when disabled", func() {
+			buildCache(CacheOptions{Disabled: true})
+
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(cacheDir)).To(BeFalse())
+		})
+
+		It("returns error when inner blobstore fails", func() {
+			innerBlobstore.GetError = errors.New("fake-get-error")
+
+			_, err := cache.Get("fake-blob-id", "fake-sha1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		})
+	})
+
+	Describe("Contains", func() {
+		It("returns true only for cached blobs", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			Expect(cache.Contains(sha1)).To(BeFalse())
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(cache.Contains(sha1)).To(BeTrue())
+			Expect(cache.Contains("")).To(BeFalse())
+		})
+
+		It("returns true for blobs cached by a previous cache instance", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			buildCache(CacheOptions{MaxSize: 10})
+
+			Expect(cache.Contains(sha1)).To(BeTrue())
+		})
+
+		It("returns false when disabled", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			buildCache(CacheOptions{Disabled: true})
+
+			Expect(cache.Contains(sha1)).To(BeFalse())
+		})
+	})
+
+	Describe("CleanUp", func() {
+		It("removes blobs copied out of the cache", func() {
+			path, sha1 := writeBlob("blob", "fake-blob")
+			innerBlobstore.GetFileName = path
+
+			_, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			fileName, err := cache.Get("fake-blob-id", sha1)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = cache.CleanUp(fileName)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fs.FileExists(fileName)).To(BeFalse())
+			Expect(innerBlobstore.CleanUpFileName).To(BeEmpty())
+		})
+
+		It("delegates clean up of downloaded blobs to inner blobstore", func() {
+			err := cache.CleanUp("fake-file-name")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(innerBlobstore.CleanUpFileName).To(Equal("fake-file-name"))
+		})
+	})
+
+	It("delegates create to inner blobstore", func() {
+		innerBlobstore.CreateBlobID = "fake-blob-id"
+
+		blobID, _, err := cache.Create("fake-file-name")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("fake-blob-id"))
+		Expect(innerBlobstore.CreateFileName).To(Equal("fake-file-name"))
+	})
+})
@@ -55,6 +55,10 @@ func (b sha1Verifiable) Create(fileName string) (blobID string, fingerprint stri
 	return
 }
 
+func (b sha1Verifiable) Size(blobID string) (int64, bool) {
+	return blobSize(b.blobstore, blobID)
+}
+
 func calculateSha1(fileName string) (fingerprint string, err error) {
 	file, err := os.Open(fileName)
 	if err != nil {
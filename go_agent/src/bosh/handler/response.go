@@ -21,7 +21,8 @@ func (r valueResponse) responseInterfaceFunc() {}
 
 type exceptionResponse struct {
 	Exception struct {
-		Message string `json:"message,omitempty"`
+		Message        string `json:"message,omitempty"`
+		LogBlobstoreID string `json:"log_blobstore_id,omitempty"`
 	} `json:"exception"`
 }
 
@@ -31,5 +32,14 @@ func NewExceptionResponse(msg string, args ...interface{}) (resp Response) {
 	return r
 }
 
+// NewExceptionResponseWithLog includes blobstore ID of a log
+// that explains the exception (e.g. package build log)
+func NewExceptionResponseWithLog(logBlobstoreID string, msg string, args ...interface{}) (resp Response) {
+	r := exceptionResponse{}
+	r.Exception.Message = fmt.Sprintf(msg, args...)
+	r.Exception.LogBlobstoreID = logBlobstoreID
+	return r
+}
+
 func (r exceptionResponse) responseInterfaceFunc() {
 }
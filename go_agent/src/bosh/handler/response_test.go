@@ -18,5 +18,10 @@ func init() {
 			resp := NewExceptionResponse("oops!")
 			boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"oops!"}}`)
 		})
+		It("json with exception and log blobstore id", func() {
+
+			resp := NewExceptionResponseWithLog("fake-log-blob-id", "oops %s", "100%")
+			boshassert.MatchesJSONString(GinkgoT(), resp, `{"exception":{"message":"oops 100%","log_blobstore_id":"fake-log-blob-id"}}`)
+		})
 	})
 }
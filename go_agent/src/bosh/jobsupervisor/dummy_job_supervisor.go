@@ -30,6 +30,18 @@ func (s *dummyJobSupervisor) Status() (status string) {
 	return s.status
 }
 
+func (s *dummyJobSupervisor) StartProcess(name string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) StopProcess(name string) error {
+	return nil
+}
+
+func (s *dummyJobSupervisor) Processes() ([]Process, error) {
+	return []Process{}, nil
+}
+
 func (s *dummyJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	return nil
 }
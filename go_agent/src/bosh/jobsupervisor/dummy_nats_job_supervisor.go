@@ -24,6 +24,18 @@ func (d *dummyNatsJobSupervisor) Reload() error {
 	return nil
 }
 
+func (d *dummyNatsJobSupervisor) StartProcess(name string) error {
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) StopProcess(name string) error {
+	return nil
+}
+
+func (d *dummyNatsJobSupervisor) Processes() ([]Process, error) {
+	return []Process{}, nil
+}
+
 func (d *dummyNatsJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
 	return nil
 }
@@ -1,13 +1,16 @@
 package fakes
 
 import (
+	"sync"
+
 	boshalert "bosh/agent/alert"
 	boshjobsuper "bosh/jobsupervisor"
 )
 
 type FakeJobSupervisor struct {
-	Reloaded  bool
-	ReloadErr error
+	Reloaded    bool
+	ReloadCount int
+	ReloadErr   error
 
 	AddJobArgs []AddJobArgs
 
@@ -23,7 +26,24 @@ type FakeJobSupervisor struct {
 	Unmonitored  bool
 	UnmonitorErr error
 
+	// Use SetStatus when status is read concurrently
 	StatusStatus string
+	statusLock   sync.Mutex
+
+	StartProcessNames []string
+	StartProcessErr   error
+
+	// Processes report this state after they are started (if set)
+	StartProcessState string
+
+	StopProcessNames []string
+	StopProcessErr   error
+
+	// Processes report this state after they are stopped (if set)
+	StopProcessState string
+
+	ProcessesProcesses []boshjobsuper.Process
+	ProcessesErr       error
 
 	JobFailureAlert *boshalert.MonitAlert
 }
@@ -40,6 +60,7 @@ func NewFakeJobSupervisor() *FakeJobSupervisor {
 
 func (m *FakeJobSupervisor) Reload() error {
 	m.Reloaded = true
+	m.ReloadCount++
 	return m.ReloadErr
 }
 
@@ -74,9 +95,47 @@ func (m *FakeJobSupervisor) Unmonitor() error {
 }
 
 func (m *FakeJobSupervisor) Status() string {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+
 	return m.StatusStatus
 }
 
+func (m *FakeJobSupervisor) SetStatus(status string) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+
+	m.StatusStatus = status
+}
+
+func (m *FakeJobSupervisor) StartProcess(name string) error {
+	m.StartProcessNames = append(m.StartProcessNames, name)
+	m.setProcessState(name, m.StartProcessState)
+	return m.StartProcessErr
+}
+
+func (m *FakeJobSupervisor) StopProcess(name string) error {
+	m.StopProcessNames = append(m.StopProcessNames, name)
+	m.setProcessState(name, m.StopProcessState)
+	return m.StopProcessErr
+}
+
+func (m *FakeJobSupervisor) setProcessState(name, state string) {
+	if state == "" {
+		return
+	}
+
+	for i, process := range m.ProcessesProcesses {
+		if process.Name == name {
+			m.ProcessesProcesses[i].State = state
+		}
+	}
+}
+
+func (m *FakeJobSupervisor) Processes() ([]boshjobsuper.Process, error) {
+	return m.ProcessesProcesses, m.ProcessesErr
+}
+
 func (m *FakeJobSupervisor) MonitorJobFailures(handler boshjobsuper.JobFailureHandler) error {
 	if m.JobFailureAlert != nil {
 		handler(*m.JobFailureAlert)
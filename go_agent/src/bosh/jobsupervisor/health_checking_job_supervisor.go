@@ -0,0 +1,383 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	boshalert "bosh/agent/alert"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const healthCheckingJobSupervisorLogTag = "healthCheckingJobSupervisor"
+
+// DefaultHealthProbeInterval is how often job health probes are run
+const DefaultHealthProbeInterval = 30 * time.Second
+
+// healthCheckingJobSupervisor runs probes declared in job's health.json
+// and reports job as failing when any of them fails even though
+// wrapped job supervisor considers job to be running.
+type healthCheckingJobSupervisor struct {
+	delegate JobSupervisor
+
+	fs       boshsys.FileSystem
+	prober   healthProber
+	logger   boshlog.Logger
+	interval time.Duration
+
+	// Probes are restored from <jobsDir>/<job>/health.json after agent restart
+	jobsDir string
+
+	lock sync.Mutex
+
+	// Probes of jobs added since RemoveAllJobs; they take effect on Reload
+	pendingProbes []jobHealthProbe
+	pendingDirs   map[string]bool
+
+	// Probes are only restored if jobs were not added before monitoring started
+	jobsAdded bool
+
+	probes []jobHealthProbe
+
+	// Failure descriptions keyed by job/probe
+	failures map[string]string
+
+	handler JobFailureHandler
+
+	// Closed to stop probing; nil when not probing
+	stopCh      chan struct{}
+	probingDone sync.WaitGroup
+}
+
+type jobHealthProbe struct {
+	jobName string
+	config  healthProbeConfig
+}
+
+func NewHealthCheckingJobSupervisor(
+	delegate JobSupervisor,
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	jobsDir string,
+	interval time.Duration,
+) *healthCheckingJobSupervisor {
+	return &healthCheckingJobSupervisor{
+		delegate: delegate,
+		fs:       fs,
+		prober:   healthProber{runner: runner},
+		logger:   logger,
+		jobsDir:  jobsDir,
+		interval: interval,
+
+		pendingDirs: map[string]bool{},
+		failures:    map[string]string{},
+	}
+}
+
+func (s *healthCheckingJobSupervisor) Reload() error {
+	err := s.delegate.Reload()
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.probes = append([]jobHealthProbe{}, s.pendingProbes...)
+
+	active := map[string]bool{}
+	for _, probe := range s.probes {
+		active[probe.key()] = true
+	}
+
+	for key := range s.failures {
+		if !active[key] {
+			delete(s.failures, key)
+		}
+	}
+
+	return nil
+}
+
+func (s *healthCheckingJobSupervisor) Start() error {
+	return s.delegate.Start()
+}
+
+func (s *healthCheckingJobSupervisor) Stop() error {
+	err := s.delegate.Stop()
+	if err != nil {
+		return err
+	}
+
+	// Failures observed before stopping should not
+	// make job look failing once it is started again
+	s.lock.Lock()
+	s.failures = map[string]string{}
+	s.lock.Unlock()
+
+	return nil
+}
+
+func (s *healthCheckingJobSupervisor) Unmonitor() error {
+	return s.delegate.Unmonitor()
+}
+
+func (s *healthCheckingJobSupervisor) Status() string {
+	status := s.delegate.Status()
+	if status != "running" {
+		return status
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.failures) > 0 {
+		return "failing"
+	}
+
+	return status
+}
+
+func (s *healthCheckingJobSupervisor) StartProcess(name string) error {
+	return s.delegate.StartProcess(name)
+}
+
+func (s *healthCheckingJobSupervisor) StopProcess(name string) error {
+	return s.delegate.StopProcess(name)
+}
+
+func (s *healthCheckingJobSupervisor) Processes() ([]Process, error) {
+	return s.delegate.Processes()
+}
+
+func (s *healthCheckingJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	err := s.delegate.AddJob(jobName, jobIndex, configPath)
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.jobsAdded = true
+
+	return s.addProbes(jobName, filepath.Dir(configPath))
+}
+
+// addProbes must be called with lock held
+func (s *healthCheckingJobSupervisor) addProbes(jobName, jobDir string) error {
+	if s.pendingDirs[jobDir] {
+		return nil
+	}
+
+	healthPath := filepath.Join(jobDir, "health.json")
+	if !s.fs.FileExists(healthPath) {
+		return nil
+	}
+
+	content, err := s.fs.ReadFile(healthPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job health probes from file")
+	}
+
+	configs, err := parseHealthProbes(content)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing job health probes")
+	}
+
+	for _, config := range configs {
+		s.pendingProbes = append(s.pendingProbes, jobHealthProbe{jobName: jobName, config: config})
+	}
+
+	s.pendingDirs[jobDir] = true
+
+	return nil
+}
+
+func (s *healthCheckingJobSupervisor) RemoveAllJobs() error {
+	err := s.delegate.RemoveAllJobs()
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.pendingProbes = nil
+	s.pendingDirs = map[string]bool{}
+	s.jobsAdded = true
+
+	return nil
+}
+
+// MonitorJobFailures is called when agent starts so probes
+// of installed jobs are restored before probing starts
+func (s *healthCheckingJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	s.lock.Lock()
+
+	s.handler = handler
+
+	if !s.jobsAdded {
+		err := s.restoreProbes()
+		if err != nil {
+			s.logger.Error(healthCheckingJobSupervisorLogTag, "Failed to restore health probes: %s", err.Error())
+		}
+	}
+
+	if s.stopCh == nil {
+		s.stopCh = make(chan struct{})
+		s.probingDone.Add(1)
+		go s.probePeriodically(s.stopCh)
+	}
+
+	s.lock.Unlock()
+
+	return s.delegate.MonitorJobFailures(handler)
+}
+
+// restoreProbes must be called with lock held
+func (s *healthCheckingJobSupervisor) restoreProbes() error {
+	// Jobs are only restored once
+	s.jobsAdded = true
+
+	healthPaths, err := s.fs.Glob(filepath.Join(s.jobsDir, "*", "health.json"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing installed jobs")
+	}
+
+	for _, healthPath := range healthPaths {
+		jobDir := filepath.Dir(healthPath)
+
+		err = s.addProbes(filepath.Base(jobDir), jobDir)
+		if err != nil {
+			return bosherr.WrapError(err, "Restoring health probes of job %s", filepath.Base(jobDir))
+		}
+	}
+
+	s.probes = append([]jobHealthProbe{}, s.pendingProbes...)
+
+	return nil
+}
+
+// StopMonitoring stops health probes and waits for running ones to finish
+func (s *healthCheckingJobSupervisor) StopMonitoring() {
+	s.lock.Lock()
+
+	stopCh := s.stopCh
+	s.stopCh = nil
+
+	s.lock.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+
+	close(stopCh)
+	s.probingDone.Wait()
+}
+
+func (s *healthCheckingJobSupervisor) probePeriodically(stopCh chan struct{}) {
+	defer s.probingDone.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runProbes()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (s *healthCheckingJobSupervisor) runProbes() {
+	// Stopped or restarting jobs are expected to fail probes
+	status := s.delegate.Status()
+	if status != "running" {
+		s.logger.Debug(healthCheckingJobSupervisorLogTag, "Skipping health probes since job is %s", status)
+		return
+	}
+
+	s.lock.Lock()
+	probes := s.probes
+	s.lock.Unlock()
+
+	for _, probe := range probes {
+		probeErr := s.prober.probe(probe.config)
+		s.recordResult(probe, probeErr)
+	}
+}
+
+func (s *healthCheckingJobSupervisor) recordResult(probe jobHealthProbe, probeErr error) {
+	key := probe.key()
+
+	s.lock.Lock()
+
+	active := false
+	for _, activeProbe := range s.probes {
+		if activeProbe.key() == key {
+			active = true
+			break
+		}
+	}
+
+	// Jobs might have been reloaded while probe was running
+	if !active {
+		s.lock.Unlock()
+		return
+	}
+
+	_, wasFailing := s.failures[key]
+
+	if probeErr != nil {
+		s.failures[key] = probeErr.Error()
+	} else {
+		delete(s.failures, key)
+	}
+
+	handler := s.handler
+
+	s.lock.Unlock()
+
+	switch {
+	case probeErr != nil && !wasFailing:
+		s.logger.Error(healthCheckingJobSupervisorLogTag, "Health probe %s started failing: %s", key, probeErr.Error())
+		s.reportProbe(handler, key, "health probe failed", probeErr.Error())
+
+	case probeErr == nil && wasFailing:
+		s.logger.Info(healthCheckingJobSupervisorLogTag, "Health probe %s recovered", key)
+		s.reportProbe(handler, key, "health probe recovered", "probe succeeded")
+	}
+}
+
+func (s *healthCheckingJobSupervisor) reportProbe(handler JobFailureHandler, key, event, description string) {
+	if handler == nil {
+		return
+	}
+
+	now := time.Now()
+
+	alert := boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s@localhost", now.Unix(), key),
+		Service:     key,
+		Event:       event,
+		Action:      "alert",
+		Date:        now.Format(time.RFC1123Z),
+		Description: description,
+	}
+
+	err := handler(alert)
+	if err != nil {
+		s.logger.Error(healthCheckingJobSupervisorLogTag, "Failed to handle health probe %s alert: %s", key, err.Error())
+	}
+}
+
+func (p jobHealthProbe) key() string {
+	return p.jobName + "/" + p.config.Name
+}
@@ -0,0 +1,399 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "bosh/agent/alert"
+	. "bosh/jobsupervisor"
+	fakejobsuper "bosh/jobsupervisor/fakes"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	fakesys "bosh/system/fakes"
+)
+
+// Probing is stopped after each test so that probes
+// do not keep running against fakes of other tests
+type stoppableJobSupervisor interface {
+	JobSupervisor
+	StopMonitoring()
+}
+
+var _ = Describe("healthCheckingJobSupervisor", func() {
+	var (
+		delegate   *fakejobsuper.FakeJobSupervisor
+		fs         *fakesys.FakeFileSystem
+		runner     *fakesys.FakeCmdRunner
+		supervisor stoppableJobSupervisor
+		alertsCh   chan boshalert.MonitAlert
+	)
+
+	BeforeEach(func() {
+		delegate = fakejobsuper.NewFakeJobSupervisor()
+		delegate.SetStatus("running")
+
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		supervisor = NewHealthCheckingJobSupervisor(delegate, fs, runner, logger, "/fake-installed-jobs", 10*time.Millisecond)
+
+		alertsCh = make(chan boshalert.MonitAlert, 100)
+	})
+
+	AfterEach(func() {
+		supervisor.StopMonitoring()
+	})
+
+	monitor := func() {
+		err := supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+			alertsCh <- alert
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	receiveAlert := func() boshalert.MonitAlert {
+		select {
+		case alert := <-alertsCh:
+			return alert
+		case <-time.After(1 * time.Second):
+			Fail("Expected alert to be reported")
+		}
+		return boshalert.MonitAlert{}
+	}
+
+	status := func() string { return supervisor.Status() }
+
+	addJob := func(jobName, healthJSON string) {
+		fs.WriteFileString("/fake-jobs/"+jobName+"/monit", "")
+		fs.WriteFileString("/fake-jobs/"+jobName+"/health.json", healthJSON)
+
+		err := supervisor.AddJob(jobName, 0, "/fake-jobs/"+jobName+"/monit")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = supervisor.Reload()
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Describe("AddJob", func() {
+		It("adds job to wrapped job supervisor", func() {
+			err := supervisor.AddJob("fake-job", 1, "/fake-jobs/fake-job/monit")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(delegate.AddJobArgs).To(Equal([]fakejobsuper.AddJobArgs{
+				{Name: "fake-job", Index: 1, ConfigPath: "/fake-jobs/fake-job/monit"},
+			}))
+		})
+
+		It("returns error when health probes cannot be parsed", func() {
+			fs.WriteFileString("/fake-jobs/fake-job/health.json", "-")
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-jobs/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing job health probes"))
+		})
+
+		It("returns error when health probe has unknown type", func() {
+			fs.WriteFileString("/fake-jobs/fake-job/health.json", `{"probes":[{"name":"fake-probe","type":"udp"}]}`)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-jobs/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Health probe fake-probe has unknown type udp"))
+		})
+
+		It("returns error when health probe is missing required field", func() {
+			fs.WriteFileString("/fake-jobs/fake-job/health.json", `{"probes":[{"name":"fake-probe","type":"tcp"}]}`)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-jobs/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Health probe fake-probe does not have address"))
+		})
+	})
+
+	Describe("Reload", func() {
+		It("returns error from wrapped job supervisor", func() {
+			delegate.ReloadErr = errors.New("fake-reload-err")
+
+			err := supervisor.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-reload-err"))
+		})
+	})
+
+	Describe("Status", func() {
+		It("returns status of wrapped job supervisor when job does not have probes", func() {
+			monitor()
+
+			delegate.SetStatus("starting")
+			Expect(supervisor.Status()).To(Equal("starting"))
+		})
+
+		Context("with http probe", func() {
+			var (
+				server         *httptest.Server
+				responseStatus int32
+				requests       int32
+			)
+
+			BeforeEach(func() {
+				atomic.StoreInt32(&responseStatus, http.StatusInternalServerError)
+				atomic.StoreInt32(&requests, 0)
+
+				server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					atomic.AddInt32(&requests, 1)
+					w.WriteHeader(int(atomic.LoadInt32(&responseStatus)))
+				}))
+
+				addJob("fake-job", `{"probes":[{"name":"fake-probe","type":"http","url":"`+server.URL+`/health"}]}`)
+			})
+
+			AfterEach(func() { server.Close() })
+
+			probeRuns := func() int32 { return atomic.LoadInt32(&requests) }
+
+			It("reports job as failing and sends alert when probe starts failing", func() {
+				monitor()
+
+				alert := receiveAlert()
+				Expect(alert.Service).To(Equal("fake-job/fake-probe"))
+				Expect(alert.Event).To(Equal("health probe failed"))
+				Expect(alert.Action).To(Equal("alert"))
+				Expect(alert.Description).To(ContainSubstring("Expected status 200"))
+				Expect(alert.Description).To(ContainSubstring("but got 500"))
+
+				Expect(status()).To(Equal("failing"))
+			})
+
+			It("sends single alert while probe keeps failing", func() {
+				monitor()
+
+				receiveAlert()
+
+				Eventually(probeRuns).Should(BeNumerically(">=", 3))
+				supervisor.StopMonitoring()
+
+				Expect(alertsCh).To(BeEmpty())
+			})
+
+			It("reports job as running and sends alert when probe recovers", func() {
+				monitor()
+
+				receiveAlert()
+
+				atomic.StoreInt32(&responseStatus, http.StatusOK)
+
+				alert := receiveAlert()
+				Expect(alert.Service).To(Equal("fake-job/fake-probe"))
+				Expect(alert.Event).To(Equal("health probe recovered"))
+
+				Expect(status()).To(Equal("running"))
+			})
+
+			It("uses expected status from probe", func() {
+				atomic.StoreInt32(&responseStatus, http.StatusNoContent)
+
+				err := supervisor.RemoveAllJobs()
+				Expect(err).ToNot(HaveOccurred())
+
+				addJob("fake-job-2", `{"probes":[{"name":"fake-probe","type":"http","url":"`+server.URL+`","expected_status":204}]}`)
+
+				monitor()
+
+				Eventually(probeRuns).ShouldNot(BeZero())
+				supervisor.StopMonitoring()
+
+				Expect(alertsCh).To(BeEmpty())
+				Expect(status()).To(Equal("running"))
+			})
+
+			It("does not run probes when job is not running", func() {
+				delegate.SetStatus("stopped")
+
+				monitor()
+
+				Consistently(probeRuns, 50*time.Millisecond).Should(BeZero())
+				Expect(alertsCh).To(BeEmpty())
+				Expect(status()).To(Equal("stopped"))
+			})
+
+			It("forgets failures of probes removed by reload", func() {
+				monitor()
+
+				receiveAlert()
+				Expect(status()).To(Equal("failing"))
+
+				err := supervisor.RemoveAllJobs()
+				Expect(err).ToNot(HaveOccurred())
+
+				err = supervisor.Reload()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(status()).To(Equal("running"))
+			})
+
+			It("forgets failures when job is stopped", func() {
+				monitor()
+
+				receiveAlert()
+
+				delegate.SetStatus("stopped")
+
+				err := supervisor.Stop()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(delegate.Stopped).To(BeTrue())
+
+				atomic.StoreInt32(&responseStatus, http.StatusOK)
+				delegate.SetStatus("running")
+
+				Expect(status()).To(Equal("running"))
+
+				runs := probeRuns()
+				Eventually(probeRuns).Should(BeNumerically(">", runs))
+				supervisor.StopMonitoring()
+
+				Expect(alertsCh).To(BeEmpty())
+			})
+		})
+
+		Context("with tcp probe", func() {
+			It("reports job as failing when connection cannot be made", func() {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).ToNot(HaveOccurred())
+
+				address := listener.Addr().String()
+				listener.Close()
+
+				addJob("fake-job", `{"probes":[{"name":"fake-probe","type":"tcp","address":"`+address+`"}]}`)
+
+				monitor()
+
+				alert := receiveAlert()
+				Expect(alert.Event).To(Equal("health probe failed"))
+				Expect(alert.Description).To(ContainSubstring("Connecting to " + address))
+
+				Expect(status()).To(Equal("failing"))
+			})
+
+			It("reports job as running when connection can be made", func() {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).ToNot(HaveOccurred())
+
+				defer listener.Close()
+
+				var connections int32
+
+				go func() {
+					for {
+						conn, err := listener.Accept()
+						if err != nil {
+							return
+						}
+						atomic.AddInt32(&connections, 1)
+						conn.Close()
+					}
+				}()
+
+				addJob("fake-job", `{"probes":[{"name":"fake-probe","type":"tcp","address":"`+listener.Addr().String()+`"}]}`)
+
+				monitor()
+
+				Eventually(func() int32 { return atomic.LoadInt32(&connections) }).ShouldNot(BeZero())
+				supervisor.StopMonitoring()
+
+				Expect(alertsCh).To(BeEmpty())
+				Expect(status()).To(Equal("running"))
+			})
+		})
+
+		Context("with exec probe", func() {
+			addResults := func(exitStatus int) {
+				// Each probe run needs its own process
+				for i := 0; i < 100; i++ {
+					runner.AddProcess("/fake-check -q", &fakesys.FakeProcess{
+						WaitResult: boshsys.Result{ExitStatus: exitStatus},
+					})
+				}
+			}
+
+			BeforeEach(func() {
+				addJob("fake-job", `{"probes":[{"name":"fake-probe","type":"exec","command":"/fake-check","args":["-q"]}]}`)
+			})
+
+			It("reports job as failing when command exits with non-zero status", func() {
+				addResults(1)
+
+				monitor()
+
+				alert := receiveAlert()
+				Expect(alert.Event).To(Equal("health probe failed"))
+				Expect(alert.Description).To(ContainSubstring("Command /fake-check exited with status 1"))
+
+				Expect(status()).To(Equal("failing"))
+			})
+
+			It("reports job as running when command succeeds", func() {
+				addResults(0)
+
+				monitor()
+
+				Eventually(runner.RunComplexCommandsCount).ShouldNot(BeZero())
+				supervisor.StopMonitoring()
+
+				Expect(alertsCh).To(BeEmpty())
+				Expect(status()).To(Equal("running"))
+			})
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		It("monitors failures reported by wrapped job supervisor", func() {
+			delegate.JobFailureAlert = &boshalert.MonitAlert{ID: "fake-monit-alert"}
+
+			monitor()
+
+			alert := receiveAlert()
+			Expect(alert.ID).To(Equal("fake-monit-alert"))
+		})
+
+		Context("when agent restarts", func() {
+			BeforeEach(func() {
+				fs.WriteFileString("/fake-installed-jobs/fake-job/health.json",
+					`{"probes":[{"name":"fake-probe","type":"exec","command":"/fake-check"}]}`)
+				fs.SetGlob("/fake-installed-jobs/*/health.json", []string{"/fake-installed-jobs/fake-job/health.json"})
+
+				for i := 0; i < 100; i++ {
+					runner.AddProcess("/fake-check", &fakesys.FakeProcess{
+						WaitResult: boshsys.Result{ExitStatus: 1},
+					})
+				}
+			})
+
+			It("runs probes of installed jobs", func() {
+				monitor()
+
+				alert := receiveAlert()
+				Expect(alert.Service).To(Equal("fake-job/fake-probe"))
+				Expect(alert.Event).To(Equal("health probe failed"))
+
+				Expect(status()).To(Equal("failing"))
+			})
+
+			It("does not restore probes when jobs were added before monitoring started", func() {
+				err := supervisor.RemoveAllJobs()
+				Expect(err).ToNot(HaveOccurred())
+
+				monitor()
+
+				Consistently(runner.RunComplexCommandsCount, 50*time.Millisecond).Should(BeZero())
+			})
+		})
+	})
+})
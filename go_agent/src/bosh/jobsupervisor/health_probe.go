@@ -0,0 +1,180 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	bosherr "bosh/errors"
+	boshsys "bosh/system"
+)
+
+const (
+	healthProbeHTTP = "http"
+	healthProbeTCP  = "tcp"
+	healthProbeExec = "exec"
+
+	healthProbeDefaultTimeout = 5 * time.Second
+)
+
+// healthProbesConfig is read from health.json in job's directory e.g.
+//
+//	{
+//	  "probes": [
+//	    {"name": "api", "type": "http", "url": "http://127.0.0.1:8080/health", "expected_status": 200},
+//	    {"name": "port", "type": "tcp", "address": "127.0.0.1:8081", "timeout": 2},
+//	    {"name": "check", "type": "exec", "command": "/var/vcap/jobs/api/bin/check", "args": ["-q"]}
+//	  ]
+//	}
+type healthProbesConfig struct {
+	Probes []healthProbeConfig `json:"probes"`
+}
+
+type healthProbeConfig struct {
+	Name string `json:"name"`
+
+	// One of http, tcp or exec
+	Type string `json:"type"`
+
+	// Used by http probe; expected status defaults to 200
+	URL            string `json:"url"`
+	ExpectedStatus int    `json:"expected_status"`
+
+	// Used by tcp probe
+	Address string `json:"address"`
+
+	// Used by exec probe; command has to exit with 0
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+
+	TimeoutSeconds int `json:"timeout"`
+}
+
+func parseHealthProbes(content []byte) ([]healthProbeConfig, error) {
+	var config healthProbesConfig
+
+	err := json.Unmarshal(content, &config)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling health probes")
+	}
+
+	names := map[string]bool{}
+
+	for i, probe := range config.Probes {
+		if probe.Name == "" {
+			return nil, bosherr.New("Health probe at index %d does not have name", i)
+		}
+
+		if names[probe.Name] {
+			return nil, bosherr.New("Health probe %s is specified more than once", probe.Name)
+		}
+		names[probe.Name] = true
+
+		var missing string
+
+		switch probe.Type {
+		case healthProbeHTTP:
+			if probe.URL == "" {
+				missing = "url"
+			}
+			if probe.ExpectedStatus == 0 {
+				config.Probes[i].ExpectedStatus = http.StatusOK
+			}
+		case healthProbeTCP:
+			if probe.Address == "" {
+				missing = "address"
+			}
+		case healthProbeExec:
+			if probe.Command == "" {
+				missing = "command"
+			}
+		default:
+			return nil, bosherr.New("Health probe %s has unknown type %s", probe.Name, probe.Type)
+		}
+
+		if missing != "" {
+			return nil, bosherr.New("Health probe %s does not have %s", probe.Name, missing)
+		}
+	}
+
+	return config.Probes, nil
+}
+
+func (c healthProbeConfig) timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return healthProbeDefaultTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+type healthProber struct {
+	runner boshsys.CmdRunner
+}
+
+// probe returns error describing why probe failed
+func (p healthProber) probe(config healthProbeConfig) error {
+	switch config.Type {
+	case healthProbeHTTP:
+		return p.probeHTTP(config)
+	case healthProbeTCP:
+		return p.probeTCP(config)
+	default:
+		return p.probeExec(config)
+	}
+}
+
+func (p healthProber) probeHTTP(config healthProbeConfig) error {
+	client := http.Client{Timeout: config.timeout()}
+
+	response, err := client.Get(config.URL)
+	if err != nil {
+		return bosherr.WrapError(err, "Requesting %s", config.URL)
+	}
+
+	response.Body.Close()
+
+	if response.StatusCode != config.ExpectedStatus {
+		return bosherr.New("Expected status %d from %s but got %d", config.ExpectedStatus, config.URL, response.StatusCode)
+	}
+
+	return nil
+}
+
+func (p healthProber) probeTCP(config healthProbeConfig) error {
+	conn, err := net.DialTimeout("tcp", config.Address, config.timeout())
+	if err != nil {
+		return bosherr.WrapError(err, "Connecting to %s", config.Address)
+	}
+
+	conn.Close()
+
+	return nil
+}
+
+func (p healthProber) probeExec(config healthProbeConfig) error {
+	cmd := boshsys.Command{
+		Name: config.Command,
+		Args: config.Args,
+	}
+
+	process, err := p.runner.RunComplexCommandAsync(cmd)
+	if err != nil {
+		return bosherr.WrapError(err, "Running %s", config.Command)
+	}
+
+	resultCh := process.Wait()
+
+	select {
+	case result := <-resultCh:
+		if result.ExitStatus != 0 {
+			return bosherr.New("Command %s exited with status %d", config.Command, result.ExitStatus)
+		}
+		return nil
+
+	case <-time.After(config.timeout()):
+		process.TerminateNicely(1 * time.Second)
+		<-resultCh
+		return bosherr.New("Command %s did not finish within %s", config.Command, config.timeout())
+	}
+}
@@ -6,6 +6,34 @@ import (
 
 type JobFailureHandler func(boshalert.MonitAlert) error
 
+// Process is a single service managed by job supervisor;
+// details that supervisor does not know about are left empty
+type Process struct {
+	Name string `json:"name"`
+
+	// One of running, starting, failing or stopped
+	State string `json:"state"`
+
+	Pid           int `json:"pid"`
+	UptimeSeconds int `json:"uptime"`
+	Children      int `json:"children"`
+
+	Memory ProcessMemory `json:"mem"`
+	CPU    ProcessCPU    `json:"cpu"`
+
+	// Number of times process was restarted since agent started
+	Restarts int `json:"restarts"`
+}
+
+type ProcessMemory struct {
+	Kb      int     `json:"kb"`
+	Percent float64 `json:"percent"`
+}
+
+type ProcessCPU struct {
+	Total float64 `json:"total"`
+}
+
 type JobSupervisor interface {
 	Reload() error
 
@@ -21,6 +49,12 @@ type JobSupervisor interface {
 
 	Status() string
 
+	// Actions taken on a single service
+	StartProcess(name string) error
+	StopProcess(name string) error
+
+	Processes() ([]Process, error)
+
 	// Job management
 	AddJob(jobName string, jobIndex int, configPath string) error
 	RemoveAllJobs() error
@@ -25,6 +25,23 @@ type serviceTag struct {
 	Name    string   `xml:"name,attr"`
 	Status  int      `xml:"status"`
 	Monitor int      `xml:"monitor"`
+
+	// Only included for running processes
+	Pid      int       `xml:"pid"`
+	Uptime   int       `xml:"uptime"`
+	Children int       `xml:"children"`
+	Memory   memoryTag `xml:"memory"`
+	CPU      cpuTag    `xml:"cpu"`
+}
+
+// Totals include process' children
+type memoryTag struct {
+	PercentTotal  float64 `xml:"percenttotal"`
+	KilobyteTotal int     `xml:"kilobytetotal"`
+}
+
+type cpuTag struct {
+	PercentTotal float64 `xml:"percenttotal"`
 }
 
 type serviceGroupsTag struct {
@@ -84,8 +101,16 @@ func (status status) ServicesInGroup(name string) (services []Service) {
 	for _, serviceTag := range status.Services.Services {
 		if serviceGroupTag.Contains(serviceTag.Name) {
 			service := Service{
+				Name:      serviceTag.Name,
 				Monitored: serviceTag.Monitor > 0,
 				Status:    serviceTag.StatusString(),
+
+				Pid:             serviceTag.Pid,
+				UptimeSeconds:   serviceTag.Uptime,
+				Children:        serviceTag.Children,
+				MemoryKilobytes: serviceTag.Memory.KilobyteTotal,
+				MemoryPercent:   serviceTag.Memory.PercentTotal,
+				CPUPercent:      serviceTag.CPU.PercentTotal,
 			}
 
 			services = append(services, service)
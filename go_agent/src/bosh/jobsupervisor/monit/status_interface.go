@@ -6,6 +6,16 @@ type Status interface {
 }
 
 type Service struct {
+	Name      string
 	Monitored bool
 	Status    string
+
+	Pid           int
+	UptimeSeconds int
+	Children      int
+
+	// Memory and CPU usage of process and its children
+	MemoryKilobytes int
+	MemoryPercent   float64
+	CPUPercent      float64
 }
@@ -21,18 +21,29 @@ func init() {
 
 			expectedServices := []Service{
 				{
+					Name:      "running-service",
 					Monitored: true,
 					Status:    "running",
+
+					Pid:             1234,
+					UptimeSeconds:   600,
+					Children:        2,
+					MemoryKilobytes: 4004,
+					MemoryPercent:   0.5,
+					CPUPercent:      1.5,
 				},
 				{
+					Name:      "unmonitored-service",
 					Monitored: false,
 					Status:    "unknown",
 				},
 				{
+					Name:      "starting-service",
 					Monitored: true,
 					Status:    "starting",
 				},
 				{
+					Name:      "failing-service",
 					Monitored: true,
 					Status:    "failing",
 				},
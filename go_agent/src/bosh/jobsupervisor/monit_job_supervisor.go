@@ -3,6 +3,7 @@ package jobsupervisor
 import (
 	"fmt"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/pivotal/go-smtpd/smtpd"
@@ -23,6 +24,9 @@ type monitJobSupervisor struct {
 	dirProvider                    boshdir.DirectoriesProvider
 	jobFailuresServerPort          int
 	delayBetweenReloadCheckRetries time.Duration
+
+	// Monit does not keep track of how many times it restarted services
+	restarts *serviceRestarts
 }
 
 const MonitTag = "Monit Job Supervisor"
@@ -44,6 +48,7 @@ func NewMonitJobSupervisor(
 		dirProvider:                    dirProvider,
 		jobFailuresServerPort:          jobFailuresServerPort,
 		delayBetweenReloadCheckRetries: delayBetweenReloadCheckRetries,
+		restarts:                       newServiceRestarts(),
 	}
 }
 
@@ -150,6 +155,64 @@ func (m monitJobSupervisor) Status() (status string) {
 	return
 }
 
+func (m monitJobSupervisor) StartProcess(name string) error {
+	err := m.client.StartService(name)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting service %s", name)
+	}
+
+	m.logger.Debug(MonitTag, "Starting service %s", name)
+
+	return nil
+}
+
+func (m monitJobSupervisor) StopProcess(name string) error {
+	err := m.client.StopService(name)
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping service %s", name)
+	}
+
+	m.logger.Debug(MonitTag, "Stopping service %s", name)
+
+	return nil
+}
+
+// Processes reports services that monit does not monitor as stopped
+// since monit stops monitoring services that it stops
+func (m monitJobSupervisor) Processes() ([]Process, error) {
+	monitStatus, err := m.client.Status()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting monit status")
+	}
+
+	processes := []Process{}
+
+	for _, service := range monitStatus.ServicesInGroup("vcap") {
+		state := service.Status
+		if !service.Monitored {
+			state = "stopped"
+		}
+
+		process := Process{
+			Name:          service.Name,
+			State:         state,
+			Pid:           service.Pid,
+			UptimeSeconds: service.UptimeSeconds,
+			Children:      service.Children,
+			Memory: ProcessMemory{
+				Kb:      service.MemoryKilobytes,
+				Percent: service.MemoryPercent,
+			},
+			CPU:      ProcessCPU{Total: service.CPUPercent},
+			Restarts: m.restarts.get(service.Name),
+		}
+
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
 func (m monitJobSupervisor) getIncarnation() (int, error) {
 	monitStatus, err := m.client.Status()
 	if err != nil {
@@ -181,10 +244,17 @@ func (m monitJobSupervisor) RemoveAllJobs() error {
 }
 
 func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err error) {
+	countingHandler := func(alert boshalert.MonitAlert) error {
+		if alert.Action == "restart" {
+			m.restarts.increment(alert.Service)
+		}
+		return handler(alert)
+	}
+
 	alertHandler := func(smtpd.Connection, smtpd.MailAddress) (env smtpd.Envelope, err error) {
 		env = &alertEnvelope{
 			new(smtpd.BasicEnvelope),
-			handler,
+			countingHandler,
 			new(boshalert.MonitAlert),
 		}
 		return
@@ -201,3 +271,26 @@ func (m monitJobSupervisor) MonitorJobFailures(handler JobFailureHandler) (err e
 	}
 	return
 }
+
+type serviceRestarts struct {
+	lock   sync.Mutex
+	counts map[string]int
+}
+
+func newServiceRestarts() *serviceRestarts {
+	return &serviceRestarts{counts: map[string]int{}}
+}
+
+func (r *serviceRestarts) increment(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.counts[name]++
+}
+
+func (r *serviceRestarts) get(name string) int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.counts[name]
+}
@@ -197,6 +197,70 @@ func init() {
 			Expect("unknown").To(Equal(status))
 		})
 
+		It("start process starts monit service", func() {
+			err := monit.StartProcess("fake-service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.StartServiceNames).To(Equal([]string{"fake-service"}))
+		})
+
+		It("start process returns error when starting service fails", func() {
+			client.StartServiceErr = errors.New("fake-start-service-error")
+
+			err := monit.StartProcess("fake-service")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-service-error"))
+		})
+
+		It("stop process stops monit service", func() {
+			err := monit.StopProcess("fake-service")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.StopServiceNames).To(Equal([]string{"fake-service"}))
+		})
+
+		It("processes returns state of each service with unmonitored services being stopped", func() {
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					boshmonit.Service{
+						Name:            "fake-service-1",
+						Monitored:       true,
+						Status:          "running",
+						Pid:             1234,
+						UptimeSeconds:   600,
+						Children:        2,
+						MemoryKilobytes: 4004,
+						MemoryPercent:   0.5,
+						CPUPercent:      1.5,
+					},
+					boshmonit.Service{Name: "fake-service-2", Monitored: true, Status: "starting"},
+					boshmonit.Service{Name: "fake-service-3", Monitored: false, Status: "unknown"},
+				},
+			}
+
+			processes, err := monit.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				{
+					Name:          "fake-service-1",
+					State:         "running",
+					Pid:           1234,
+					UptimeSeconds: 600,
+					Children:      2,
+					Memory:        ProcessMemory{Kb: 4004, Percent: 0.5},
+					CPU:           ProcessCPU{Total: 1.5},
+				},
+				{Name: "fake-service-2", State: "starting"},
+				{Name: "fake-service-3", State: "stopped"},
+			}))
+		})
+
+		It("processes returns error when getting status fails", func() {
+			client.StatusErr = errors.New("fake-monit-client-error")
+
+			_, err := monit.Processes()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-monit-client-error"))
+		})
+
 		It("monitor job failures", func() {
 			var handledAlert boshalert.MonitAlert
 
@@ -227,6 +291,34 @@ func init() {
 			})
 		})
 
+		It("processes include number of times monit restarted service", func() {
+			client.StatusStatus = fakemonit.FakeMonitStatus{
+				Services: []boshmonit.Service{
+					boshmonit.Service{Name: "nats", Monitored: true, Status: "running"},
+				},
+			}
+
+			go monit.MonitorJobFailures(func(alert boshalert.MonitAlert) error { return nil })
+
+			msg := `Message-id: <1304319946.0@localhost>
+    Service: nats
+    Event: does not exist
+    Action: restart
+    Date: Sun, 22 May 2011 20:07:41 +0500
+    Description: process is not running`
+
+			err := doJobFailureEmail(msg, jobFailuresServerPort)
+			Expect(err).ToNot(HaveOccurred())
+
+			restarts := func() int {
+				processes, err := monit.Processes()
+				Expect(err).ToNot(HaveOccurred())
+				return processes[0].Restarts
+			}
+
+			Eventually(restarts).Should(Equal(1))
+		})
+
 		It("monitor job failures ignores other emails", func() {
 			var didHandleAlert bool
 
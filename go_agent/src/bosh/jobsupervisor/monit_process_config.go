@@ -0,0 +1,135 @@
+package jobsupervisor
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	bosherr "bosh/errors"
+	boshsys "bosh/system"
+)
+
+// monitProcess is a process definition from a job's monit file
+// used by supervisors other than monit
+type monitProcess struct {
+	Name    string
+	Pidfile string
+
+	StartProgram string
+	StopProgram  string
+
+	// Seconds; 0 when not specified
+	StartTimeout int
+
+	DependsOn []string
+}
+
+var (
+	monitCheckProcessRegexp = regexp.MustCompile(`^check\s+process\s+(\S+)`)
+	monitCheckRegexp        = regexp.MustCompile(`^check\s+`)
+	monitPidfileRegexp      = regexp.MustCompile(`^(?:with\s+)?pidfile\s+"?([^"\s]+)"?`)
+	monitProgramRegexp      = regexp.MustCompile(`^(start|stop)\s+program\s*=?\s*"([^"]+)"(?:\s+with\s+timeout\s+(\d+)\s+seconds?)?`)
+	monitDependsOnRegexp    = regexp.MustCompile(`^depends\s+on\s+(.+)$`)
+)
+
+// JobProcessNames returns names of processes declared in job's monit files
+// (monit and *.monit) regardless of which job supervisor runs them
+func JobProcessNames(fs boshsys.FileSystem, jobDir string) ([]string, error) {
+	var monitFilePaths []string
+
+	monitFilePath := filepath.Join(jobDir, "monit")
+	if fs.FileExists(monitFilePath) {
+		monitFilePaths = append(monitFilePaths, monitFilePath)
+	}
+
+	additionalMonitFilePaths, err := fs.Glob(filepath.Join(jobDir, "*.monit"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Looking for additional monit files")
+	}
+
+	monitFilePaths = append(monitFilePaths, additionalMonitFilePaths...)
+
+	names := []string{}
+
+	for _, path := range monitFilePaths {
+		config, err := fs.ReadFileString(path)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Reading monit file %s", path)
+		}
+
+		processes, err := parseMonitProcesses(config)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing monit file %s", path)
+		}
+
+		for _, process := range processes {
+			names = append(names, process.Name)
+		}
+	}
+
+	return names, nil
+}
+
+// parseMonitProcesses only understands subset of monit syntax
+// that BOSH jobs use to describe their processes
+func parseMonitProcesses(config string) ([]monitProcess, error) {
+	var processes []monitProcess
+	var current *monitProcess
+
+	for _, line := range strings.Split(config, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if matches := monitCheckProcessRegexp.FindStringSubmatch(line); matches != nil {
+			processes = append(processes, monitProcess{Name: matches[1]})
+			current = &processes[len(processes)-1]
+			continue
+		}
+
+		// Other checks (e.g. check file) are not processes
+		if monitCheckRegexp.MatchString(line) {
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if matches := monitPidfileRegexp.FindStringSubmatch(line); matches != nil {
+			current.Pidfile = matches[1]
+			continue
+		}
+
+		if matches := monitProgramRegexp.FindStringSubmatch(line); matches != nil {
+			if matches[1] == "start" {
+				current.StartProgram = matches[2]
+
+				if matches[3] != "" {
+					current.StartTimeout, _ = strconv.Atoi(matches[3])
+				}
+			} else {
+				current.StopProgram = matches[2]
+			}
+			continue
+		}
+
+		if matches := monitDependsOnRegexp.FindStringSubmatch(line); matches != nil {
+			for _, name := range strings.Split(matches[1], ",") {
+				current.DependsOn = append(current.DependsOn, strings.TrimSpace(name))
+			}
+		}
+	}
+
+	for _, process := range processes {
+		if process.StartProgram == "" {
+			return nil, bosherr.New("Process %s does not have start program", process.Name)
+		}
+	}
+
+	return processes, nil
+}
@@ -0,0 +1,658 @@
+package jobsupervisor
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	boshalert "bosh/agent/alert"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const nativeJobSupervisorLogTag = "nativeJobSupervisor"
+
+const (
+	nativeProcessStarting = "starting"
+	nativeProcessRunning  = "running"
+	nativeProcessFailing  = "failing"
+	nativeProcessStopped  = "stopped"
+)
+
+// nativeJobSupervisor runs job processes described in processes.json
+// as children of the agent so that monit does not need to be installed.
+type nativeJobSupervisor struct {
+	fs     boshsys.FileSystem
+	runner boshsys.CmdRunner
+	logger boshlog.Logger
+
+	// Process output is written to <logsDir>/<job>/<process>.std{out,err}.log
+	logsDir string
+
+	// Jobs are restored from <jobsDir>/<job>/processes.json after agent restart
+	jobsDir string
+
+	// Exists while jobs are stopped so that they are not
+	// started again when jobs are restored
+	stoppedPath string
+
+	// Restart delay doubles after each consecutive failure
+	minRestartDelay time.Duration
+	maxRestartDelay time.Duration
+
+	// Serializes starting, stopping and reloading so that
+	// same process is not started and stopped at the same time
+	opLock sync.Mutex
+
+	lock sync.Mutex
+
+	// Jobs added since RemoveAllJobs; they take effect on Reload
+	jobs    []nativeJob
+	jobDirs map[string]bool
+
+	// Jobs are only restored if they were not added before monitoring started
+	jobsAdded bool
+
+	processes []*nativeProcess
+	handler   JobFailureHandler
+}
+
+type nativeJob struct {
+	name      string
+	processes []nativeProcessConfig
+}
+
+type nativeProcess struct {
+	jobName string
+	config  nativeProcessConfig
+
+	state     string
+	monitored bool
+
+	startedAt time.Time
+	restarts  int
+
+	// Both are nil when process is not supervised
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	stdout io.WriteCloser
+	stderr io.WriteCloser
+}
+
+func NewNativeJobSupervisor(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	logsDir string,
+	jobsDir string,
+	stoppedPath string,
+	minRestartDelay time.Duration,
+	maxRestartDelay time.Duration,
+) *nativeJobSupervisor {
+	return &nativeJobSupervisor{
+		fs:              fs,
+		runner:          runner,
+		logger:          logger,
+		logsDir:         logsDir,
+		jobsDir:         jobsDir,
+		stoppedPath:     stoppedPath,
+		minRestartDelay: minRestartDelay,
+		maxRestartDelay: maxRestartDelay,
+		jobDirs:         map[string]bool{},
+	}
+}
+
+// Reload supervises processes of added jobs; processes of jobs
+// that are no longer added are stopped. Processes that kept running
+// use their new configuration after they are restarted.
+func (s *nativeJobSupervisor) Reload() error {
+	s.opLock.Lock()
+	defer s.opLock.Unlock()
+
+	s.lock.Lock()
+
+	removed := map[string]*nativeProcess{}
+	for _, process := range s.processes {
+		removed[process.key()] = process
+	}
+
+	var processes []*nativeProcess
+
+	for _, job := range s.jobs {
+		for _, config := range job.processes {
+			process, found := removed[job.name+"/"+config.Name]
+			if found {
+				delete(removed, process.key())
+				process.config = config
+			} else {
+				process = &nativeProcess{
+					jobName:   job.name,
+					config:    config,
+					state:     nativeProcessStopped,
+					monitored: true,
+				}
+			}
+
+			processes = append(processes, process)
+		}
+	}
+
+	s.processes = processes
+
+	s.lock.Unlock()
+
+	for _, process := range removed {
+		s.stopProcess(process)
+		s.closeLogs(process)
+	}
+
+	return nil
+}
+
+// Start also starts processes that exited and were not restarted
+func (s *nativeJobSupervisor) Start() error {
+	s.opLock.Lock()
+	defer s.opLock.Unlock()
+
+	err := s.fs.RemoveAll(s.stoppedPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Removing stopped jobs marker")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, process := range s.processes {
+		err := s.startProcess(process)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops processes in reverse order
+func (s *nativeJobSupervisor) Stop() error {
+	s.opLock.Lock()
+	defer s.opLock.Unlock()
+
+	err := s.fs.WriteFileString(s.stoppedPath, "")
+	if err != nil {
+		return bosherr.WrapError(err, "Writing stopped jobs marker")
+	}
+
+	s.lock.Lock()
+	processes := s.processes
+	s.lock.Unlock()
+
+	for i := len(processes) - 1; i >= 0; i-- {
+		s.stopProcess(processes[i])
+	}
+
+	return nil
+}
+
+// Unmonitor keeps processes running but does not restart them when they exit
+func (s *nativeJobSupervisor) Unmonitor() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, process := range s.processes {
+		process.monitored = false
+	}
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) Status() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	status := "running"
+
+	for _, process := range s.processes {
+		switch {
+		case process.state == nativeProcessStarting:
+			return "starting"
+		case !process.monitored || process.state != nativeProcessRunning:
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (s *nativeJobSupervisor) StartProcess(name string) error {
+	s.opLock.Lock()
+	defer s.opLock.Unlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	process, err := s.findProcess(name)
+	if err != nil {
+		return err
+	}
+
+	return s.startProcess(process)
+}
+
+func (s *nativeJobSupervisor) StopProcess(name string) error {
+	s.opLock.Lock()
+	defer s.opLock.Unlock()
+
+	s.lock.Lock()
+	process, err := s.findProcess(name)
+	s.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.stopProcess(process)
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) Processes() ([]Process, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	processes := []Process{}
+
+	for _, process := range s.processes {
+		p := Process{
+			Name:     process.config.Name,
+			State:    process.state,
+			Restarts: process.restarts,
+		}
+
+		if process.state == nativeProcessRunning {
+			p.UptimeSeconds = int(time.Since(process.startedAt).Seconds())
+		}
+
+		processes = append(processes, p)
+	}
+
+	return processes, nil
+}
+
+// AddJob reads processes.json next to job's monit file;
+// additional monit files of the same job are ignored
+func (s *nativeJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	jobDir := filepath.Dir(configPath)
+	if s.jobDirs[jobDir] {
+		return nil
+	}
+
+	processesPath := filepath.Join(jobDir, "processes.json")
+	if !s.fs.FileExists(processesPath) {
+		s.logger.Debug(nativeJobSupervisorLogTag, "Job %s does not have processes to run", jobName)
+		return nil
+	}
+
+	content, err := s.fs.ReadFile(processesPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job processes from file")
+	}
+
+	processes, err := parseNativeProcesses(content)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing job processes")
+	}
+
+	s.jobs = append(s.jobs, nativeJob{name: jobName, processes: processes})
+	s.jobDirs[jobDir] = true
+	s.jobsAdded = true
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) RemoveAllJobs() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.jobs = nil
+	s.jobDirs = map[string]bool{}
+	s.jobsAdded = true
+
+	return nil
+}
+
+// MonitorJobFailures does not block since failures are reported
+// by goroutines that supervise processes. Agent starts monitoring
+// when it starts so installed jobs are restored at that point.
+func (s *nativeJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	s.lock.Lock()
+	s.handler = handler
+	restore := !s.jobsAdded
+	s.lock.Unlock()
+
+	if !restore {
+		return nil
+	}
+
+	err := s.restoreJobs()
+	if err != nil {
+		return bosherr.WrapError(err, "Restoring jobs")
+	}
+
+	return nil
+}
+
+// restoreJobs supervises processes of installed jobs since processes
+// do not outlive the agent; jobs that were stopped are not started
+func (s *nativeJobSupervisor) restoreJobs() error {
+	processesPaths, err := s.fs.Glob(filepath.Join(s.jobsDir, "*", "processes.json"))
+	if err != nil {
+		return bosherr.WrapError(err, "Listing installed jobs")
+	}
+
+	if len(processesPaths) == 0 {
+		return nil
+	}
+
+	for _, processesPath := range processesPaths {
+		jobDir := filepath.Dir(processesPath)
+
+		err = s.AddJob(filepath.Base(jobDir), 0, filepath.Join(jobDir, "monit"))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.Reload()
+	if err != nil {
+		return err
+	}
+
+	if s.fs.FileExists(s.stoppedPath) {
+		s.logger.Debug(nativeJobSupervisorLogTag, "Not starting restored jobs since they were stopped")
+		return nil
+	}
+
+	return s.Start()
+}
+
+// startProcess must be called with lock held
+func (s *nativeJobSupervisor) startProcess(process *nativeProcess) error {
+	process.monitored = true
+
+	if process.doneCh != nil {
+		return nil
+	}
+
+	err := s.openLogs(process)
+	if err != nil {
+		return bosherr.WrapError(err, "Opening logs of process %s", process.config.Name)
+	}
+
+	process.state = nativeProcessStarting
+	process.stopCh = make(chan struct{})
+	process.doneCh = make(chan struct{})
+
+	go s.supervise(process, process.stopCh, process.doneCh)
+
+	s.logger.Debug(nativeJobSupervisorLogTag, "Starting process %s", process.config.Name)
+
+	return nil
+}
+
+// findProcess must be called with lock held
+func (s *nativeJobSupervisor) findProcess(name string) (*nativeProcess, error) {
+	for _, process := range s.processes {
+		if process.config.Name == name {
+			return process, nil
+		}
+	}
+
+	return nil, bosherr.New("Process %s is not supervised", name)
+}
+
+func (s *nativeJobSupervisor) supervise(process *nativeProcess, stopCh, doneCh chan struct{}) {
+	defer s.finishSupervising(process, doneCh)
+
+	restartDelay := s.minRestartDelay
+
+	for {
+		s.lock.Lock()
+		config := process.config
+		stdout, stderr := process.stdout, process.stderr
+		s.lock.Unlock()
+
+		startedAt := time.Now()
+
+		exitStatus, stopped := s.run(process, config, stdout, stderr, stopCh)
+		if stopped {
+			s.setState(process, nativeProcessStopped)
+			return
+		}
+
+		s.lock.Lock()
+		monitored := process.monitored
+		restart := monitored && config.shouldRestart(exitStatus)
+		if restart {
+			process.state = nativeProcessStarting
+		} else {
+			process.state = nativeProcessFailing
+		}
+		s.lock.Unlock()
+
+		if !monitored {
+			return
+		}
+
+		if !restart {
+			s.reportFailure(config.Name, "alert", exitStatus)
+			return
+		}
+
+		s.reportFailure(config.Name, "restart", exitStatus)
+
+		s.lock.Lock()
+		process.restarts++
+		s.lock.Unlock()
+
+		// Process that ran for a while is not considered to be crashing repeatedly
+		if time.Since(startedAt) >= s.maxRestartDelay {
+			restartDelay = s.minRestartDelay
+		}
+
+		select {
+		case <-time.After(restartDelay):
+		case <-stopCh:
+			s.setState(process, nativeProcessStopped)
+			return
+		}
+
+		restartDelay *= 2
+		if restartDelay > s.maxRestartDelay {
+			restartDelay = s.maxRestartDelay
+		}
+
+		s.lock.Lock()
+		monitored = process.monitored
+		if !monitored {
+			process.state = nativeProcessFailing
+		}
+		s.lock.Unlock()
+
+		if !monitored {
+			return
+		}
+	}
+}
+
+// run returns true when process was stopped instead of exiting on its own
+func (s *nativeJobSupervisor) run(
+	process *nativeProcess,
+	config nativeProcessConfig,
+	stdout, stderr io.Writer,
+	stopCh chan struct{},
+) (int, bool) {
+	cmd := s.buildCommand(config)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	proc, err := s.runner.RunComplexCommandAsync(cmd)
+	if err != nil {
+		s.logger.Error(nativeJobSupervisorLogTag, "Failed to start process %s: %s", config.Name, err.Error())
+		return -1, false
+	}
+
+	s.lock.Lock()
+	process.state = nativeProcessRunning
+	process.startedAt = time.Now()
+	s.lock.Unlock()
+
+	waitCh := proc.Wait()
+
+	select {
+	case result := <-waitCh:
+		s.logger.Debug(nativeJobSupervisorLogTag, "Process %s exited with status %d", config.Name, result.ExitStatus)
+		return result.ExitStatus, false
+
+	case <-stopCh:
+		err = proc.TerminateNicely(config.stopTimeout())
+		if err != nil {
+			s.logger.Error(nativeJobSupervisorLogTag, "Failed to terminate process %s: %s", config.Name, err.Error())
+		}
+
+		<-waitCh
+		return 0, true
+	}
+}
+
+func (s *nativeJobSupervisor) finishSupervising(process *nativeProcess, doneCh chan struct{}) {
+	s.lock.Lock()
+	if process.doneCh == doneCh {
+		process.stopCh = nil
+		process.doneCh = nil
+	}
+	s.lock.Unlock()
+
+	close(doneCh)
+}
+
+// stopProcess waits for process to be terminated
+func (s *nativeJobSupervisor) stopProcess(process *nativeProcess) {
+	s.lock.Lock()
+	stopCh, doneCh := process.stopCh, process.doneCh
+	process.stopCh = nil
+	process.doneCh = nil
+	process.state = nativeProcessStopped
+	s.lock.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+
+	close(stopCh)
+	<-doneCh
+
+	s.logger.Debug(nativeJobSupervisorLogTag, "Stopped process %s", process.config.Name)
+}
+
+// buildCommand uses su to switch user since commands
+// cannot specify credentials of the process
+func (s *nativeJobSupervisor) buildCommand(config nativeProcessConfig) boshsys.Command {
+	if config.User == "" {
+		return boshsys.Command{
+			Name: config.Executable,
+			Args: config.Args,
+			Env:  config.Env,
+		}
+	}
+
+	args := []string{"-m", config.User, "-s", "/bin/sh", "-c", `exec "$0" "$@"`, config.Executable}
+
+	return boshsys.Command{
+		Name: "su",
+		Args: append(args, config.Args...),
+		Env:  config.Env,
+	}
+}
+
+// openLogs truncates logs of process that is not supervised yet;
+// logs stay open while process is being restarted
+func (s *nativeJobSupervisor) openLogs(process *nativeProcess) error {
+	if process.stdout != nil {
+		return nil
+	}
+
+	logPrefix := filepath.Join(s.logsDir, process.jobName, process.config.Name)
+
+	stdout, err := s.fs.CreateFile(logPrefix + ".stdout.log")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating stdout log")
+	}
+
+	stderr, err := s.fs.CreateFile(logPrefix + ".stderr.log")
+	if err != nil {
+		stdout.Close()
+		return bosherr.WrapError(err, "Creating stderr log")
+	}
+
+	process.stdout = stdout
+	process.stderr = stderr
+
+	return nil
+}
+
+func (s *nativeJobSupervisor) closeLogs(process *nativeProcess) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if process.stdout != nil {
+		process.stdout.Close()
+		process.stderr.Close()
+		process.stdout = nil
+		process.stderr = nil
+	}
+}
+
+func (s *nativeJobSupervisor) setState(process *nativeProcess, state string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	process.state = state
+}
+
+func (s *nativeJobSupervisor) reportFailure(processName, action string, exitStatus int) {
+	s.lock.Lock()
+	handler := s.handler
+	s.lock.Unlock()
+
+	if handler == nil {
+		s.logger.Debug(nativeJobSupervisorLogTag, "Not reporting failure of process %s", processName)
+		return
+	}
+
+	now := time.Now()
+
+	alert := boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s@localhost", now.Unix(), processName),
+		Service:     processName,
+		Event:       "does not exist",
+		Action:      action,
+		Date:        now.Format(time.RFC1123Z),
+		Description: fmt.Sprintf("process exited with status %d", exitStatus),
+	}
+
+	err := handler(alert)
+	if err != nil {
+		s.logger.Error(nativeJobSupervisorLogTag, "Failed to handle failure of process %s: %s", processName, err.Error())
+	}
+}
+
+func (p *nativeProcess) key() string {
+	return p.jobName + "/" + p.config.Name
+}
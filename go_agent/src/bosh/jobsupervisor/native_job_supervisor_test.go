@@ -0,0 +1,504 @@
+package jobsupervisor_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "bosh/agent/alert"
+	. "bosh/jobsupervisor"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("nativeJobSupervisor", func() {
+	var (
+		fs         *fakesys.FakeFileSystem
+		runner     *fakesys.FakeCmdRunner
+		supervisor JobSupervisor
+		alertsCh   chan boshalert.MonitAlert
+	)
+
+	newSupervisor := func() JobSupervisor {
+		return NewNativeJobSupervisor(
+			fs,
+			runner,
+			boshlog.NewLogger(boshlog.LevelNone),
+			"/fake-logs-dir",
+			"/fake-jobs",
+			"/fake-bosh-dir/native_jobs_stopped",
+			1*time.Millisecond,
+			10*time.Millisecond,
+		)
+	}
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+
+		supervisor = newSupervisor()
+
+		alertsCh = make(chan boshalert.MonitAlert, 10)
+
+		err := supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+			alertsCh <- alert
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	// Long running process exits only when it is terminated
+	newRunningProcess := func() *fakesys.FakeProcess {
+		return &fakesys.FakeProcess{
+			TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+				p.WaitCh <- boshsys.Result{ExitStatus: 143}
+			},
+		}
+	}
+
+	receiveAlert := func() boshalert.MonitAlert {
+		select {
+		case alert := <-alertsCh:
+			return alert
+		case <-time.After(1 * time.Second):
+			Fail("Expected alert to be reported")
+		}
+		return boshalert.MonitAlert{}
+	}
+
+	status := func() string { return supervisor.Status() }
+
+	addJob := func(jobName, processesJSON string) {
+		fs.WriteFileString("/fake-jobs/"+jobName+"/monit", "")
+		fs.WriteFileString("/fake-jobs/"+jobName+"/processes.json", processesJSON)
+
+		err := supervisor.AddJob(jobName, 0, "/fake-jobs/"+jobName+"/monit")
+		Expect(err).ToNot(HaveOccurred())
+	}
+
+	Describe("AddJob", func() {
+		It("returns error when processes cannot be parsed", func() {
+			fs.WriteFileString("/fake-jobs/fake-job/processes.json", "-")
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-jobs/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing job processes"))
+		})
+
+		It("returns error when process does not have executable", func() {
+			fs.WriteFileString("/fake-jobs/fake-job/processes.json", `{"processes":[{"name":"fake-process"}]}`)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-jobs/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-process does not have executable"))
+		})
+
+		It("returns error when process has unknown restart policy", func() {
+			fs.WriteFileString("/fake-jobs/fake-job/processes.json",
+				`{"processes":[{"name":"fake-process","executable":"/fake-exe","restart":"sometimes"}]}`)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-jobs/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unknown restart policy sometimes"))
+		})
+
+		It("ignores jobs without processes", func() {
+			err := supervisor.AddJob("fake-job", 0, "/fake-jobs/fake-job/monit")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("ignores additional monit files of the same job", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+
+			err := supervisor.AddJob("fake-job_extra", 0, "/fake-jobs/fake-job/extra.monit")
+			Expect(err).ToNot(HaveOccurred())
+
+			runner.AddProcess("/fake-exe", newRunningProcess())
+
+			err = supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			Expect(runner.RunComplexCommands).To(HaveLen(1))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Start", func() {
+		It("runs processes with their args and env", func() {
+			addJob("fake-job", `{"processes":[{
+				"name": "fake-process",
+				"executable": "/fake-exe",
+				"args": ["fake-arg1", "fake-arg2"],
+				"env": {"FAKE_ENV": "fake-env-value"}
+			}]}`)
+
+			runner.AddProcess("/fake-exe fake-arg1 fake-arg2", newRunningProcess())
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			cmd := runner.RunComplexCommands[0]
+			Expect(cmd.Name).To(Equal("/fake-exe"))
+			Expect(cmd.Args).To(Equal([]string{"fake-arg1", "fake-arg2"}))
+			Expect(cmd.Env).To(Equal(map[string]string{"FAKE_ENV": "fake-env-value"}))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("runs processes as specified user", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe","args":["fake-arg"],"user":"vcap"}]}`)
+
+			runner.AddProcess(`su -m vcap -s /bin/sh -c exec "$0" "$@" /fake-exe fake-arg`, newRunningProcess())
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("captures process output in job's log dir", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe","restart":"never"}]}`)
+
+			runner.AddProcess("/fake-exe", &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{Stdout: "fake-stdout", Stderr: "fake-stderr"},
+			})
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("failing"))
+
+			stdout := fs.GetFileTestStat("/fake-logs-dir/fake-job/fake-process.stdout.log")
+			Expect(stdout.StringContents()).To(Equal("fake-stdout"))
+
+			stderr := fs.GetFileTestStat("/fake-logs-dir/fake-job/fake-process.stderr.log")
+			Expect(stderr.StringContents()).To(Equal("fake-stderr"))
+		})
+
+		It("restarts processes that exit and reports their failures", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+
+			runner.AddProcess("/fake-exe", &fakesys.FakeProcess{WaitResult: boshsys.Result{ExitStatus: 1}})
+			runner.AddProcess("/fake-exe", newRunningProcess())
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			alert := receiveAlert()
+			Expect(alert.Service).To(Equal("fake-process"))
+			Expect(alert.Event).To(Equal("does not exist"))
+			Expect(alert.Action).To(Equal("restart"))
+			Expect(alert.Description).To(Equal("process exited with status 1"))
+
+			Eventually(status).Should(Equal("running"))
+			Expect(runner.RunComplexCommands).To(HaveLen(2))
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes[0].Restarts).To(Equal(1))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("does not restart processes that exit successfully when restart policy is on-failure", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe","restart":"on-failure"}]}`)
+
+			runner.AddProcess("/fake-exe", &fakesys.FakeProcess{WaitResult: boshsys.Result{ExitStatus: 0}})
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			alert := receiveAlert()
+			Expect(alert.Action).To(Equal("alert"))
+
+			Eventually(status).Should(Equal("failing"))
+			Expect(runner.RunComplexCommands).To(HaveLen(1))
+		})
+
+		It("starts processes again after they were stopped", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+
+			runner.AddProcess("/fake-exe", newRunningProcess())
+			runner.AddProcess("/fake-exe", newRunningProcess())
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(supervisor.Status()).To(Equal("failing"))
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			Expect(runner.RunComplexCommands).To(HaveLen(2))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("Stop", func() {
+		It("terminates processes with their stop timeout", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe","stop_timeout":30}]}`)
+
+			process := newRunningProcess()
+			runner.AddProcess("/fake-exe", process)
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(process.TerminatedNicely).To(BeTrue())
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(30 * time.Second))
+			Expect(supervisor.Status()).To(Equal("failing"))
+			Expect(alertsCh).To(BeEmpty())
+		})
+	})
+
+	Describe("Unmonitor", func() {
+		It("does not restart processes that exit", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+
+			process := &fakesys.FakeProcess{
+				// Exits when test says so
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {},
+			}
+			runner.AddProcess("/fake-exe", process)
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			err = supervisor.Unmonitor()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(supervisor.Status()).To(Equal("failing"))
+
+			Eventually(func() chan boshsys.Result { return process.WaitCh }).ShouldNot(BeNil())
+			process.WaitCh <- boshsys.Result{ExitStatus: 1}
+
+			time.Sleep(20 * time.Millisecond)
+			Expect(runner.RunComplexCommands).To(HaveLen(1))
+			Expect(alertsCh).To(BeEmpty())
+		})
+	})
+
+	Describe("StartProcess and StopProcess", func() {
+		BeforeEach(func() {
+			addJob("fake-job", `{"processes":[
+				{"name":"fake-process-1","executable":"/fake-exe-1"},
+				{"name":"fake-process-2","executable":"/fake-exe-2"}
+			]}`)
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("starts and stops only named process", func() {
+			process := newRunningProcess()
+			runner.AddProcess("/fake-exe-2", process)
+
+			err := supervisor.StartProcess("fake-process-2")
+			Expect(err).ToNot(HaveOccurred())
+
+			processes := func() []Process {
+				processes, err := supervisor.Processes()
+				Expect(err).ToNot(HaveOccurred())
+				return processes
+			}
+
+			Eventually(processes).Should(Equal([]Process{
+				{Name: "fake-process-1", State: "stopped"},
+				{Name: "fake-process-2", State: "running"},
+			}))
+
+			err = supervisor.StopProcess("fake-process-2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(process.TerminatedNicely).To(BeTrue())
+
+			Expect(processes()).To(Equal([]Process{
+				{Name: "fake-process-1", State: "stopped"},
+				{Name: "fake-process-2", State: "stopped"},
+			}))
+		})
+
+		It("returns error when process is not supervised", func() {
+			err := supervisor.StartProcess("fake-unknown-process")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-unknown-process is not supervised"))
+
+			err = supervisor.StopProcess("fake-unknown-process")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-unknown-process is not supervised"))
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		var (
+			restarted JobSupervisor
+		)
+
+		BeforeEach(func() {
+			fs.WriteFileString("/fake-jobs/fake-job/processes.json", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+			fs.SetGlob("/fake-jobs/*/processes.json", []string{"/fake-jobs/fake-job/processes.json"})
+
+			restarted = newSupervisor()
+		})
+
+		AfterEach(func() {
+			err := restarted.Stop()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("restores and starts installed jobs after agent restart", func() {
+			runner.AddProcess("/fake-exe", newRunningProcess())
+
+			err := restarted.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })
+			Expect(err).ToNot(HaveOccurred())
+
+			Eventually(restarted.Status).Should(Equal("running"))
+
+			processes, err := restarted.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(HaveLen(1))
+			Expect(processes[0].Name).To(Equal("fake-process"))
+			Expect(processes[0].State).To(Equal("running"))
+		})
+
+		It("restores but does not start jobs that were stopped before agent restart", func() {
+			err := supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = restarted.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })
+			Expect(err).ToNot(HaveOccurred())
+
+			processes, err := restarted.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(HaveLen(1))
+			Expect(processes[0].State).To(Equal("stopped"))
+
+			Expect(runner.RunComplexCommands).To(BeEmpty())
+		})
+
+		It("does not restore jobs that were added before monitoring started", func() {
+			err := restarted.RemoveAllJobs()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = restarted.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })
+			Expect(err).ToNot(HaveOccurred())
+
+			processes, err := restarted.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(BeEmpty())
+		})
+
+		It("returns error when installed jobs cannot be parsed", func() {
+			fs.WriteFileString("/fake-jobs/fake-job/processes.json", "-")
+
+			err := restarted.MonitorJobFailures(func(boshalert.MonitAlert) error { return nil })
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Restoring jobs"))
+		})
+	})
+
+	Describe("Reload", func() {
+		It("stops processes of jobs that were removed", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+
+			process := newRunningProcess()
+			runner.AddProcess("/fake-exe", process)
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			err = supervisor.RemoveAllJobs()
+			Expect(err).ToNot(HaveOccurred())
+
+			// Processes keep running until reload
+			Expect(process.TerminatedNicely).To(BeFalse())
+
+			err = supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(process.TerminatedNicely).To(BeTrue())
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("keeps processes of jobs that are still added running", func() {
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+
+			process := newRunningProcess()
+			runner.AddProcess("/fake-exe", process)
+
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			err = supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(status).Should(Equal("running"))
+
+			err = supervisor.RemoveAllJobs()
+			Expect(err).ToNot(HaveOccurred())
+
+			addJob("fake-job", `{"processes":[{"name":"fake-process","executable":"/fake-exe"}]}`)
+
+			err = supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(process.TerminatedNicely).To(BeFalse())
+			Expect(supervisor.Status()).To(Equal("running"))
+
+			err = supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+})
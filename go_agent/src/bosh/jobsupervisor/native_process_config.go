@@ -0,0 +1,103 @@
+package jobsupervisor
+
+import (
+	"encoding/json"
+	"time"
+
+	bosherr "bosh/errors"
+)
+
+const (
+	nativeRestartAlways    = "always"
+	nativeRestartOnFailure = "on-failure"
+	nativeRestartNever     = "never"
+
+	nativeDefaultStopTimeout = 10 * time.Second
+)
+
+// nativeProcessesConfig is read from processes.json in job's directory e.g.
+//
+//	{
+//	  "processes": [{
+//	    "name": "web",
+//	    "executable": "/var/vcap/jobs/web/bin/web",
+//	    "args": ["--port", "8080"],
+//	    "env": {"GOMAXPROCS": "2"},
+//	    "user": "vcap",
+//	    "restart": "on-failure",
+//	    "stop_timeout": 30
+//	  }]
+//	}
+type nativeProcessesConfig struct {
+	Processes []nativeProcessConfig `json:"processes"`
+}
+
+type nativeProcessConfig struct {
+	Name       string            `json:"name"`
+	Executable string            `json:"executable"`
+	Args       []string          `json:"args"`
+	Env        map[string]string `json:"env"`
+
+	// Process runs as agent's user when empty
+	User string `json:"user"`
+
+	// One of always (default), on-failure, never
+	Restart string `json:"restart"`
+
+	// Seconds to wait after SIGTERM before killing process
+	StopTimeoutSeconds int `json:"stop_timeout"`
+}
+
+func parseNativeProcesses(content []byte) ([]nativeProcessConfig, error) {
+	var config nativeProcessesConfig
+
+	err := json.Unmarshal(content, &config)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling processes")
+	}
+
+	names := map[string]bool{}
+
+	for i, process := range config.Processes {
+		if process.Name == "" {
+			return nil, bosherr.New("Process at index %d does not have name", i)
+		}
+
+		if names[process.Name] {
+			return nil, bosherr.New("Process %s is specified more than once", process.Name)
+		}
+		names[process.Name] = true
+
+		if process.Executable == "" {
+			return nil, bosherr.New("Process %s does not have executable", process.Name)
+		}
+
+		switch process.Restart {
+		case "":
+			config.Processes[i].Restart = nativeRestartAlways
+		case nativeRestartAlways, nativeRestartOnFailure, nativeRestartNever:
+		default:
+			return nil, bosherr.New("Process %s has unknown restart policy %s", process.Name, process.Restart)
+		}
+	}
+
+	return config.Processes, nil
+}
+
+func (c nativeProcessConfig) stopTimeout() time.Duration {
+	if c.StopTimeoutSeconds <= 0 {
+		return nativeDefaultStopTimeout
+	}
+	return time.Duration(c.StopTimeoutSeconds) * time.Second
+}
+
+func (c nativeProcessConfig) shouldRestart(exitStatus int) bool {
+	switch c.Restart {
+	case nativeRestartNever:
+		return false
+	case nativeRestartOnFailure:
+		return exitStatus != 0
+	default:
+		return true
+	}
+}
@@ -1,6 +1,7 @@
 package jobsupervisor
 
 import (
+	"path/filepath"
 	"time"
 
 	bosherr "bosh/errors"
@@ -11,6 +12,10 @@ import (
 	boshdir "bosh/settings/directories"
 )
 
+// MonitAlertPort is where monit delivers alerts over SMTP
+// (configured as mailserver in monitrc)
+const MonitAlertPort = 2825
+
 type provider struct {
 	supervisors map[string]JobSupervisor
 }
@@ -23,7 +28,9 @@ func NewProvider(
 	handler boshhandler.Handler,
 ) (p provider) {
 	p.supervisors = map[string]JobSupervisor{
-		"monit":      NewMonitJobSupervisor(platform.GetFs(), platform.GetRunner(), client, logger, dirProvider, 2825, 5*time.Second),
+		"monit":      NewMonitJobSupervisor(platform.GetFs(), platform.GetRunner(), client, logger, dirProvider, MonitAlertPort, 5*time.Second),
+		"systemd":    NewSystemdJobSupervisor(platform.GetFs(), platform.GetRunner(), logger, "/etc/systemd/system", "/run/systemd/system", 5*time.Second),
+		"native":     NewNativeJobSupervisor(platform.GetFs(), platform.GetRunner(), logger, filepath.Join(dirProvider.BaseDir(), "sys", "log"), dirProvider.JobsDir(), filepath.Join(dirProvider.BoshDir(), "native_jobs_stopped"), 1*time.Second, 60*time.Second),
 		"dummy":      newDummyJobSupervisor(),
 		"dummy-nats": NewDummyNatsJobSupervisor(handler),
 	}
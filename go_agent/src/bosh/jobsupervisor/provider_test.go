@@ -31,7 +31,7 @@ func init() {
 			client = fakemonit.NewFakeMonitClient()
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 			dirProvider = boshdir.NewDirectoriesProvider("/fake-base-dir")
-			jobFailuresServerPort = 2825
+			jobFailuresServerPort = MonitAlertPort
 			handler = &fakembus.FakeHandler{}
 
 			provider = NewProvider(
@@ -59,6 +59,38 @@ func init() {
 			Expect(actualSupervisor).To(Equal(expectedSupervisor))
 		})
 
+		It("provides a systemd job supervisor", func() {
+			actualSupervisor, err := provider.Get("systemd")
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedSupervisor := NewSystemdJobSupervisor(
+				platform.Fs,
+				platform.Runner,
+				logger,
+				"/etc/systemd/system",
+				"/run/systemd/system",
+				5*time.Second,
+			)
+			Expect(actualSupervisor).To(Equal(expectedSupervisor))
+		})
+
+		It("provides a native job supervisor", func() {
+			actualSupervisor, err := provider.Get("native")
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedSupervisor := NewNativeJobSupervisor(
+				platform.Fs,
+				platform.Runner,
+				logger,
+				"/fake-base-dir/sys/log",
+				"/fake-base-dir/jobs",
+				"/fake-base-dir/bosh/native_jobs_stopped",
+				1*time.Second,
+				60*time.Second,
+			)
+			Expect(actualSupervisor).To(Equal(expectedSupervisor))
+		})
+
 		It("provides a dummy job supervisor", func() {
 			actualSupervisor, err := provider.Get("dummy")
 			Expect(err).ToNot(HaveOccurred())
@@ -0,0 +1,524 @@
+package jobsupervisor
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	boshalert "bosh/agent/alert"
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const (
+	systemdJobSupervisorLogTag = "systemdJobSupervisor"
+
+	// Prefix distinguishes job units from other units (e.g. bosh-agent.service)
+	systemdUnitPrefix = "bosh-job-"
+
+	// Runtime drop-in that disables restarts until jobs are started again
+	systemdUnmonitorDropIn = "bosh-unmonitor.conf"
+)
+
+// systemdUnitState is reported by systemctl show
+type systemdUnitState struct {
+	// e.g. active, activating, failed
+	ActiveState string
+
+	// Number of automatic restarts since unit was started
+	NRestarts int
+}
+
+type systemdJobSupervisor struct {
+	fs     boshsys.FileSystem
+	runner boshsys.CmdRunner
+	logger boshlog.Logger
+
+	// Persistent unit files are generated here (e.g. /etc/systemd/system)
+	unitsDir string
+
+	// Drop-ins that should not survive reboot go here (e.g. /run/systemd/system)
+	runtimeUnitsDir string
+
+	stateCheckInterval time.Duration
+}
+
+func NewSystemdJobSupervisor(
+	fs boshsys.FileSystem,
+	runner boshsys.CmdRunner,
+	logger boshlog.Logger,
+	unitsDir string,
+	runtimeUnitsDir string,
+	stateCheckInterval time.Duration,
+) (s systemdJobSupervisor) {
+	return systemdJobSupervisor{
+		fs:                 fs,
+		runner:             runner,
+		logger:             logger,
+		unitsDir:           unitsDir,
+		runtimeUnitsDir:    runtimeUnitsDir,
+		stateCheckInterval: stateCheckInterval,
+	}
+}
+
+func (s systemdJobSupervisor) Reload() error {
+	_, _, _, err := s.runner.RunCommand("systemctl", "daemon-reload")
+	if err != nil {
+		return bosherr.WrapError(err, "Reloading systemd units")
+	}
+
+	return nil
+}
+
+// Start re-enables restarts of units that were unmonitored
+func (s systemdJobSupervisor) Start() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	var remonitored bool
+
+	for _, unit := range units {
+		if !s.isUnmonitored(unit) {
+			continue
+		}
+
+		err = s.fs.RemoveAll(s.unmonitorDropInPath(unit))
+		if err != nil {
+			return bosherr.WrapError(err, "Removing unmonitor drop-in for unit %s", unit)
+		}
+
+		remonitored = true
+	}
+
+	if remonitored {
+		err = s.Reload()
+		if err != nil {
+			return err
+		}
+	}
+
+	// Systemd orders units based on their dependencies
+	_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"start"}, units...)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting units")
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Started units %v", units)
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Stop() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"stop"}, units...)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping units")
+	}
+
+	s.logger.Debug(systemdJobSupervisorLogTag, "Stopped units %v", units)
+
+	return nil
+}
+
+// Unmonitor keeps processes running but prevents systemd from restarting them
+func (s systemdJobSupervisor) Unmonitor() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	for _, unit := range units {
+		err = s.fs.WriteFileString(s.unmonitorDropInPath(unit), "[Service]\nRestart=no\n")
+		if err != nil {
+			return bosherr.WrapError(err, "Writing unmonitor drop-in for unit %s", unit)
+		}
+	}
+
+	return s.Reload()
+}
+
+func (s systemdJobSupervisor) Status() string {
+	units, err := s.units()
+	if err != nil {
+		return "unknown"
+	}
+
+	states, err := s.unitStates(units)
+	if err != nil {
+		return "unknown"
+	}
+
+	status := "running"
+
+	for _, unit := range units {
+		switch {
+		case states[unit].ActiveState == "activating":
+			return "starting"
+		case s.isUnmonitored(unit) || states[unit].ActiveState != "active":
+			status = "failing"
+		}
+	}
+
+	return status
+}
+
+func (s systemdJobSupervisor) StartProcess(name string) error {
+	unit := s.unitName(name)
+
+	if s.isUnmonitored(unit) {
+		err := s.fs.RemoveAll(s.unmonitorDropInPath(unit))
+		if err != nil {
+			return bosherr.WrapError(err, "Removing unmonitor drop-in for unit %s", unit)
+		}
+
+		err = s.Reload()
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _, _, err := s.runner.RunCommand("systemctl", "start", unit)
+	if err != nil {
+		return bosherr.WrapError(err, "Starting unit %s", unit)
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) StopProcess(name string) error {
+	unit := s.unitName(name)
+
+	_, _, _, err := s.runner.RunCommand("systemctl", "stop", unit)
+	if err != nil {
+		return bosherr.WrapError(err, "Stopping unit %s", unit)
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) Processes() ([]Process, error) {
+	units, err := s.units()
+	if err != nil {
+		return nil, err
+	}
+
+	states, err := s.unitStates(units)
+	if err != nil {
+		return nil, err
+	}
+
+	processes := []Process{}
+
+	for _, unit := range units {
+		var state string
+
+		activeState := states[unit].ActiveState
+
+		switch {
+		case s.isUnmonitored(unit):
+			// Same as monit which reports unmonitored services as stopped
+			state = "stopped"
+		case activeState == "active":
+			state = "running"
+		case activeState == "activating" || activeState == "reloading":
+			state = "starting"
+		case activeState == "inactive" || activeState == "deactivating":
+			state = "stopped"
+		default:
+			state = "failing"
+		}
+
+		processes = append(processes, Process{Name: s.processName(unit), State: state})
+	}
+
+	return processes, nil
+}
+
+func (s systemdJobSupervisor) AddJob(jobName string, jobIndex int, configPath string) error {
+	config, err := s.fs.ReadFileString(configPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading job config from file")
+	}
+
+	processes, err := parseMonitProcesses(config)
+	if err != nil {
+		return bosherr.WrapError(err, "Parsing job config")
+	}
+
+	var units []string
+
+	for _, process := range processes {
+		unit := s.unitName(process.Name)
+
+		err = s.fs.WriteFileString(filepath.Join(s.unitsDir, unit), s.buildUnit(jobName, process))
+		if err != nil {
+			return bosherr.WrapError(err, "Writing unit file for process %s", process.Name)
+		}
+
+		units = append(units, unit)
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	// Enabled units are started by systemd on boot
+	// even before agent starts jobs
+	_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"enable"}, units...)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Enabling units")
+	}
+
+	return nil
+}
+
+func (s systemdJobSupervisor) RemoveAllJobs() error {
+	units, err := s.units()
+	if err != nil {
+		return err
+	}
+
+	if len(units) == 0 {
+		return nil
+	}
+
+	// Disabling needs unit files to find out what was enabled
+	_, _, _, err = s.runner.RunCommand("systemctl", append([]string{"disable"}, units...)...)
+	if err != nil {
+		return bosherr.WrapError(err, "Disabling units")
+	}
+
+	for _, unit := range units {
+		err = s.fs.RemoveAll(filepath.Join(s.unitsDir, unit))
+		if err != nil {
+			return bosherr.WrapError(err, "Removing unit file %s", unit)
+		}
+
+		err = s.fs.RemoveAll(filepath.Join(s.runtimeUnitsDir, unit+".d"))
+		if err != nil {
+			return bosherr.WrapError(err, "Removing drop-ins of unit %s", unit)
+		}
+	}
+
+	return nil
+}
+
+// MonitorJobFailures polls unit states since systemd restarts
+// failed processes on its own and does not notify anyone about it
+func (s systemdJobSupervisor) MonitorJobFailures(handler JobFailureHandler) error {
+	prevStates := map[string]systemdUnitState{}
+
+	for {
+		units, err := s.units()
+		if err != nil {
+			s.logger.Error(systemdJobSupervisorLogTag, "Failed to list units: %s", err.Error())
+		} else {
+			prevStates = s.checkUnitStates(units, prevStates, handler)
+		}
+
+		time.Sleep(s.stateCheckInterval)
+	}
+}
+
+// checkUnitStates compares restart counters instead of only active states
+// since units are restarted quickly (RestartSec) and restarts that happen
+// in between polls would go unnoticed
+func (s systemdJobSupervisor) checkUnitStates(units []string, prevStates map[string]systemdUnitState, handler JobFailureHandler) map[string]systemdUnitState {
+	states, err := s.unitStates(units)
+	if err != nil {
+		s.logger.Error(systemdJobSupervisorLogTag, "Failed to get unit states: %s", err.Error())
+		return prevStates
+	}
+
+	for _, unit := range units {
+		state := states[unit]
+
+		prevState, found := prevStates[unit]
+		if !found || s.isUnmonitored(unit) {
+			continue
+		}
+
+		var action string
+
+		switch {
+		case state.NRestarts > prevState.NRestarts:
+			action = "restart"
+		case prevState.ActiveState == "active" && state.ActiveState == "failed":
+			action = "alert"
+		default:
+			// Units that were stopped on purpose become inactive
+			continue
+		}
+
+		alert := s.buildAlert(unit, action)
+
+		err := handler(alert)
+		if err != nil {
+			s.logger.Error(systemdJobSupervisorLogTag, "Failed to handle failure of unit %s: %s", unit, err.Error())
+		}
+	}
+
+	return states
+}
+
+func (s systemdJobSupervisor) buildAlert(unit, action string) boshalert.MonitAlert {
+	now := time.Now()
+	processName := s.processName(unit)
+
+	return boshalert.MonitAlert{
+		ID:          fmt.Sprintf("%d.%s@localhost", now.Unix(), processName),
+		Service:     processName,
+		Event:       "does not exist",
+		Action:      action,
+		Date:        now.Format(time.RFC1123Z),
+		Description: "process is not running",
+	}
+}
+
+// unitStates returns active state and restart counter of each unit
+func (s systemdJobSupervisor) unitStates(units []string) (map[string]systemdUnitState, error) {
+	states := map[string]systemdUnitState{}
+
+	if len(units) == 0 {
+		return states, nil
+	}
+
+	args := append([]string{"show", "-p", "Id,ActiveState,NRestarts"}, units...)
+
+	stdout, _, _, err := s.runner.RunCommand("systemctl", args...)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Checking unit states")
+	}
+
+	// Properties of each unit are separated by an empty line
+	for _, section := range strings.Split(strings.TrimSpace(stdout), "\n\n") {
+		var unit string
+		var state systemdUnitState
+
+		for _, line := range strings.Split(section, "\n") {
+			parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+
+			switch parts[0] {
+			case "Id":
+				unit = parts[1]
+			case "ActiveState":
+				state.ActiveState = parts[1]
+			case "NRestarts":
+				state.NRestarts, err = strconv.Atoi(parts[1])
+				if err != nil {
+					return nil, bosherr.WrapError(err, "Parsing unit restarts %s", parts[1])
+				}
+			}
+		}
+
+		if unit != "" {
+			states[unit] = state
+		}
+	}
+
+	for _, unit := range units {
+		if _, found := states[unit]; !found {
+			return nil, bosherr.New("Expected state of unit %s but got '%s'", unit, stdout)
+		}
+	}
+
+	return states, nil
+}
+
+// units returns sorted names of job unit files
+func (s systemdJobSupervisor) units() ([]string, error) {
+	unitPaths, err := s.fs.Glob(filepath.Join(s.unitsDir, systemdUnitPrefix+"*.service"))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Listing unit files")
+	}
+
+	var units []string
+	for _, unitPath := range unitPaths {
+		units = append(units, filepath.Base(unitPath))
+	}
+
+	return units, nil
+}
+
+func (s systemdJobSupervisor) unitName(processName string) string {
+	return systemdUnitPrefix + processName + ".service"
+}
+
+func (s systemdJobSupervisor) processName(unit string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(unit, systemdUnitPrefix), ".service")
+}
+
+func (s systemdJobSupervisor) unmonitorDropInPath(unit string) string {
+	return filepath.Join(s.runtimeUnitsDir, unit+".d", systemdUnmonitorDropIn)
+}
+
+func (s systemdJobSupervisor) isUnmonitored(unit string) bool {
+	return s.fs.FileExists(s.unmonitorDropInPath(unit))
+}
+
+// buildUnit expects start program to daemonize process and write its pidfile
+// like monit does
+func (s systemdJobSupervisor) buildUnit(jobName string, process monitProcess) string {
+	buf := bytes.NewBufferString("")
+
+	fmt.Fprintf(buf, "[Unit]\n")
+	fmt.Fprintf(buf, "Description=BOSH job %s process %s\n", jobName, process.Name)
+
+	for _, dependency := range process.DependsOn {
+		unit := s.unitName(dependency)
+		fmt.Fprintf(buf, "Requires=%s\n", unit)
+		fmt.Fprintf(buf, "After=%s\n", unit)
+	}
+
+	fmt.Fprintf(buf, "\n[Service]\n")
+
+	if process.Pidfile != "" {
+		fmt.Fprintf(buf, "Type=forking\n")
+		fmt.Fprintf(buf, "PIDFile=%s\n", process.Pidfile)
+	} else {
+		fmt.Fprintf(buf, "Type=simple\n")
+	}
+
+	fmt.Fprintf(buf, "ExecStart=%s\n", process.StartProgram)
+
+	if process.StopProgram != "" {
+		fmt.Fprintf(buf, "ExecStop=%s\n", process.StopProgram)
+	}
+
+	if process.StartTimeout > 0 {
+		fmt.Fprintf(buf, "TimeoutStartSec=%d\n", process.StartTimeout)
+	}
+
+	fmt.Fprintf(buf, "Restart=always\n")
+	fmt.Fprintf(buf, "RestartSec=1\n")
+
+	fmt.Fprintf(buf, "\n[Install]\n")
+	fmt.Fprintf(buf, "WantedBy=multi-user.target\n")
+
+	return buf.String()
+}
@@ -0,0 +1,507 @@
+package jobsupervisor_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshalert "bosh/agent/alert"
+	. "bosh/jobsupervisor"
+	boshlog "bosh/logger"
+	fakesys "bosh/system/fakes"
+)
+
+var _ = Describe("systemdJobSupervisor", func() {
+	var (
+		fs         *fakesys.FakeFileSystem
+		runner     *fakesys.FakeCmdRunner
+		supervisor JobSupervisor
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		runner = fakesys.NewFakeCmdRunner()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+
+		supervisor = NewSystemdJobSupervisor(
+			fs,
+			runner,
+			logger,
+			"/fake-units-dir",
+			"/fake-runtime-units-dir",
+			1*time.Millisecond,
+		)
+	})
+
+	setUnits := func(units ...string) {
+		var paths []string
+		for _, unit := range units {
+			paths = append(paths, "/fake-units-dir/"+unit)
+		}
+		fs.SetGlob("/fake-units-dir/bosh-job-*.service", paths)
+	}
+
+	// unitsShow builds systemctl show output from unit, active state and restarts triples
+	unitsShow := func(unitStates ...string) string {
+		var sections []string
+		for i := 0; i < len(unitStates); i += 3 {
+			sections = append(sections, fmt.Sprintf(
+				"NRestarts=%s\nId=%s\nActiveState=%s\n",
+				unitStates[i+2], unitStates[i], unitStates[i+1],
+			))
+		}
+		return strings.Join(sections, "\n")
+	}
+
+	Describe("Reload", func() {
+		It("reloads systemd units", func() {
+			err := supervisor.Reload()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{{"systemctl", "daemon-reload"}}))
+		})
+
+		It("returns error when reloading fails", func() {
+			runner.AddCmdResult("systemctl daemon-reload", fakesys.FakeCmdResult{Error: errors.New("fake-reload-error")})
+
+			err := supervisor.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-reload-error"))
+		})
+	})
+
+	Describe("Start", func() {
+		BeforeEach(func() {
+			setUnits("bosh-job-fake-process-1.service", "bosh-job-fake-process-2.service")
+		})
+
+		It("starts all job units", func() {
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "start", "bosh-job-fake-process-1.service", "bosh-job-fake-process-2.service"},
+			}))
+		})
+
+		It("re-monitors unmonitored units before starting them", func() {
+			dropInPath := "/fake-runtime-units-dir/bosh-job-fake-process-1.service.d/bosh-unmonitor.conf"
+			fs.WriteFileString(dropInPath, "fake-drop-in")
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(dropInPath)).To(BeFalse())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "daemon-reload"},
+				{"systemctl", "start", "bosh-job-fake-process-1.service", "bosh-job-fake-process-2.service"},
+			}))
+		})
+
+		It("returns error when starting units fails", func() {
+			runner.AddCmdResult(
+				"systemctl start bosh-job-fake-process-1.service bosh-job-fake-process-2.service",
+				fakesys.FakeCmdResult{Error: errors.New("fake-start-error")},
+			)
+
+			err := supervisor.Start()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-error"))
+		})
+
+		It("does nothing when there are no job units", func() {
+			setUnits()
+
+			err := supervisor.Start()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(BeEmpty())
+		})
+	})
+
+	Describe("Stop", func() {
+		It("stops all job units without re-monitoring them", func() {
+			setUnits("bosh-job-fake-process.service")
+
+			dropInPath := "/fake-runtime-units-dir/bosh-job-fake-process.service.d/bosh-unmonitor.conf"
+			fs.WriteFileString(dropInPath, "fake-drop-in")
+
+			err := supervisor.Stop()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "stop", "bosh-job-fake-process.service"},
+			}))
+			Expect(fs.FileExists(dropInPath)).To(BeTrue())
+		})
+	})
+
+	Describe("Unmonitor", func() {
+		It("disables restarts of all job units", func() {
+			setUnits("bosh-job-fake-process.service")
+
+			err := supervisor.Unmonitor()
+			Expect(err).ToNot(HaveOccurred())
+
+			dropIn := fs.GetFileTestStat("/fake-runtime-units-dir/bosh-job-fake-process.service.d/bosh-unmonitor.conf")
+			Expect(dropIn.StringContents()).To(Equal("[Service]\nRestart=no\n"))
+
+			Expect(runner.RunCommands).To(Equal([][]string{{"systemctl", "daemon-reload"}}))
+		})
+	})
+
+	Describe("Status", func() {
+		BeforeEach(func() {
+			setUnits("bosh-job-fake-process-1.service", "bosh-job-fake-process-2.service")
+		})
+
+		showCmd := "systemctl show -p Id,ActiveState,NRestarts bosh-job-fake-process-1.service bosh-job-fake-process-2.service"
+
+		It("returns running when all units are active", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow(
+				"bosh-job-fake-process-1.service", "active", "0",
+				"bosh-job-fake-process-2.service", "active", "0",
+			)})
+			Expect(supervisor.Status()).To(Equal("running"))
+		})
+
+		It("returns starting when any unit is activating", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow(
+				"bosh-job-fake-process-1.service", "active", "0",
+				"bosh-job-fake-process-2.service", "activating", "0",
+			)})
+			Expect(supervisor.Status()).To(Equal("starting"))
+		})
+
+		It("returns failing when any unit is not active", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow(
+				"bosh-job-fake-process-1.service", "failed", "0",
+				"bosh-job-fake-process-2.service", "active", "0",
+			)})
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("returns failing when any unit is unmonitored", func() {
+			fs.WriteFileString("/fake-runtime-units-dir/bosh-job-fake-process-2.service.d/bosh-unmonitor.conf", "")
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow(
+				"bosh-job-fake-process-1.service", "active", "0",
+				"bosh-job-fake-process-2.service", "active", "0",
+			)})
+			Expect(supervisor.Status()).To(Equal("failing"))
+		})
+
+		It("returns unknown when unit states cannot be determined", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Error: errors.New("fake-run-error")})
+			Expect(supervisor.Status()).To(Equal("unknown"))
+		})
+
+		It("returns unknown when state of any unit is missing", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow(
+				"bosh-job-fake-process-1.service", "active", "0",
+			)})
+			Expect(supervisor.Status()).To(Equal("unknown"))
+		})
+	})
+
+	Describe("StartProcess", func() {
+		It("starts process unit", func() {
+			err := supervisor.StartProcess("fake-process")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "start", "bosh-job-fake-process.service"},
+			}))
+		})
+
+		It("re-monitors process unit before starting it", func() {
+			dropInPath := "/fake-runtime-units-dir/bosh-job-fake-process.service.d/bosh-unmonitor.conf"
+			fs.WriteFileString(dropInPath, "fake-drop-in")
+
+			err := supervisor.StartProcess("fake-process")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists(dropInPath)).To(BeFalse())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "daemon-reload"},
+				{"systemctl", "start", "bosh-job-fake-process.service"},
+			}))
+		})
+
+		It("returns error when starting unit fails", func() {
+			runner.AddCmdResult("systemctl start bosh-job-fake-process.service", fakesys.FakeCmdResult{Error: errors.New("fake-start-error")})
+
+			err := supervisor.StartProcess("fake-process")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-start-error"))
+		})
+	})
+
+	Describe("StopProcess", func() {
+		It("stops process unit", func() {
+			err := supervisor.StopProcess("fake-process")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "stop", "bosh-job-fake-process.service"},
+			}))
+		})
+	})
+
+	Describe("Processes", func() {
+		It("returns state of each process unit", func() {
+			setUnits(
+				"bosh-job-fake-process-1.service",
+				"bosh-job-fake-process-2.service",
+				"bosh-job-fake-process-3.service",
+				"bosh-job-fake-process-4.service",
+			)
+
+			runner.AddCmdResult(
+				"systemctl show -p Id,ActiveState,NRestarts bosh-job-fake-process-1.service bosh-job-fake-process-2.service bosh-job-fake-process-3.service bosh-job-fake-process-4.service",
+				fakesys.FakeCmdResult{Stdout: unitsShow(
+					"bosh-job-fake-process-1.service", "active", "0",
+					"bosh-job-fake-process-2.service", "activating", "1",
+					"bosh-job-fake-process-3.service", "inactive", "0",
+					"bosh-job-fake-process-4.service", "failed", "5",
+				)},
+			)
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				{Name: "fake-process-1", State: "running"},
+				{Name: "fake-process-2", State: "starting"},
+				{Name: "fake-process-3", State: "stopped"},
+				{Name: "fake-process-4", State: "failing"},
+			}))
+		})
+
+		It("returns stopped state for unmonitored process units", func() {
+			setUnits("bosh-job-fake-process-1.service", "bosh-job-fake-process-2.service")
+
+			fs.WriteFileString("/fake-runtime-units-dir/bosh-job-fake-process-2.service.d/bosh-unmonitor.conf", "")
+
+			runner.AddCmdResult(
+				"systemctl show -p Id,ActiveState,NRestarts bosh-job-fake-process-1.service bosh-job-fake-process-2.service",
+				fakesys.FakeCmdResult{Stdout: unitsShow(
+					"bosh-job-fake-process-1.service", "active", "0",
+					"bosh-job-fake-process-2.service", "active", "0",
+				)},
+			)
+
+			processes, err := supervisor.Processes()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(processes).To(Equal([]Process{
+				{Name: "fake-process-1", State: "running"},
+				{Name: "fake-process-2", State: "stopped"},
+			}))
+		})
+	})
+
+	Describe("AddJob", func() {
+		It("generates unit file for each process in monit file", func() {
+			fs.WriteFileString("/fake-job/monit", `
+check process fake-process-1
+  with pidfile /var/vcap/sys/run/fake-job/fake-process-1.pid
+  start program "/var/vcap/jobs/fake-job/bin/ctl start" with timeout 60 seconds
+  stop program "/var/vcap/jobs/fake-job/bin/ctl stop"
+  group vcap
+
+check process fake-process-2
+  start program "/var/vcap/jobs/fake-job/bin/ctl2 start"
+  depends on fake-process-1
+  group vcap
+
+check file fake-file with path /var/vcap/fake-file
+  if changed checksum then alert
+`)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).ToNot(HaveOccurred())
+
+			unit1 := fs.GetFileTestStat("/fake-units-dir/bosh-job-fake-process-1.service")
+			Expect(unit1.StringContents()).To(Equal(`[Unit]
+Description=BOSH job fake-job process fake-process-1
+
+[Service]
+Type=forking
+PIDFile=/var/vcap/sys/run/fake-job/fake-process-1.pid
+ExecStart=/var/vcap/jobs/fake-job/bin/ctl start
+ExecStop=/var/vcap/jobs/fake-job/bin/ctl stop
+TimeoutStartSec=60
+Restart=always
+RestartSec=1
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+			unit2 := fs.GetFileTestStat("/fake-units-dir/bosh-job-fake-process-2.service")
+			Expect(unit2.StringContents()).To(Equal(`[Unit]
+Description=BOSH job fake-job process fake-process-2
+Requires=bosh-job-fake-process-1.service
+After=bosh-job-fake-process-1.service
+
+[Service]
+Type=simple
+ExecStart=/var/vcap/jobs/fake-job/bin/ctl2 start
+Restart=always
+RestartSec=1
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+			Expect(fs.FileExists("/fake-units-dir/bosh-job-fake-file.service")).To(BeFalse())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "enable", "bosh-job-fake-process-1.service", "bosh-job-fake-process-2.service"},
+			}))
+		})
+
+		It("returns error when enabling units fails", func() {
+			fs.WriteFileString("/fake-job/monit", "check process fake-process\n  start program \"/fake-start\"\n")
+
+			runner.AddCmdResult(
+				"systemctl enable bosh-job-fake-process.service",
+				fakesys.FakeCmdResult{Error: errors.New("fake-enable-error")},
+			)
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-enable-error"))
+		})
+
+		It("returns error when process does not have start program", func() {
+			fs.WriteFileString("/fake-job/monit", "check process fake-process\n  group vcap\n")
+
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Process fake-process does not have start program"))
+		})
+
+		It("returns error when monit file cannot be read", func() {
+			err := supervisor.AddJob("fake-job", 0, "/fake-job/monit")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading job config"))
+		})
+	})
+
+	Describe("RemoveAllJobs", func() {
+		It("removes job unit files and their drop-ins", func() {
+			setUnits("bosh-job-fake-process.service")
+
+			fs.WriteFileString("/fake-units-dir/bosh-job-fake-process.service", "fake-unit")
+			fs.WriteFileString("/fake-units-dir/other.service", "fake-other-unit")
+			fs.WriteFileString("/fake-runtime-units-dir/bosh-job-fake-process.service.d/bosh-unmonitor.conf", "")
+
+			err := supervisor.RemoveAllJobs()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fs.FileExists("/fake-units-dir/bosh-job-fake-process.service")).To(BeFalse())
+			Expect(fs.FileExists("/fake-runtime-units-dir/bosh-job-fake-process.service.d/bosh-unmonitor.conf")).To(BeFalse())
+			Expect(fs.FileExists("/fake-units-dir/other.service")).To(BeTrue())
+
+			Expect(runner.RunCommands).To(Equal([][]string{
+				{"systemctl", "disable", "bosh-job-fake-process.service"},
+			}))
+		})
+
+		It("returns error without removing unit files when disabling units fails", func() {
+			setUnits("bosh-job-fake-process.service")
+
+			fs.WriteFileString("/fake-units-dir/bosh-job-fake-process.service", "fake-unit")
+
+			runner.AddCmdResult(
+				"systemctl disable bosh-job-fake-process.service",
+				fakesys.FakeCmdResult{Error: errors.New("fake-disable-error")},
+			)
+
+			err := supervisor.RemoveAllJobs()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-disable-error"))
+
+			Expect(fs.FileExists("/fake-units-dir/bosh-job-fake-process.service")).To(BeTrue())
+		})
+	})
+
+	Describe("MonitorJobFailures", func() {
+		showCmd := "systemctl show -p Id,ActiveState,NRestarts bosh-job-fake-process.service"
+
+		var alertsCh chan boshalert.MonitAlert
+
+		BeforeEach(func() {
+			setUnits("bosh-job-fake-process.service")
+			alertsCh = make(chan boshalert.MonitAlert, 10)
+		})
+
+		monitorJobFailures := func() {
+			go supervisor.MonitorJobFailures(func(alert boshalert.MonitAlert) error {
+				alertsCh <- alert
+				return nil
+			})
+		}
+
+		receiveAlert := func() boshalert.MonitAlert {
+			var alert boshalert.MonitAlert
+			select {
+			case alert = <-alertsCh:
+			case <-time.After(1 * time.Second):
+				Fail("Expected alert to be reported")
+			}
+			return alert
+		}
+
+		It("reports units that were restarted in between polls", func() {
+			// Unit is active again by the time of the next poll
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "active", "0")})
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "active", "1"), Sticky: true})
+
+			monitorJobFailures()
+
+			alert := receiveAlert()
+			Expect(alert.Service).To(Equal("fake-process"))
+			Expect(alert.Event).To(Equal("does not exist"))
+			Expect(alert.Action).To(Equal("restart"))
+			Expect(alert.Description).To(Equal("process is not running"))
+
+			// Only changes are reported
+			time.Sleep(20 * time.Millisecond)
+			Expect(alertsCh).To(BeEmpty())
+		})
+
+		It("reports units that failed", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "active", "3")})
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "failed", "3"), Sticky: true})
+
+			monitorJobFailures()
+
+			alert := receiveAlert()
+			Expect(alert.Service).To(Equal("fake-process"))
+			Expect(alert.Action).To(Equal("alert"))
+
+			time.Sleep(20 * time.Millisecond)
+			Expect(alertsCh).To(BeEmpty())
+		})
+
+		It("does not report units that were stopped on purpose", func() {
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "active", "0")})
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "inactive", "0"), Sticky: true})
+
+			monitorJobFailures()
+
+			time.Sleep(20 * time.Millisecond)
+			Expect(alertsCh).To(BeEmpty())
+		})
+
+		It("does not report restarts of unmonitored units", func() {
+			fs.WriteFileString("/fake-runtime-units-dir/bosh-job-fake-process.service.d/bosh-unmonitor.conf", "")
+
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "active", "0")})
+			runner.AddCmdResult(showCmd, fakesys.FakeCmdResult{Stdout: unitsShow("bosh-job-fake-process.service", "active", "1"), Sticky: true})
+
+			monitorJobFailures()
+
+			time.Sleep(20 * time.Millisecond)
+			Expect(alertsCh).To(BeEmpty())
+		})
+	})
+})
@@ -8,6 +8,7 @@ import (
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
 	boshlog "bosh/logger"
+	boshmetrics "bosh/metrics"
 	"bosh/micro"
 	boshplatform "bosh/platform"
 	boshsettings "bosh/settings"
@@ -17,12 +18,18 @@ import (
 type MbusHandlerProvider struct {
 	settings boshsettings.Service
 	logger   boshlog.Logger
+	metrics  boshmetrics.Registry
 	handler  boshhandler.Handler
 }
 
-func NewHandlerProvider(settings boshsettings.Service, logger boshlog.Logger) (p MbusHandlerProvider) {
+func NewHandlerProvider(
+	settings boshsettings.Service,
+	logger boshlog.Logger,
+	metrics boshmetrics.Registry,
+) (p MbusHandlerProvider) {
 	p.settings = settings
 	p.logger = logger
+	p.metrics = metrics
 	return
 }
 
@@ -43,7 +50,7 @@ func (p MbusHandlerProvider) Get(
 
 	switch mbusURL.Scheme {
 	case "nats":
-		handler = NewNatsHandler(p.settings, p.logger, yagnats.NewClient())
+		handler = NewNatsHandler(p.settings, p.logger, yagnats.NewClient(), p.metrics)
 	case "https":
 		handler = micro.NewHTTPSHandler(mbusURL, p.logger, platform.GetFs(), dirProvider)
 	default:
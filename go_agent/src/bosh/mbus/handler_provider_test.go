@@ -8,6 +8,7 @@ import (
 
 	boshlog "bosh/logger"
 	. "bosh/mbus"
+	fakemetrics "bosh/metrics/fakes"
 	"bosh/micro"
 	fakeplatform "bosh/platform/fakes"
 	boshdir "bosh/settings/directories"
@@ -19,12 +20,14 @@ type providerDeps struct {
 	platform    *fakeplatform.FakePlatform
 	dirProvider boshdir.DirectoriesProvider
 	logger      boshlog.Logger
+	metrics     *fakemetrics.FakeRegistry
 }
 
 func buildProvider(mbusURL string) (deps providerDeps, provider MbusHandlerProvider) {
 	deps.settings = &fakesettings.FakeSettingsService{MbusURL: mbusURL}
 	deps.logger = boshlog.NewLogger(boshlog.LevelNone)
-	provider = NewHandlerProvider(deps.settings, deps.logger)
+	deps.metrics = fakemetrics.NewFakeRegistry()
+	provider = NewHandlerProvider(deps.settings, deps.logger, deps.metrics)
 
 	deps.platform = fakeplatform.NewFakePlatform()
 	deps.dirProvider = boshdir.NewDirectoriesProvider("/var/vcap")
@@ -37,7 +40,7 @@ func init() {
 			handler, err := provider.Get(deps.platform, deps.dirProvider)
 
 			Expect(err).ToNot(HaveOccurred())
-			assert.IsType(GinkgoT(), NewNatsHandler(deps.settings, deps.logger, yagnats.NewClient()), handler)
+			assert.IsType(GinkgoT(), NewNatsHandler(deps.settings, deps.logger, yagnats.NewClient(), deps.metrics), handler)
 		})
 		It("handler provider get returns https handler", func() {
 
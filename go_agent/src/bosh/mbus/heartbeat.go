@@ -1,14 +1,16 @@
 package mbus
 
 import (
+	boshjobsuper "bosh/jobsupervisor"
 	boshvitals "bosh/platform/vitals"
 )
 
 type Heartbeat struct {
-	Job      *string           `json:"job"`
-	Index    *int              `json:"index"`
-	JobState string            `json:"job_state"`
-	Vitals   boshvitals.Vitals `json:"vitals"`
+	Job       *string                `json:"job"`
+	Index     *int                   `json:"index"`
+	JobState  string                 `json:"job_state"`
+	Vitals    boshvitals.Vitals      `json:"vitals"`
+	Processes []boshjobsuper.Process `json:"processes,omitempty"`
 }
 
 //Heartbeat payload example:
@@ -29,5 +31,15 @@ type Heartbeat struct {
 //  "ntp": {
 //      "offset": "-0.06423",
 //      "timestamp": "14 Oct 11:13:19"
-//  }
+//  },
+//  "processes": [{
+//    "name": "cloud_controller_ng",
+//    "state": "running",
+//    "pid": 1234,
+//    "uptime": 3600,
+//    "children": 2,
+//    "mem": {"kb": 145996, "percent": 3.5},
+//    "cpu": {"total": 0.4},
+//    "restarts": 0
+//  }]
 //}
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	boshjobsuper "bosh/jobsupervisor"
 	. "bosh/mbus"
 	boshvitals "bosh/platform/vitals"
 )
@@ -38,6 +39,34 @@ func init() {
 			})
 		})
 
+		Context("when processes are available", func() {
+			It("serializes processes", func() {
+				hb := Heartbeat{
+					JobState: "running",
+					Processes: []boshjobsuper.Process{
+						{
+							Name:          "fake-process",
+							State:         "running",
+							Pid:           1234,
+							UptimeSeconds: 600,
+							Children:      2,
+							Memory:        boshjobsuper.ProcessMemory{Kb: 4004, Percent: 0.5},
+							CPU:           boshjobsuper.ProcessCPU{Total: 1.5},
+							Restarts:      3,
+						},
+					},
+				}
+
+				expectedJSON := `{"job":null,"index":null,"job_state":"running","vitals":{"cpu":{},"mem":{},"swap":{}},` +
+					`"processes":[{"name":"fake-process","state":"running","pid":1234,"uptime":600,"children":2,` +
+					`"mem":{"kb":4004,"percent":0.5},"cpu":{"total":1.5},"restarts":3}]}`
+
+				hbBytes, err := json.Marshal(hb)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(hbBytes)).To(Equal(expectedJSON))
+			})
+		})
+
 		Context("when job name, index are not available", func() {
 			It("serializes job name and index as nulls to indicate that there is no job assigned to this agent", func() {
 				hb := Heartbeat{
@@ -13,6 +13,7 @@ import (
 	bosherr "bosh/errors"
 	boshhandler "bosh/handler"
 	boshlog "bosh/logger"
+	boshmetrics "bosh/metrics"
 	boshsettings "bosh/settings"
 )
 
@@ -27,14 +28,21 @@ type natsHandler struct {
 	settings     boshsettings.Service
 	logger       boshlog.Logger
 	client       yagnats.NATSClient
+	metrics      boshmetrics.Registry
 	handlerFuncs []boshhandler.HandlerFunc
 }
 
-func NewNatsHandler(settings boshsettings.Service, logger boshlog.Logger, client yagnats.NATSClient) *natsHandler {
+func NewNatsHandler(
+	settings boshsettings.Service,
+	logger boshlog.Logger,
+	client yagnats.NATSClient,
+	metrics boshmetrics.Registry,
+) *natsHandler {
 	return &natsHandler{
 		settings: settings,
 		logger:   logger,
 		client:   client,
+		metrics:  metrics,
 	}
 }
 
@@ -63,6 +71,8 @@ func (h *natsHandler) Start(handlerFunc boshhandler.HandlerFunc) error {
 		return bosherr.WrapError(err, "Connecting")
 	}
 
+	h.metrics.SetNatsConnectionCheck(h.client.Ping)
+
 	subject := fmt.Sprintf("agent.%s", h.settings.GetAgentID())
 
 	h.logger.Error(natsHandlerLogTag, "Subscribing to %s", subject)
@@ -101,6 +111,7 @@ func (h natsHandler) SendToHealthManager(topic string, payload interface{}) erro
 
 func (h natsHandler) Stop() {
 	h.client.Disconnect()
+	h.metrics.SetNatsConnectionCheck(nil)
 }
 
 func (h natsHandler) handleNatsMsg(natsMsg *yagnats.Message, handlerFunc boshhandler.HandlerFunc) {
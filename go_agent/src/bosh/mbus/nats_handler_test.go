@@ -12,6 +12,7 @@ import (
 	boshhandler "bosh/handler"
 	boshlog "bosh/logger"
 	. "bosh/mbus"
+	fakemetrics "bosh/metrics/fakes"
 	fakesettings "bosh/settings/fakes"
 )
 
@@ -20,6 +21,7 @@ func init() {
 		var (
 			client  *fakeyagnats.FakeYagnats
 			logger  boshlog.Logger
+			metrics *fakemetrics.FakeRegistry
 			handler boshhandler.Handler
 		)
 
@@ -30,7 +32,8 @@ func init() {
 			}
 			logger = boshlog.NewLogger(boshlog.LevelNone)
 			client = fakeyagnats.New()
-			handler = NewNatsHandler(settings, logger, client)
+			metrics = fakemetrics.NewFakeRegistry()
+			handler = NewNatsHandler(settings, logger, client, metrics)
 		})
 
 		Describe("Start", func() {
@@ -44,6 +47,7 @@ func init() {
 				defer handler.Stop()
 
 				Expect(client.ConnectedConnectionProvider).ToNot(BeNil())
+				Expect(metrics.NatsConnectionCheck()).To(BeTrue())
 
 				Expect(len(client.Subscriptions)).To(Equal(1))
 				subscriptions := client.Subscriptions["agent.my-agent-id"]
@@ -183,16 +187,27 @@ func init() {
 
 			It("does not err when no username and password", func() {
 				settings := &fakesettings.FakeSettingsService{MbusURL: "nats://127.0.0.1:1234"}
-				handler = NewNatsHandler(settings, logger, client)
+				handler = NewNatsHandler(settings, logger, client, metrics)
 
 				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
 				Expect(err).ToNot(HaveOccurred())
 				defer handler.Stop()
 			})
 
+			It("reports connection state by pinging NATS server", func() {
+				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
+				Expect(err).ToNot(HaveOccurred())
+				defer handler.Stop()
+
+				Expect(metrics.NatsConnectionCheck()).To(BeTrue())
+
+				client.PingResponse = false
+				Expect(metrics.NatsConnectionCheck()).To(BeFalse())
+			})
+
 			It("errs when has username without password", func() {
 				settings := &fakesettings.FakeSettingsService{MbusURL: "nats://foo@127.0.0.1:1234"}
-				handler = NewNatsHandler(settings, logger, client)
+				handler = NewNatsHandler(settings, logger, client, metrics)
 
 				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
 				Expect(err).To(HaveOccurred())
@@ -200,6 +215,17 @@ func init() {
 			})
 		})
 
+		Describe("Stop", func() {
+			It("records that agent is no longer connected", func() {
+				err := handler.Start(func(req boshhandler.Request) (res boshhandler.Response) { return })
+				Expect(err).ToNot(HaveOccurred())
+				Expect(metrics.NatsConnectionCheck).ToNot(BeNil())
+
+				handler.Stop()
+				Expect(metrics.NatsConnectionCheck).To(BeNil())
+			})
+		})
+
 		Describe("SendToHealthManager", func() {
 			It("sends periodic heartbeats", func() {
 				errChan := make(chan error, 1)
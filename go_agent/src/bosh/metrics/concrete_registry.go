@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+type concreteRegistry struct {
+	lock sync.Mutex
+
+	actions        map[string]ActionStats
+	taskQueueDepth int
+	blobstoreBytes map[string]int64
+
+	natsConnectionCheck func() bool
+}
+
+func NewRegistry() Registry {
+	return &concreteRegistry{
+		actions:        map[string]ActionStats{},
+		blobstoreBytes: map[string]int64{},
+	}
+}
+
+func (r *concreteRegistry) RecordAction(method string, duration time.Duration, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	stats := r.actions[method]
+
+	if err != nil {
+		stats.Failed++
+	} else {
+		stats.Succeeded++
+	}
+
+	stats.Duration += duration
+
+	r.actions[method] = stats
+}
+
+func (r *concreteRegistry) TaskQueued() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.taskQueueDepth++
+}
+
+func (r *concreteRegistry) TaskFinished() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.taskQueueDepth > 0 {
+		r.taskQueueDepth--
+	}
+}
+
+func (r *concreteRegistry) SetNatsConnectionCheck(check func() bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.natsConnectionCheck = check
+}
+
+func (r *concreteRegistry) AddBlobstoreBytes(direction string, bytes int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.blobstoreBytes[direction] += bytes
+}
+
+func (r *concreteRegistry) Snapshot() Snapshot {
+	// Check might wait for NATS server so it is run without holding the lock
+	natsConnected := r.checkNatsConnection()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	snapshot := Snapshot{
+		Actions:        make(map[string]ActionStats, len(r.actions)),
+		TaskQueueDepth: r.taskQueueDepth,
+		NatsConnected:  natsConnected,
+		BlobstoreBytes: make(map[string]int64, len(r.blobstoreBytes)),
+	}
+
+	for method, stats := range r.actions {
+		snapshot.Actions[method] = stats
+	}
+
+	for direction, bytes := range r.blobstoreBytes {
+		snapshot.BlobstoreBytes[direction] = bytes
+	}
+
+	return snapshot
+}
+
+func (r *concreteRegistry) checkNatsConnection() bool {
+	r.lock.Lock()
+	check := r.natsConnectionCheck
+	r.lock.Unlock()
+
+	if check == nil {
+		return false
+	}
+
+	return check()
+}
@@ -0,0 +1,89 @@
+package metrics_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/metrics"
+)
+
+var _ = Describe("concreteRegistry", func() {
+	var (
+		registry Registry
+	)
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+	})
+
+	Describe("RecordAction", func() {
+		It("counts successful and failed runs and total duration per method", func() {
+			registry.RecordAction("apply", 2*time.Second, nil)
+			registry.RecordAction("apply", 3*time.Second, errors.New("fake-err"))
+			registry.RecordAction("ping", time.Millisecond, nil)
+
+			Expect(registry.Snapshot().Actions).To(Equal(map[string]ActionStats{
+				"apply": ActionStats{Succeeded: 1, Failed: 1, Duration: 5 * time.Second},
+				"ping":  ActionStats{Succeeded: 1, Duration: time.Millisecond},
+			}))
+		})
+	})
+
+	Describe("TaskQueued and TaskFinished", func() {
+		It("keeps track of task queue depth", func() {
+			registry.TaskQueued()
+			registry.TaskQueued()
+			Expect(registry.Snapshot().TaskQueueDepth).To(Equal(2))
+
+			registry.TaskFinished()
+			Expect(registry.Snapshot().TaskQueueDepth).To(Equal(1))
+		})
+
+		It("does not go below zero", func() {
+			registry.TaskFinished()
+			Expect(registry.Snapshot().TaskQueueDepth).To(Equal(0))
+		})
+	})
+
+	Describe("SetNatsConnectionCheck", func() {
+		It("checks connection state on every snapshot", func() {
+			Expect(registry.Snapshot().NatsConnected).To(BeFalse())
+
+			connected := true
+			registry.SetNatsConnectionCheck(func() bool { return connected })
+			Expect(registry.Snapshot().NatsConnected).To(BeTrue())
+
+			connected = false
+			Expect(registry.Snapshot().NatsConnected).To(BeFalse())
+
+			registry.SetNatsConnectionCheck(nil)
+			Expect(registry.Snapshot().NatsConnected).To(BeFalse())
+		})
+	})
+
+	Describe("AddBlobstoreBytes", func() {
+		It("sums bytes per direction", func() {
+			registry.AddBlobstoreBytes(BlobstoreDownload, 10)
+			registry.AddBlobstoreBytes(BlobstoreDownload, 5)
+			registry.AddBlobstoreBytes(BlobstoreUpload, 7)
+
+			Expect(registry.Snapshot().BlobstoreBytes).To(Equal(map[string]int64{
+				BlobstoreDownload: 15,
+				BlobstoreUpload:   7,
+			}))
+		})
+	})
+
+	Describe("Snapshot", func() {
+		It("is not affected by later changes", func() {
+			registry.RecordAction("apply", time.Second, nil)
+			snapshot := registry.Snapshot()
+
+			registry.RecordAction("apply", time.Second, nil)
+			Expect(snapshot.Actions["apply"].Succeeded).To(Equal(1))
+		})
+	})
+})
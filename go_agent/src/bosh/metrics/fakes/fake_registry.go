@@ -0,0 +1,73 @@
+package fakes
+
+import (
+	"sync"
+	"time"
+
+	boshmetrics "bosh/metrics"
+)
+
+type FakeRegistry struct {
+	lock sync.Mutex
+
+	RecordedActions []RecordedAction
+
+	TaskQueueDepth      int
+	NatsConnectionCheck func() bool
+	BlobstoreBytes      map[string]int64
+
+	SnapshotSnapshot boshmetrics.Snapshot
+}
+
+type RecordedAction struct {
+	Method   string
+	Duration time.Duration
+	Err      error
+}
+
+func NewFakeRegistry() *FakeRegistry {
+	return &FakeRegistry{BlobstoreBytes: map[string]int64{}}
+}
+
+func (r *FakeRegistry) RecordAction(method string, duration time.Duration, err error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.RecordedActions = append(r.RecordedActions, RecordedAction{
+		Method:   method,
+		Duration: duration,
+		Err:      err,
+	})
+}
+
+func (r *FakeRegistry) TaskQueued() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.TaskQueueDepth++
+}
+
+func (r *FakeRegistry) TaskFinished() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.TaskQueueDepth--
+}
+
+func (r *FakeRegistry) SetNatsConnectionCheck(check func() bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.NatsConnectionCheck = check
+}
+
+func (r *FakeRegistry) AddBlobstoreBytes(direction string, bytes int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.BlobstoreBytes[direction] += bytes
+}
+
+func (r *FakeRegistry) Snapshot() boshmetrics.Snapshot {
+	return r.SnapshotSnapshot
+}
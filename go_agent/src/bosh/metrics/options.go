@@ -0,0 +1,21 @@
+package metrics
+
+// DefaultListenAddress must not collide with ports of other
+// agent listeners (e.g. monit alerts on 2825, monit HTTP on 2822)
+const DefaultListenAddress = "127.0.0.1:2826"
+
+type Options struct {
+	// Disabled turns off metrics endpoint
+	Disabled bool
+
+	// Address metrics endpoint listens on;
+	// only reachable from the VM itself by default
+	ListenAddress string
+}
+
+func (o Options) Address() string {
+	if o.ListenAddress == "" {
+		return DefaultListenAddress
+	}
+	return o.ListenAddress
+}
@@ -0,0 +1,31 @@
+package metrics_test
+
+import (
+	"net"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshjobsuper "bosh/jobsupervisor"
+	. "bosh/metrics"
+)
+
+var _ = Describe("Options", func() {
+	Describe("Address", func() {
+		It("returns configured listen address", func() {
+			options := Options{ListenAddress: "0.0.0.0:1234"}
+			Expect(options.Address()).To(Equal("0.0.0.0:1234"))
+		})
+
+		It("returns local address by default", func() {
+			Expect(Options{}.Address()).To(Equal(DefaultListenAddress))
+		})
+
+		It("does not listen on monit alert port by default", func() {
+			_, port, err := net.SplitHostPort(Options{}.Address())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(port).ToNot(Equal(strconv.Itoa(boshjobsuper.MonitAlertPort)))
+		})
+	})
+})
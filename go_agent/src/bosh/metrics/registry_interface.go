@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"time"
+)
+
+const (
+	BlobstoreDownload = "download"
+	BlobstoreUpload   = "upload"
+)
+
+// Registry keeps track of agent internals exposed on metrics endpoint
+type Registry interface {
+	// RecordAction records single run of an action;
+	// asynchronous actions are recorded when their task finishes
+	RecordAction(method string, duration time.Duration, err error)
+
+	// Tasks are queued when they are handed to task service
+	// and finished when they stop running
+	TaskQueued()
+	TaskFinished()
+
+	// SetNatsConnectionCheck sets function called on every snapshot
+	// to find out whether agent is connected to NATS; nil means disconnected
+	SetNatsConnectionCheck(check func() bool)
+
+	// AddBlobstoreBytes records size of a blob transferred
+	// in given direction (BlobstoreDownload or BlobstoreUpload)
+	AddBlobstoreBytes(direction string, bytes int64)
+
+	Snapshot() Snapshot
+}
+
+type Snapshot struct {
+	Actions        map[string]ActionStats
+	TaskQueueDepth int
+	NatsConnected  bool
+	BlobstoreBytes map[string]int64
+}
+
+type ActionStats struct {
+	Succeeded int
+	Failed    int
+
+	// Total time spent running action
+	Duration time.Duration
+}
@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	bosherr "bosh/errors"
+	boshjobsuper "bosh/jobsupervisor"
+	boshlog "bosh/logger"
+	boshvitals "bosh/platform/vitals"
+)
+
+const serverLogTag = "metricsServer"
+
+// processStates are states reported by job supervisor for each process
+var processStates = []string{"running", "starting", "failing", "stopped"}
+
+// Server exposes vitals, processes and agent internals
+// on /metrics in Prometheus text format
+type Server struct {
+	options       Options
+	registry      Registry
+	vitalsService boshvitals.Service
+	jobSupervisor boshjobsuper.JobSupervisor
+	logger        boshlog.Logger
+}
+
+func NewServer(
+	options Options,
+	registry Registry,
+	vitalsService boshvitals.Service,
+	jobSupervisor boshjobsuper.JobSupervisor,
+	logger boshlog.Logger,
+) Server {
+	return Server{
+		options:       options,
+		registry:      registry,
+		vitalsService: vitalsService,
+		jobSupervisor: jobSupervisor,
+		logger:        logger,
+	}
+}
+
+// Run blocks serving metrics until listener fails
+func (s Server) Run() error {
+	if s.options.Disabled {
+		return nil
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.HandleMetrics)
+
+	s.logger.Info(serverLogTag, "Serving metrics on %s", s.options.Address())
+
+	err := http.ListenAndServe(s.options.Address(), mux)
+	if err != nil {
+		return bosherr.WrapError(err, "Serving metrics")
+	}
+
+	return nil
+}
+
+func (s Server) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	writer := &textWriter{}
+
+	s.writeVitals(writer)
+	s.writeProcesses(writer)
+	s.writeInternals(writer)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(writer.Bytes())
+}
+
+func (s Server) writeVitals(writer *textWriter) {
+	vitals, err := s.vitalsService.Get()
+	if err != nil {
+		// Still expose remaining metrics when vitals are not available
+		s.logger.Error(serverLogTag, "Getting vitals: %s", err.Error())
+		return
+	}
+
+	var loadSamples []sample
+	for i, period := range []string{"1m", "5m", "15m"} {
+		if i < len(vitals.Load) {
+			loadSamples = appendSample(loadSamples, vitals.Load[i], label{"period", period})
+		}
+	}
+	writer.WriteMetric("bosh_agent_load_average", metricTypeGauge, "System load average.", loadSamples)
+
+	var cpuSamples []sample
+	cpuSamples = appendSample(cpuSamples, vitals.CPU.User, label{"mode", "user"})
+	cpuSamples = appendSample(cpuSamples, vitals.CPU.Sys, label{"mode", "sys"})
+	cpuSamples = appendSample(cpuSamples, vitals.CPU.Wait, label{"mode", "wait"})
+	writer.WriteMetric("bosh_agent_cpu_percent", metricTypeGauge, "CPU usage percentage by mode.", cpuSamples)
+
+	writer.WriteMetric("bosh_agent_mem_percent", metricTypeGauge, "Memory usage percentage.", appendSample(nil, vitals.Mem.Percent))
+	writer.WriteMetric("bosh_agent_mem_kb", metricTypeGauge, "Memory used in kilobytes.", appendSample(nil, vitals.Mem.Kb))
+	writer.WriteMetric("bosh_agent_swap_percent", metricTypeGauge, "Swap usage percentage.", appendSample(nil, vitals.Swap.Percent))
+	writer.WriteMetric("bosh_agent_swap_kb", metricTypeGauge, "Swap used in kilobytes.", appendSample(nil, vitals.Swap.Kb))
+
+	var diskSamples, inodeSamples []sample
+	for _, name := range sortedDiskNames(vitals.Disk) {
+		disk := vitals.Disk[name]
+		diskSamples = appendSample(diskSamples, disk.Percent, label{"disk", name})
+		inodeSamples = appendSample(inodeSamples, disk.InodePercent, label{"disk", name})
+	}
+	writer.WriteMetric("bosh_agent_disk_percent", metricTypeGauge, "Disk usage percentage.", diskSamples)
+	writer.WriteMetric("bosh_agent_disk_inode_percent", metricTypeGauge, "Disk inode usage percentage.", inodeSamples)
+}
+
+func (s Server) writeProcesses(writer *textWriter) {
+	processes, err := s.jobSupervisor.Processes()
+	if err != nil {
+		s.logger.Error(serverLogTag, "Getting processes: %s", err.Error())
+		return
+	}
+
+	sort.Sort(processesByName(processes))
+
+	var stateSamples, uptimeSamples, memSamples, cpuSamples, restartSamples []sample
+
+	for _, process := range processes {
+		name := label{"process", process.Name}
+
+		for _, state := range processStates {
+			stateSamples = append(stateSamples, sample{
+				Labels: []label{name, {"state", state}},
+				Value:  boolValue(process.State == state),
+			})
+		}
+
+		uptimeSamples = append(uptimeSamples, sample{Labels: []label{name}, Value: float64(process.UptimeSeconds)})
+		memSamples = append(memSamples, sample{Labels: []label{name}, Value: float64(process.Memory.Kb)})
+		cpuSamples = append(cpuSamples, sample{Labels: []label{name}, Value: process.CPU.Total})
+		restartSamples = append(restartSamples, sample{Labels: []label{name}, Value: float64(process.Restarts)})
+	}
+
+	writer.WriteMetric("bosh_agent_process_state", metricTypeGauge, "Process state reported by job supervisor; 1 for current state.", stateSamples)
+	writer.WriteMetric("bosh_agent_process_uptime_seconds", metricTypeGauge, "Process uptime in seconds.", uptimeSamples)
+	writer.WriteMetric("bosh_agent_process_mem_kb", metricTypeGauge, "Process memory usage in kilobytes.", memSamples)
+	writer.WriteMetric("bosh_agent_process_cpu_percent", metricTypeGauge, "Process CPU usage percentage.", cpuSamples)
+	writer.WriteMetric("bosh_agent_process_restarts_total", metricTypeCounter, "Process restarts since agent started.", restartSamples)
+}
+
+func (s Server) writeInternals(writer *textWriter) {
+	snapshot := s.registry.Snapshot()
+
+	methods := make([]string, 0, len(snapshot.Actions))
+	for method := range snapshot.Actions {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	var countSamples, durationSamples []sample
+
+	for _, method := range methods {
+		stats := snapshot.Actions[method]
+		name := label{"method", method}
+
+		countSamples = append(countSamples,
+			sample{Labels: []label{name, {"result", "success"}}, Value: float64(stats.Succeeded)},
+			sample{Labels: []label{name, {"result", "failure"}}, Value: float64(stats.Failed)},
+		)
+
+		durationSamples = append(durationSamples,
+			sample{Suffix: "_sum", Labels: []label{name}, Value: stats.Duration.Seconds()},
+			sample{Suffix: "_count", Labels: []label{name}, Value: float64(stats.Succeeded + stats.Failed)},
+		)
+	}
+
+	writer.WriteMetric("bosh_agent_actions_total", metricTypeCounter, "Actions run by method and result.", countSamples)
+	writer.WriteMetric("bosh_agent_action_duration_seconds", metricTypeSummary, "Time spent running actions by method.", durationSamples)
+
+	writer.WriteMetric("bosh_agent_task_queue_depth", metricTypeGauge, "Asynchronous tasks waiting or running.",
+		[]sample{{Value: float64(snapshot.TaskQueueDepth)}})
+
+	writer.WriteMetric("bosh_agent_nats_connected", metricTypeGauge, "Whether agent is connected to NATS.",
+		[]sample{{Value: boolValue(snapshot.NatsConnected)}})
+
+	var blobstoreSamples []sample
+	for _, direction := range []string{BlobstoreDownload, BlobstoreUpload} {
+		blobstoreSamples = append(blobstoreSamples, sample{
+			Labels: []label{{"direction", direction}},
+			Value:  float64(snapshot.BlobstoreBytes[direction]),
+		})
+	}
+	writer.WriteMetric("bosh_agent_blobstore_bytes_total", metricTypeCounter, "Bytes transferred to and from blobstore.", blobstoreSamples)
+}
+
+// appendSample skips values that vitals left empty or are not numbers
+func appendSample(samples []sample, value string, labels ...label) []sample {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return samples
+	}
+	return append(samples, sample{Labels: labels, Value: number})
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func sortedDiskNames(disks boshvitals.DiskVitals) []string {
+	names := make([]string, 0, len(disks))
+	for name := range disks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type processesByName []boshjobsuper.Process
+
+func (p processesByName) Len() int           { return len(p) }
+func (p processesByName) Less(i, j int) bool { return p[i].Name < p[j].Name }
+func (p processesByName) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
@@ -0,0 +1,162 @@
+package metrics_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshjobsuper "bosh/jobsupervisor"
+	fakejobsuper "bosh/jobsupervisor/fakes"
+	boshlog "bosh/logger"
+	. "bosh/metrics"
+	fakemetrics "bosh/metrics/fakes"
+	boshvitals "bosh/platform/vitals"
+	fakevitals "bosh/platform/vitals/fakes"
+)
+
+var _ = Describe("Server", func() {
+	var (
+		registry      *fakemetrics.FakeRegistry
+		vitalsService *fakevitals.FakeService
+		jobSupervisor *fakejobsuper.FakeJobSupervisor
+		server        Server
+	)
+
+	BeforeEach(func() {
+		registry = fakemetrics.NewFakeRegistry()
+		vitalsService = fakevitals.NewFakeService()
+		jobSupervisor = fakejobsuper.NewFakeJobSupervisor()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		server = NewServer(Options{}, registry, vitalsService, jobSupervisor, logger)
+	})
+
+	Describe("HandleMetrics", func() {
+		scrape := func() *httptest.ResponseRecorder {
+			request, err := http.NewRequest("GET", "/metrics", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			recorder := httptest.NewRecorder()
+			server.HandleMetrics(recorder, request)
+			return recorder
+		}
+
+		It("responds with prometheus text format", func() {
+			recorder := scrape()
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4"))
+		})
+
+		It("exposes vitals", func() {
+			vitalsService.GetVitals = boshvitals.Vitals{
+				Load: []string{"0.20", "4.55", "1.12"},
+				CPU:  boshvitals.CPUVitals{User: "56.0", Sys: "10.0", Wait: "1.0"},
+				Mem:  boshvitals.MemoryVitals{Percent: "70", Kb: "700"},
+				Swap: boshvitals.MemoryVitals{Percent: "60", Kb: "600"},
+				Disk: boshvitals.DiskVitals{
+					"system":     boshvitals.SpecificDiskVitals{Percent: "50", InodePercent: "10"},
+					"persistent": boshvitals.SpecificDiskVitals{Percent: "90", InodePercent: "95"},
+				},
+			}
+
+			body := scrape().Body.String()
+			Expect(body).To(ContainSubstring("# TYPE bosh_agent_load_average gauge\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_load_average{period="1m"} 0.2` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_load_average{period="15m"} 1.12` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_cpu_percent{mode="user"} 56` + "\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_mem_percent 70\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_mem_kb 700\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_swap_percent 60\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_disk_percent{disk="persistent"} 90` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_disk_inode_percent{disk="system"} 10` + "\n"))
+		})
+
+		It("skips vitals that are not reported", func() {
+			vitalsService.GetVitals = boshvitals.Vitals{
+				Disk: boshvitals.DiskVitals{"system": boshvitals.SpecificDiskVitals{Percent: "50"}},
+			}
+
+			body := scrape().Body.String()
+			Expect(body).ToNot(ContainSubstring("bosh_agent_mem_percent"))
+			Expect(body).ToNot(ContainSubstring("bosh_agent_disk_inode_percent"))
+			Expect(body).To(ContainSubstring(`bosh_agent_disk_percent{disk="system"} 50` + "\n"))
+		})
+
+		It("exposes remaining metrics when vitals cannot be retrieved", func() {
+			vitalsService.GetErr = errors.New("fake-vitals-err")
+
+			recorder := scrape()
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Body.String()).To(ContainSubstring("bosh_agent_nats_connected 0\n"))
+		})
+
+		It("exposes processes reported by job supervisor", func() {
+			jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+				{
+					Name:          "fake-process-b",
+					State:         "failing",
+					UptimeSeconds: 10,
+					Memory:        boshjobsuper.ProcessMemory{Kb: 100},
+					CPU:           boshjobsuper.ProcessCPU{Total: 1.5},
+					Restarts:      3,
+				},
+				{Name: "fake-process-a", State: "running"},
+			}
+
+			body := scrape().Body.String()
+			Expect(body).To(ContainSubstring(`bosh_agent_process_state{process="fake-process-a",state="running"} 1` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_state{process="fake-process-b",state="running"} 0` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_state{process="fake-process-b",state="failing"} 1` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_uptime_seconds{process="fake-process-b"} 10` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_mem_kb{process="fake-process-b"} 100` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_cpu_percent{process="fake-process-b"} 1.5` + "\n"))
+			Expect(body).To(ContainSubstring("# TYPE bosh_agent_process_restarts_total counter\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_process_restarts_total{process="fake-process-b"} 3` + "\n"))
+
+			Expect(body).To(MatchRegexp(`(?s)process="fake-process-a".*process="fake-process-b"`))
+		})
+
+		It("escapes label values", func() {
+			jobSupervisor.ProcessesProcesses = []boshjobsuper.Process{
+				{Name: "fake\"process\\", State: "running"},
+			}
+
+			body := scrape().Body.String()
+			Expect(body).To(ContainSubstring(`bosh_agent_process_uptime_seconds{process="fake\"process\\"} 0` + "\n"))
+		})
+
+		It("exposes agent internals", func() {
+			registry.SnapshotSnapshot = Snapshot{
+				Actions: map[string]ActionStats{
+					"apply": ActionStats{Succeeded: 2, Failed: 1, Duration: 1500 * time.Millisecond},
+				},
+				TaskQueueDepth: 3,
+				NatsConnected:  true,
+				BlobstoreBytes: map[string]int64{BlobstoreDownload: 1024},
+			}
+
+			body := scrape().Body.String()
+			Expect(body).To(ContainSubstring(`bosh_agent_actions_total{method="apply",result="success"} 2` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_actions_total{method="apply",result="failure"} 1` + "\n"))
+			Expect(body).To(ContainSubstring("# TYPE bosh_agent_action_duration_seconds summary\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_action_duration_seconds_sum{method="apply"} 1.5` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_action_duration_seconds_count{method="apply"} 3` + "\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_task_queue_depth 3\n"))
+			Expect(body).To(ContainSubstring("bosh_agent_nats_connected 1\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_blobstore_bytes_total{direction="download"} 1024` + "\n"))
+			Expect(body).To(ContainSubstring(`bosh_agent_blobstore_bytes_total{direction="upload"} 0` + "\n"))
+		})
+
+		It("rejects methods other than GET", func() {
+			request, err := http.NewRequest("POST", "/metrics", nil)
+			Expect(err).ToNot(HaveOccurred())
+
+			recorder := httptest.NewRecorder()
+			server.HandleMetrics(recorder, request)
+			Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		})
+	})
+})
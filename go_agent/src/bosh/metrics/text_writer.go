@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	metricTypeGauge   = "gauge"
+	metricTypeCounter = "counter"
+	metricTypeSummary = "summary"
+)
+
+// textWriter writes metrics in Prometheus text exposition format
+type textWriter struct {
+	buf bytes.Buffer
+}
+
+type label struct {
+	Name  string
+	Value string
+}
+
+type sample struct {
+	// Suffix is appended to metric name e.g. _sum and _count of summaries
+	Suffix string
+	Labels []label
+	Value  float64
+}
+
+func (w *textWriter) WriteMetric(name, metricType, help string, samples []sample) {
+	if len(samples) == 0 {
+		return
+	}
+
+	fmt.Fprintf(&w.buf, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(&w.buf, "# TYPE %s %s\n", name, metricType)
+
+	for _, s := range samples {
+		w.buf.WriteString(name)
+		w.buf.WriteString(s.Suffix)
+
+		if len(s.Labels) > 0 {
+			pairs := make([]string, len(s.Labels))
+			for i, l := range s.Labels {
+				pairs[i] = fmt.Sprintf("%s=\"%s\"", l.Name, escapeLabelValue(l.Value))
+			}
+			fmt.Fprintf(&w.buf, "{%s}", strings.Join(pairs, ","))
+		}
+
+		fmt.Fprintf(&w.buf, " %s\n", strconv.FormatFloat(s.Value, 'g', -1, 64))
+	}
+}
+
+func (w *textWriter) Bytes() []byte {
+	return w.buf.Bytes()
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
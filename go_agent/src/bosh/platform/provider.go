@@ -28,7 +28,7 @@ type ProviderOptions struct {
 func NewProvider(logger boshlog.Logger, dirProvider boshdirs.DirectoriesProvider, options ProviderOptions) (p provider) {
 	runner := boshsys.NewExecCmdRunner(logger)
 	fs := boshsys.NewOsFileSystem(logger)
-	sigarCollector := boshstats.NewSigarStatsCollector()
+	sigarCollector := boshstats.NewSigarStatsCollector(fs)
 	linuxDiskManager := boshdisk.NewLinuxDiskManager(logger, runner, fs)
 
 	udev := boshudev.NewConcreteUdevDevice(runner)
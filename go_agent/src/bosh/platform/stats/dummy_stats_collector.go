@@ -31,3 +31,19 @@ func (p dummyStatsCollector) GetDiskStats(devicePath string) (stats DiskStats, e
 	stats.InodeUsage.Total = 1
 	return
 }
+
+func (p dummyStatsCollector) GetPerCPUStats() (stats []CPUStats, err error) {
+	return
+}
+
+func (p dummyStatsCollector) GetDiskIOStats() (stats []DiskIOStats, err error) {
+	return
+}
+
+func (p dummyStatsCollector) GetNetworkStats() (stats []NetworkStats, err error) {
+	return
+}
+
+func (p dummyStatsCollector) GetUptimeStats() (stats UptimeStats, err error) {
+	return
+}
@@ -6,11 +6,20 @@ import (
 )
 
 type FakeStatsCollector struct {
-	CPULoad   boshstats.CPULoad
-	CPUStats  boshstats.CPUStats
-	MemStats  boshstats.Usage
-	SwapStats boshstats.Usage
-	DiskStats map[string]boshstats.DiskStats
+	CPULoad      boshstats.CPULoad
+	CPUStats     boshstats.CPUStats
+	PerCPUStats  []boshstats.CPUStats
+	MemStats     boshstats.Usage
+	SwapStats    boshstats.Usage
+	DiskStats    map[string]boshstats.DiskStats
+	DiskIOStats  []boshstats.DiskIOStats
+	NetworkStats []boshstats.NetworkStats
+	UptimeStats  boshstats.UptimeStats
+
+	PerCPUStatsErr  error
+	DiskIOStatsErr  error
+	NetworkStatsErr error
+	UptimeStatsErr  error
 }
 
 func (c *FakeStatsCollector) GetCPULoad() (load boshstats.CPULoad, err error) {
@@ -23,6 +32,10 @@ func (c *FakeStatsCollector) GetCPUStats() (stats boshstats.CPUStats, err error)
 	return
 }
 
+func (c *FakeStatsCollector) GetPerCPUStats() (stats []boshstats.CPUStats, err error) {
+	return c.PerCPUStats, c.PerCPUStatsErr
+}
+
 func (c *FakeStatsCollector) GetMemStats() (usage boshstats.Usage, err error) {
 	usage = c.MemStats
 	return
@@ -40,3 +53,15 @@ func (c *FakeStatsCollector) GetDiskStats(devicePath string) (stats boshstats.Di
 	}
 	return
 }
+
+func (c *FakeStatsCollector) GetDiskIOStats() (stats []boshstats.DiskIOStats, err error) {
+	return c.DiskIOStats, c.DiskIOStatsErr
+}
+
+func (c *FakeStatsCollector) GetNetworkStats() (stats []boshstats.NetworkStats, err error) {
+	return c.NetworkStats, c.NetworkStatsErr
+}
+
+func (c *FakeStatsCollector) GetUptimeStats() (stats boshstats.UptimeStats, err error) {
+	return c.UptimeStats, c.UptimeStatsErr
+}
@@ -0,0 +1,118 @@
+package stats
+
+import (
+	"strconv"
+	"strings"
+
+	bosherr "bosh/errors"
+	boshsys "bosh/system"
+)
+
+const (
+	procNetDevPath    = "/proc/net/dev"
+	procDiskStatsPath = "/proc/diskstats"
+
+	// Sector counts in /proc/diskstats are always in 512 byte units
+	// regardless of the device's physical sector size.
+	diskStatsSectorSize = 512
+)
+
+// procStats reads stats that sigar does not provide from /proc
+type procStats struct {
+	fs boshsys.FileSystem
+}
+
+func newProcStats(fs boshsys.FileSystem) procStats {
+	return procStats{fs: fs}
+}
+
+func (p procStats) GetNetworkStats() (stats []NetworkStats, err error) {
+	contents, err := p.fs.ReadFileString(procNetDevPath)
+	if err != nil {
+		err = bosherr.WrapError(err, "Reading %s", procNetDevPath)
+		return
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		// Header lines do not contain colon separated interface names
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		fields := strings.Fields(parts[1])
+		if len(fields) < 16 {
+			continue
+		}
+
+		values, parseErr := parseUints(fields)
+		if parseErr != nil {
+			err = bosherr.WrapError(parseErr, "Parsing %s", procNetDevPath)
+			return
+		}
+
+		stats = append(stats, NetworkStats{
+			Interface:       strings.TrimSpace(parts[0]),
+			BytesReceived:   values[0],
+			PacketsReceived: values[1],
+			ErrorsReceived:  values[2],
+			BytesSent:       values[8],
+			PacketsSent:     values[9],
+			ErrorsSent:      values[10],
+		})
+	}
+
+	return
+}
+
+func (p procStats) GetDiskIOStats() (stats []DiskIOStats, err error) {
+	contents, err := p.fs.ReadFileString(procDiskStatsPath)
+	if err != nil {
+		err = bosherr.WrapError(err, "Reading %s", procDiskStatsPath)
+		return
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 14 {
+			continue
+		}
+
+		values, parseErr := parseUints(fields[3:])
+		if parseErr != nil {
+			err = bosherr.WrapError(parseErr, "Parsing %s", procDiskStatsPath)
+			return
+		}
+
+		readOps, readSectors := values[0], values[2]
+		writeOps, writeSectors := values[4], values[6]
+
+		// Skip devices that never did any I/O (unused loop and ram devices)
+		if readOps == 0 && writeOps == 0 {
+			continue
+		}
+
+		stats = append(stats, DiskIOStats{
+			Device:     fields[2],
+			ReadOps:    readOps,
+			WriteOps:   writeOps,
+			ReadBytes:  readSectors * diskStatsSectorSize,
+			WriteBytes: writeSectors * diskStatsSectorSize,
+		})
+	}
+
+	return
+}
+
+func parseUints(fields []string) (values []uint64, err error) {
+	values = make([]uint64, len(fields))
+
+	for i, field := range fields {
+		values[i], err = strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
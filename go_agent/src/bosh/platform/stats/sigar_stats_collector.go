@@ -2,14 +2,18 @@ package stats
 
 import (
 	bosherr "bosh/errors"
+	boshsys "bosh/system"
 	sigar "github.com/cloudfoundry/gosigar"
 )
 
 type sigarStatsCollector struct {
+	procStats procStats
 }
 
-func NewSigarStatsCollector() (collector StatsCollector) {
-	return sigarStatsCollector{}
+func NewSigarStatsCollector(fs boshsys.FileSystem) (collector StatsCollector) {
+	return sigarStatsCollector{
+		procStats: newProcStats(fs),
+	}
 }
 
 func (s sigarStatsCollector) GetCPULoad() (load CPULoad, err error) {
@@ -43,6 +47,26 @@ func (s sigarStatsCollector) GetCPUStats() (stats CPUStats, err error) {
 	return
 }
 
+func (s sigarStatsCollector) GetPerCPUStats() (stats []CPUStats, err error) {
+	cpuList := sigar.CpuList{}
+	err = cpuList.Get()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting Sigar CPU List")
+		return
+	}
+
+	for _, cpu := range cpuList.List {
+		stats = append(stats, CPUStats{
+			User:  cpu.User,
+			Sys:   cpu.Sys,
+			Wait:  cpu.Wait,
+			Total: cpu.Total(),
+		})
+	}
+
+	return
+}
+
 func (s sigarStatsCollector) GetMemStats() (usage Usage, err error) {
 	mem := sigar.Mem{}
 	err = mem.Get()
@@ -86,3 +110,24 @@ func (s sigarStatsCollector) GetDiskStats(mountedPath string) (stats DiskStats,
 
 	return
 }
+
+func (s sigarStatsCollector) GetDiskIOStats() (stats []DiskIOStats, err error) {
+	return s.procStats.GetDiskIOStats()
+}
+
+func (s sigarStatsCollector) GetNetworkStats() (stats []NetworkStats, err error) {
+	return s.procStats.GetNetworkStats()
+}
+
+func (s sigarStatsCollector) GetUptimeStats() (stats UptimeStats, err error) {
+	uptime := sigar.Uptime{}
+	err = uptime.Get()
+	if err != nil {
+		err = bosherr.WrapError(err, "Getting Sigar Uptime")
+		return
+	}
+
+	stats.Secs = uint64(uptime.Length)
+
+	return
+}
@@ -5,13 +5,14 @@ import (
 	. "github.com/onsi/gomega"
 
 	. "bosh/platform/stats"
+	fakesys "bosh/system/fakes"
 )
 
 func init() {
 	Describe("Testing with Ginkgo", func() {
 		It("ubuntu get cpu load", func() {
 
-			collector := NewSigarStatsCollector()
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
 
 			load, err := collector.GetCPULoad()
 			Expect(err).ToNot(HaveOccurred())
@@ -21,7 +22,7 @@ func init() {
 		})
 		It("ubuntu get cpu stats", func() {
 
-			collector := NewSigarStatsCollector()
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
 
 			stats, err := collector.GetCPUStats()
 			Expect(err).ToNot(HaveOccurred())
@@ -31,7 +32,7 @@ func init() {
 		})
 		It("ubuntu get mem stats", func() {
 
-			collector := NewSigarStatsCollector()
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
 
 			stats, err := collector.GetMemStats()
 			Expect(err).ToNot(HaveOccurred())
@@ -40,7 +41,7 @@ func init() {
 		})
 		It("ubuntu get swap stats", func() {
 
-			collector := NewSigarStatsCollector()
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
 
 			stats, err := collector.GetSwapStats()
 			Expect(err).ToNot(HaveOccurred())
@@ -48,7 +49,7 @@ func init() {
 		})
 		It("ubuntu get disk stats", func() {
 
-			collector := NewSigarStatsCollector()
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
 
 			stats, err := collector.GetDiskStats("/")
 			Expect(err).ToNot(HaveOccurred())
@@ -58,5 +59,97 @@ func init() {
 			Expect(stats.InodeUsage.Total > 0).To(BeTrue())
 			Expect(stats.InodeUsage.Used > 0).To(BeTrue())
 		})
+		It("ubuntu get per cpu stats", func() {
+
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
+
+			stats, err := collector.GetPerCPUStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(stats) > 0).To(BeTrue())
+			Expect(stats[0].Total > 0).To(BeTrue())
+		})
+		It("ubuntu get uptime stats", func() {
+
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
+
+			stats, err := collector.GetUptimeStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats.Secs > 0).To(BeTrue())
+		})
+		It("get network stats", func() {
+			fs := fakesys.NewFakeFileSystem()
+			fs.WriteFileString("/proc/net/dev", `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo:    1200      12    0    0    0     0          0         0     1200      12    0    0    0     0       0          0
+  eth0: 5306442   42071    3    0    0     0          0         0  3011370   19874    1    0    0     0       0          0
+`)
+
+			collector := NewSigarStatsCollector(fs)
+
+			stats, err := collector.GetNetworkStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal([]NetworkStats{
+				{
+					Interface:       "lo",
+					BytesReceived:   1200,
+					BytesSent:       1200,
+					PacketsReceived: 12,
+					PacketsSent:     12,
+				},
+				{
+					Interface:       "eth0",
+					BytesReceived:   5306442,
+					BytesSent:       3011370,
+					PacketsReceived: 42071,
+					PacketsSent:     19874,
+					ErrorsReceived:  3,
+					ErrorsSent:      1,
+				},
+			}))
+		})
+		It("get network stats when proc file cannot be read", func() {
+			collector := NewSigarStatsCollector(fakesys.NewFakeFileSystem())
+
+			_, err := collector.GetNetworkStats()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("/proc/net/dev"))
+		})
+		It("get disk io stats", func() {
+			fs := fakesys.NewFakeFileSystem()
+			fs.WriteFileString("/proc/diskstats", `   1       0 ram0 0 0 0 0 0 0 0 0 0 0 0
+   8       0 sda 4580 1210 260090 2730 10392 8317 382024 18360 0 9420 21070
+   8       1 sda1 4370 1210 258410 2680 10392 8317 382024 18360 0 9370 21020
+`)
+
+			collector := NewSigarStatsCollector(fs)
+
+			stats, err := collector.GetDiskIOStats()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stats).To(Equal([]DiskIOStats{
+				{
+					Device:     "sda",
+					ReadOps:    4580,
+					WriteOps:   10392,
+					ReadBytes:  260090 * 512,
+					WriteBytes: 382024 * 512,
+				},
+				{
+					Device:     "sda1",
+					ReadOps:    4370,
+					WriteOps:   10392,
+					ReadBytes:  258410 * 512,
+					WriteBytes: 382024 * 512,
+				},
+			}))
+		})
+		It("get disk io stats when proc file is malformed", func() {
+			fs := fakesys.NewFakeFileSystem()
+			fs.WriteFileString("/proc/diskstats", "   8       0 sda a b c d e f g h i j k\n")
+
+			collector := NewSigarStatsCollector(fs)
+
+			_, err := collector.GetDiskIOStats()
+			Expect(err).To(HaveOccurred())
+		})
 	})
 }
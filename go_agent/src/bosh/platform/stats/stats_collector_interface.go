@@ -23,12 +23,38 @@ type DiskStats struct {
 	InodeUsage Usage
 }
 
+type NetworkStats struct {
+	Interface       string
+	BytesReceived   uint64
+	BytesSent       uint64
+	PacketsReceived uint64
+	PacketsSent     uint64
+	ErrorsReceived  uint64
+	ErrorsSent      uint64
+}
+
+type DiskIOStats struct {
+	Device     string
+	ReadOps    uint64
+	WriteOps   uint64
+	ReadBytes  uint64
+	WriteBytes uint64
+}
+
+type UptimeStats struct {
+	Secs uint64
+}
+
 type StatsCollector interface {
 	GetCPULoad() (load CPULoad, err error)
 	GetCPUStats() (stats CPUStats, err error)
+	GetPerCPUStats() (stats []CPUStats, err error)
 	GetMemStats() (usage Usage, err error)
 	GetSwapStats() (usage Usage, err error)
 	GetDiskStats(mountedPath string) (stats DiskStats, err error)
+	GetDiskIOStats() (stats []DiskIOStats, err error)
+	GetNetworkStats() (stats []NetworkStats, err error)
+	GetUptimeStats() (stats UptimeStats, err error)
 }
 
 func (cpuStats CPUStats) UserPercent() Percentage {
@@ -0,0 +1,357 @@
+package vitals
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	bosherr "bosh/errors"
+	boshlog "bosh/logger"
+	boshsys "bosh/system"
+)
+
+const (
+	historyLogTag = "vitalsHistory"
+
+	DefaultHistoryMaxPoints = 360
+)
+
+type historySample struct {
+	Time int64
+
+	// Values are ordered by metric index; metrics indexed after
+	// sample was taken are not included and missing values are NaN
+	Values []float64
+}
+
+// historyFile keeps metric names once; missing values are null
+type historyFile struct {
+	Metrics []string            `json:"metrics"`
+	Samples []historyFileSample `json:"samples"`
+}
+
+type historyFileSample struct {
+	Time   int64      `json:"time"`
+	Values []*float64 `json:"values"`
+}
+
+type concreteHistory struct {
+	options HistoryOptions
+	fs      boshsys.FileSystem
+	path    string
+	logger  boshlog.Logger
+
+	// metrics index values of every sample so that
+	// metric names are not kept with each sample
+	samplesLock sync.Mutex
+	metrics     []string
+	metricIndex map[string]int
+
+	// samples is a ring buffer; once it is full
+	// next points to the oldest sample
+	samples []historySample
+	next    int
+}
+
+func NewHistory(
+	options HistoryOptions,
+	fs boshsys.FileSystem,
+	path string,
+	logger boshlog.Logger,
+) History {
+	return &concreteHistory{
+		options: options,
+		fs:      fs,
+		path:    path,
+		logger:  logger,
+
+		metricIndex: map[string]int{},
+		samples:     make([]historySample, 0, options.capacity()),
+	}
+}
+
+func (h *concreteHistory) Run() error {
+	if h.options.Disabled || !h.options.Persist {
+		return nil
+	}
+
+	persistTicker := time.NewTicker(h.options.persistInterval())
+	defer persistTicker.Stop()
+
+	for {
+		<-persistTicker.C
+
+		err := h.Save()
+		if err != nil {
+			h.logger.Error(historyLogTag, "Saving vitals history: %s", err.Error())
+		}
+	}
+}
+
+func (h *concreteHistory) Add(sampledAt time.Time, vitals Vitals) {
+	if h.options.Disabled {
+		return
+	}
+
+	values, err := vitals.Values()
+	if err != nil {
+		h.logger.Error(historyLogTag, "Flattening vitals: %s", err.Error())
+		return
+	}
+
+	h.samplesLock.Lock()
+	defer h.samplesLock.Unlock()
+
+	h.addValues(sampledAt.Unix(), values)
+}
+
+func (h *concreteHistory) addValues(sampledAt int64, values map[string]float64) {
+	for metric := range values {
+		_, found := h.metricIndex[metric]
+		if !found {
+			h.metricIndex[metric] = len(h.metrics)
+			h.metrics = append(h.metrics, metric)
+		}
+	}
+
+	row := make([]float64, len(h.metrics))
+	for i := range row {
+		row[i] = math.NaN()
+	}
+
+	for metric, value := range values {
+		row[h.metricIndex[metric]] = value
+	}
+
+	h.add(historySample{Time: sampledAt, Values: row})
+}
+
+func (h *concreteHistory) add(sample historySample) {
+	if len(h.samples) < cap(h.samples) {
+		h.samples = append(h.samples, sample)
+		return
+	}
+
+	h.samples[h.next] = sample
+	h.next = (h.next + 1) % len(h.samples)
+}
+
+// orderedSamples returns copy of metrics and samples ordered
+// from oldest to newest; sample values are never modified
+func (h *concreteHistory) orderedSamples() ([]string, []historySample) {
+	h.samplesLock.Lock()
+	defer h.samplesLock.Unlock()
+
+	metrics := append([]string{}, h.metrics...)
+
+	samples := make([]historySample, 0, len(h.samples))
+	samples = append(samples, h.samples[h.next:]...)
+	samples = append(samples, h.samples[:h.next]...)
+
+	return metrics, samples
+}
+
+func (h *concreteHistory) Query(query HistoryQuery) HistoryResult {
+	result := HistoryResult{
+		From:   query.From,
+		To:     query.To,
+		Series: map[string][]HistoryPoint{},
+	}
+
+	metrics, samples := h.orderedSamples()
+	if len(samples) == 0 {
+		return result
+	}
+
+	if result.From <= 0 {
+		result.From = samples[0].Time
+	}
+
+	if result.To <= 0 {
+		result.To = samples[len(samples)-1].Time
+	}
+
+	if result.To < result.From {
+		return result
+	}
+
+	maxPoints := int64(query.MaxPoints)
+	if maxPoints <= 0 {
+		maxPoints = DefaultHistoryMaxPoints
+	}
+
+	result.Step = int64(h.options.Interval() / time.Second)
+
+	span := result.To - result.From + 1
+	if span > result.Step*maxPoints {
+		result.Step = (span + maxPoints - 1) / maxPoints
+	}
+
+	var wantedMetrics map[string]bool
+
+	if len(query.Metrics) > 0 {
+		wantedMetrics = map[string]bool{}
+		for _, metric := range query.Metrics {
+			wantedMetrics[metric] = true
+		}
+	}
+
+	// Keyed by metric index
+	builders := map[int]*historySeriesBuilder{}
+
+	for i, metric := range metrics {
+		if wantedMetrics == nil || wantedMetrics[metric] {
+			builders[i] = &historySeriesBuilder{}
+		}
+	}
+
+	for _, sample := range samples {
+		if sample.Time < result.From || sample.Time > result.To {
+			continue
+		}
+
+		bucketTime := result.From + (sample.Time-result.From)/result.Step*result.Step
+
+		for i, builder := range builders {
+			if i < len(sample.Values) && !math.IsNaN(sample.Values[i]) {
+				builder.Add(bucketTime, sample.Values[i])
+			}
+		}
+	}
+
+	for i, builder := range builders {
+		points := builder.Points()
+		if len(points) > 0 {
+			result.Series[metrics[i]] = points
+		}
+	}
+
+	return result
+}
+
+func (h *concreteHistory) Load() error {
+	if h.options.Disabled || !h.options.Persist {
+		return nil
+	}
+
+	if !h.fs.FileExists(h.path) {
+		return nil
+	}
+
+	bytes, err := h.fs.ReadFile(h.path)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading vitals history file %s", h.path)
+	}
+
+	var file historyFile
+
+	err = json.Unmarshal(bytes, &file)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling vitals history")
+	}
+
+	oldest := time.Now().Add(-h.options.retention()).Unix()
+
+	h.samplesLock.Lock()
+	defer h.samplesLock.Unlock()
+
+	for _, sample := range file.Samples {
+		if sample.Time < oldest {
+			continue
+		}
+
+		// Saved metric order might differ from current metric index
+		values := map[string]float64{}
+
+		for i, value := range sample.Values {
+			if value != nil && i < len(file.Metrics) {
+				values[file.Metrics[i]] = *value
+			}
+		}
+
+		h.addValues(sample.Time, values)
+	}
+
+	return nil
+}
+
+func (h *concreteHistory) Save() error {
+	metrics, samples := h.orderedSamples()
+
+	file := historyFile{
+		Metrics: metrics,
+		Samples: make([]historyFileSample, len(samples)),
+	}
+
+	for i, sample := range samples {
+		values := make([]*float64, len(sample.Values))
+
+		for j := range sample.Values {
+			if !math.IsNaN(sample.Values[j]) {
+				values[j] = &sample.Values[j]
+			}
+		}
+
+		file.Samples[i] = historyFileSample{Time: sample.Time, Values: values}
+	}
+
+	bytes, err := json.Marshal(file)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling vitals history")
+	}
+
+	// Agent might be stopped while writing so history is
+	// written to temporary file first and then moved into place
+	tmpPath := h.path + ".tmp"
+
+	err = h.fs.WriteFile(tmpPath, bytes)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing vitals history file %s", tmpPath)
+	}
+
+	err = h.fs.Rename(tmpPath, h.path)
+	if err != nil {
+		return bosherr.WrapError(err, "Renaming vitals history file %s", tmpPath)
+	}
+
+	return nil
+}
+
+// historySeriesBuilder averages time ordered values into points
+type historySeriesBuilder struct {
+	points []HistoryPoint
+
+	bucketTime int64
+	sum        float64
+	count      int
+}
+
+func (b *historySeriesBuilder) Add(bucketTime int64, value float64) {
+	if b.count > 0 && bucketTime != b.bucketTime {
+		b.flush()
+	}
+
+	b.bucketTime = bucketTime
+	b.sum += value
+	b.count++
+}
+
+func (b *historySeriesBuilder) Points() []HistoryPoint {
+	b.flush()
+	return b.points
+}
+
+func (b *historySeriesBuilder) flush() {
+	if b.count == 0 {
+		return
+	}
+
+	b.points = append(b.points, HistoryPoint{
+		Time:  b.bucketTime,
+		Value: b.sum / float64(b.count),
+	})
+
+	b.sum = 0
+	b.count = 0
+}
@@ -0,0 +1,252 @@
+package vitals_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "bosh/logger"
+	. "bosh/platform/vitals"
+	fakesys "bosh/system/fakes"
+)
+
+func init() {
+	Describe("concreteHistory", func() {
+		var (
+			options HistoryOptions
+			fs      *fakesys.FakeFileSystem
+			history History
+		)
+
+		BeforeEach(func() {
+			options = HistoryOptions{IntervalSeconds: 10, RetentionSeconds: 60}
+			fs = fakesys.NewFakeFileSystem()
+			fs.MkdirAll("/fake-dir", os.ModePerm)
+		})
+
+		JustBeforeEach(func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			history = NewHistory(options, fs, "/fake-dir/vitals-history.json", logger)
+		})
+
+		buildVitals := func(memPercent string) Vitals {
+			return Vitals{
+				CPU:  CPUVitals{User: "1.5"},
+				Load: []string{"0.50", "0.25", "0.10"},
+				Mem:  MemoryVitals{Percent: memPercent, Kb: "100"},
+				Disk: DiskVitals{"system": SpecificDiskVitals{Percent: "30"}},
+			}
+		}
+
+		Describe("Query", func() {
+			It("returns empty series when nothing was sampled", func() {
+				result := history.Query(HistoryQuery{})
+				Expect(result.Series).To(BeEmpty())
+			})
+
+			It("returns requested metrics keyed by vitals JSON path", func() {
+				history.Add(time.Unix(1000, 0), buildVitals("10"))
+				history.Add(time.Unix(1010, 0), buildVitals("20"))
+
+				result := history.Query(HistoryQuery{
+					Metrics: []string{"mem.percent", "load.0", "disk.system.percent"},
+				})
+
+				Expect(result.From).To(Equal(int64(1000)))
+				Expect(result.To).To(Equal(int64(1010)))
+				Expect(result.Step).To(Equal(int64(10)))
+				Expect(result.Series).To(Equal(map[string][]HistoryPoint{
+					"mem.percent": []HistoryPoint{
+						{Time: 1000, Value: 10},
+						{Time: 1010, Value: 20},
+					},
+					"load.0": []HistoryPoint{
+						{Time: 1000, Value: 0.5},
+						{Time: 1010, Value: 0.5},
+					},
+					"disk.system.percent": []HistoryPoint{
+						{Time: 1000, Value: 30},
+						{Time: 1010, Value: 30},
+					},
+				}))
+			})
+
+			It("returns all metrics when none are requested", func() {
+				history.Add(time.Unix(1000, 0), buildVitals("10"))
+
+				result := history.Query(HistoryQuery{})
+				Expect(result.Series).To(HaveKey("cpu.user"))
+				Expect(result.Series).To(HaveKey("mem.kb"))
+				Expect(result.Series).To(HaveKey("load.2"))
+			})
+
+			It("only includes samples within requested time range", func() {
+				history.Add(time.Unix(1000, 0), buildVitals("10"))
+				history.Add(time.Unix(1010, 0), buildVitals("20"))
+				history.Add(time.Unix(1020, 0), buildVitals("30"))
+
+				result := history.Query(HistoryQuery{
+					From:    1005,
+					To:      1015,
+					Metrics: []string{"mem.percent"},
+				})
+
+				Expect(result.Series["mem.percent"]).To(Equal([]HistoryPoint{
+					{Time: 1005, Value: 20},
+				}))
+			})
+
+			It("averages samples into at most max points", func() {
+				for i, percent := range []string{"10", "20", "30", "40"} {
+					history.Add(time.Unix(int64(1000+i*10), 0), buildVitals(percent))
+				}
+
+				result := history.Query(HistoryQuery{
+					Metrics:   []string{"mem.percent"},
+					MaxPoints: 2,
+				})
+
+				Expect(result.Step).To(Equal(int64(16)))
+				Expect(result.Series["mem.percent"]).To(Equal([]HistoryPoint{
+					{Time: 1000, Value: 15},
+					{Time: 1016, Value: 35},
+				}))
+			})
+
+			Context("when history is disabled", func() {
+				BeforeEach(func() {
+					options.Disabled = true
+				})
+
+				It("does not record samples", func() {
+					history.Add(time.Unix(1000, 0), buildVitals("10"))
+					Expect(history.Query(HistoryQuery{}).Series).To(BeEmpty())
+				})
+			})
+
+			It("drops oldest samples once retention is reached", func() {
+				for i := 0; i < 8; i++ {
+					history.Add(time.Unix(int64(1000+i*10), 0), buildVitals("10"))
+				}
+
+				result := history.Query(HistoryQuery{Metrics: []string{"mem.percent"}})
+				Expect(result.From).To(Equal(int64(1020)))
+				Expect(result.To).To(Equal(int64(1070)))
+				Expect(result.Series["mem.percent"]).To(HaveLen(6))
+			})
+		})
+
+		Describe("Save and Load", func() {
+			BeforeEach(func() {
+				options.Persist = true
+			})
+
+			It("restores saved samples", func() {
+				now := time.Now()
+				history.Add(now.Add(-10*time.Second), buildVitals("10"))
+				history.Add(now, buildVitals("20"))
+
+				err := history.Save()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fs.FileExists("/fake-dir/vitals-history.json")).To(BeTrue())
+
+				restored := NewHistory(options, fs, "/fake-dir/vitals-history.json", boshlog.NewLogger(boshlog.LevelNone))
+
+				err = restored.Load()
+				Expect(err).ToNot(HaveOccurred())
+
+				result := restored.Query(HistoryQuery{Metrics: []string{"mem.percent"}})
+				Expect(result.Series["mem.percent"]).To(HaveLen(2))
+			})
+
+			It("does not restore samples older than retention", func() {
+				history.Add(time.Now().Add(-time.Hour), buildVitals("10"))
+
+				err := history.Save()
+				Expect(err).ToNot(HaveOccurred())
+
+				restored := NewHistory(options, fs, "/fake-dir/vitals-history.json", boshlog.NewLogger(boshlog.LevelNone))
+
+				err = restored.Load()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(restored.Query(HistoryQuery{}).Series).To(BeEmpty())
+			})
+
+			It("does not fail loading when file does not exist", func() {
+				err := history.Load()
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("does not restore samples when history is not persisted", func() {
+				history.Add(time.Now(), buildVitals("10"))
+
+				err := history.Save()
+				Expect(err).ToNot(HaveOccurred())
+
+				options.Persist = false
+				restored := NewHistory(options, fs, "/fake-dir/vitals-history.json", boshlog.NewLogger(boshlog.LevelNone))
+
+				err = restored.Load()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(restored.Query(HistoryQuery{}).Series).To(BeEmpty())
+			})
+
+			It("restores metrics that were not sampled every time", func() {
+				now := time.Now()
+				history.Add(now.Add(-10*time.Second), buildVitals("10"))
+
+				vitals := buildVitals("20")
+				vitals.Disk["persistent"] = SpecificDiskVitals{Percent: "40"}
+				history.Add(now, vitals)
+
+				err := history.Save()
+				Expect(err).ToNot(HaveOccurred())
+
+				restored := NewHistory(options, fs, "/fake-dir/vitals-history.json", boshlog.NewLogger(boshlog.LevelNone))
+
+				err = restored.Load()
+				Expect(err).ToNot(HaveOccurred())
+
+				result := restored.Query(HistoryQuery{
+					Metrics: []string{"mem.percent", "disk.persistent.percent"},
+				})
+				Expect(result.Series["mem.percent"]).To(HaveLen(2))
+				Expect(result.Series["disk.persistent.percent"]).To(Equal([]HistoryPoint{
+					{Time: now.Unix(), Value: 40},
+				}))
+			})
+
+			It("saves history to temporary file before moving it into place", func() {
+				history.Add(time.Now(), buildVitals("10"))
+
+				err := history.Save()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fs.RenameOldPaths).To(Equal([]string{"/fake-dir/vitals-history.json.tmp"}))
+				Expect(fs.RenameNewPaths).To(Equal([]string{"/fake-dir/vitals-history.json"}))
+				Expect(fs.FileExists("/fake-dir/vitals-history.json.tmp")).To(BeFalse())
+			})
+
+			It("returns error when moving saved history into place fails", func() {
+				fs.RenameError = errors.New("fake-rename-err")
+
+				err := history.Save()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-rename-err"))
+			})
+
+			It("returns error when saving fails", func() {
+				fs.WriteToFileError = errors.New("fake-write-err")
+
+				err := history.Save()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-write-err"))
+			})
+		})
+	})
+}
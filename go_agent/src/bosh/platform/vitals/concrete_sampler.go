@@ -0,0 +1,52 @@
+package vitals
+
+import (
+	"time"
+
+	boshlog "bosh/logger"
+)
+
+const samplerLogTag = "vitalsSampler"
+
+type concreteSampler struct {
+	service  Service
+	history  History
+	interval time.Duration
+	logger   boshlog.Logger
+}
+
+func NewSampler(
+	service Service,
+	history History,
+	interval time.Duration,
+	logger boshlog.Logger,
+) Sampler {
+	return concreteSampler{
+		service:  service,
+		history:  history,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+func (s concreteSampler) Run(handler SampleHandler) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		now := <-ticker.C
+		s.sample(now, handler)
+	}
+}
+
+func (s concreteSampler) sample(now time.Time, handler SampleHandler) {
+	vitals, err := s.service.Get()
+	if err != nil {
+		s.logger.Error(samplerLogTag, "Getting vitals: %s", err.Error())
+		return
+	}
+
+	s.history.Add(now, vitals)
+
+	handler(now, vitals)
+}
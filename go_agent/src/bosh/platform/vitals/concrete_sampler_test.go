@@ -0,0 +1,61 @@
+package vitals_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "bosh/logger"
+	. "bosh/platform/vitals"
+	fakevitals "bosh/platform/vitals/fakes"
+)
+
+func init() {
+	Describe("concreteSampler", func() {
+		var (
+			service *fakevitals.FakeService
+			history *fakevitals.FakeHistory
+			sampler Sampler
+		)
+
+		BeforeEach(func() {
+			service = fakevitals.NewFakeService()
+			history = fakevitals.NewFakeHistory()
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			sampler = NewSampler(service, history, 5*time.Millisecond, logger)
+		})
+
+		// Handler blocks after first sample to stop further sampling
+		runUntilFirstSample := func() chan Vitals {
+			samplesCh := make(chan Vitals)
+
+			go sampler.Run(func(sampledAt time.Time, vitals Vitals) {
+				samplesCh <- vitals
+				select {}
+			})
+
+			return samplesCh
+		}
+
+		Describe("Run", func() {
+			It("records sample into history and passes it to handler", func() {
+				service.GetVitals = Vitals{Load: []string{"0.50", "0.25", "0.10"}}
+
+				samplesCh := runUntilFirstSample()
+
+				Eventually(samplesCh).Should(Receive(Equal(service.GetVitals)))
+				Expect(history.AddedVitals).To(Equal([]Vitals{service.GetVitals}))
+			})
+
+			It("does not pass sample to handler when getting vitals fails", func() {
+				service.GetErr = errors.New("fake-get-err")
+
+				samplesCh := runUntilFirstSample()
+
+				Consistently(samplesCh, 50*time.Millisecond).ShouldNot(Receive())
+			})
+		})
+	})
+}
@@ -0,0 +1,39 @@
+package fakes
+
+import (
+	"time"
+
+	boshvitals "bosh/platform/vitals"
+)
+
+type FakeHistory struct {
+	QueryQuery  boshvitals.HistoryQuery
+	QueryResult boshvitals.HistoryResult
+
+	AddedVitals []boshvitals.Vitals
+}
+
+func NewFakeHistory() *FakeHistory {
+	return &FakeHistory{}
+}
+
+func (h *FakeHistory) Run() error {
+	return nil
+}
+
+func (h *FakeHistory) Add(sampledAt time.Time, vitals boshvitals.Vitals) {
+	h.AddedVitals = append(h.AddedVitals, vitals)
+}
+
+func (h *FakeHistory) Query(query boshvitals.HistoryQuery) boshvitals.HistoryResult {
+	h.QueryQuery = query
+	return h.QueryResult
+}
+
+func (h *FakeHistory) Load() error {
+	return nil
+}
+
+func (h *FakeHistory) Save() error {
+	return nil
+}
@@ -0,0 +1,25 @@
+package fakes
+
+import (
+	"time"
+
+	boshvitals "bosh/platform/vitals"
+)
+
+type FakeSampler struct {
+	// Samples are passed to handler when Run is called
+	Samples []boshvitals.Vitals
+	RunErr  error
+}
+
+func NewFakeSampler() *FakeSampler {
+	return &FakeSampler{}
+}
+
+func (s *FakeSampler) Run(handler boshvitals.SampleHandler) error {
+	for _, vitals := range s.Samples {
+		handler(time.Now(), vitals)
+	}
+
+	return s.RunErr
+}
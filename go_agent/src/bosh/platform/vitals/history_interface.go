@@ -0,0 +1,50 @@
+package vitals
+
+import (
+	"time"
+)
+
+// History keeps vitals sampled on an interval so that
+// spikes between heartbeats can be inspected later
+type History interface {
+	// Run blocks periodically saving history when it is persisted
+	Run() error
+
+	Add(sampledAt time.Time, vitals Vitals)
+	Query(query HistoryQuery) HistoryResult
+
+	// Load restores persisted history; it must be called
+	// before samples are added since loaded samples are appended
+	Load() error
+	Save() error
+}
+
+// HistoryQuery selects metrics sampled between From and To (unix seconds).
+// Metrics are dot separated paths into vitals JSON, e.g. "cpu.user",
+// "load.0" (1 minute load) or "disk.persistent.percent".
+type HistoryQuery struct {
+	// Zero From or To leave range open on that side
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+
+	// Empty Metrics selects all recorded metrics
+	Metrics []string `json:"metrics"`
+
+	// Samples are averaged into at most MaxPoints points per metric
+	MaxPoints int `json:"max_points"`
+}
+
+type HistoryResult struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+
+	// Step is the number of seconds each point covers
+	Step int64 `json:"step"`
+
+	Series map[string][]HistoryPoint `json:"series"`
+}
+
+type HistoryPoint struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
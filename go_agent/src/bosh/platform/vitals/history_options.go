@@ -0,0 +1,56 @@
+package vitals
+
+import (
+	"time"
+)
+
+const (
+	DefaultHistoryIntervalSeconds        = 10
+	DefaultHistoryRetentionSeconds       = 24 * 60 * 60
+	DefaultHistoryPersistIntervalSeconds = 5 * 60
+)
+
+type HistoryOptions struct {
+	// Disabled turns off recording vitals history;
+	// vitals are still sampled e.g. for resource alerts
+	Disabled bool
+
+	// Vitals are sampled every IntervalSeconds and
+	// kept in memory for RetentionSeconds
+	IntervalSeconds  int
+	RetentionSeconds int
+
+	// Persist saves samples to disk every PersistIntervalSeconds
+	// so that history survives agent restarts
+	Persist                bool
+	PersistIntervalSeconds int
+}
+
+// Interval is how often vitals are sampled
+func (o HistoryOptions) Interval() time.Duration {
+	return historyOptionSeconds(o.IntervalSeconds, DefaultHistoryIntervalSeconds)
+}
+
+func (o HistoryOptions) retention() time.Duration {
+	return historyOptionSeconds(o.RetentionSeconds, DefaultHistoryRetentionSeconds)
+}
+
+func (o HistoryOptions) persistInterval() time.Duration {
+	return historyOptionSeconds(o.PersistIntervalSeconds, DefaultHistoryPersistIntervalSeconds)
+}
+
+// capacity is the number of samples that fit into retention period
+func (o HistoryOptions) capacity() int {
+	capacity := int(o.retention() / o.Interval())
+	if capacity < 1 {
+		return 1
+	}
+	return capacity
+}
+
+func historyOptionSeconds(seconds, defaultSeconds int) time.Duration {
+	if seconds <= 0 {
+		return time.Duration(defaultSeconds) * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,15 @@
+package vitals
+
+import (
+	"time"
+)
+
+type SampleHandler func(sampledAt time.Time, vitals Vitals)
+
+// Sampler gets vitals on an interval so that vitals history
+// and everything else watching vitals see the same samples
+type Sampler interface {
+	// Run blocks recording each sample into history
+	// and then passing it to handler
+	Run(handler SampleHandler) error
+}
@@ -68,18 +68,62 @@ func (s concreteService) Get() (vitals Vitals, err error) {
 			fmt.Sprintf("%.2f", loadStats.Five),
 			fmt.Sprintf("%.2f", loadStats.Fifteen),
 		},
-		CPU: CPUVitals{
-			User: cpuStats.UserPercent().FormatFractionOf100(1),
-			Sys:  cpuStats.SysPercent().FormatFractionOf100(1),
-			Wait: cpuStats.WaitPercent().FormatFractionOf100(1),
-		},
+		CPU:  createCPUVitals(cpuStats),
 		Mem:  createMemVitals(memStats),
 		Swap: createMemVitals(swapStats),
 		Disk: diskStats,
 	}
+
+	s.addExtendedVitals(&vitals)
 	return
 }
 
+// addExtendedVitals fills in optional sections; failing to collect any
+// of them does not fail the whole vitals collection.
+func (s concreteService) addExtendedVitals(vitals *Vitals) {
+	perCPUStats, err := s.statsCollector.GetPerCPUStats()
+	if err == nil {
+		for _, cpuStats := range perCPUStats {
+			vitals.CPUs = append(vitals.CPUs, createCPUVitals(cpuStats))
+		}
+	}
+
+	diskIOStats, err := s.statsCollector.GetDiskIOStats()
+	if err == nil && len(diskIOStats) > 0 {
+		vitals.DiskIO = make(DiskIOVitals, len(diskIOStats))
+		for _, stat := range diskIOStats {
+			vitals.DiskIO[stat.Device] = SpecificDiskIOVitals{
+				ReadOps:    fmt.Sprintf("%d", stat.ReadOps),
+				WriteOps:   fmt.Sprintf("%d", stat.WriteOps),
+				ReadBytes:  fmt.Sprintf("%d", stat.ReadBytes),
+				WriteBytes: fmt.Sprintf("%d", stat.WriteBytes),
+			}
+		}
+	}
+
+	networkStats, err := s.statsCollector.GetNetworkStats()
+	if err == nil && len(networkStats) > 0 {
+		vitals.Network = make(NetworkVitals, len(networkStats))
+		for _, stat := range networkStats {
+			vitals.Network[stat.Interface] = SpecificNetworkVitals{
+				BytesReceived:   fmt.Sprintf("%d", stat.BytesReceived),
+				BytesSent:       fmt.Sprintf("%d", stat.BytesSent),
+				PacketsReceived: fmt.Sprintf("%d", stat.PacketsReceived),
+				PacketsSent:     fmt.Sprintf("%d", stat.PacketsSent),
+				ErrorsReceived:  fmt.Sprintf("%d", stat.ErrorsReceived),
+				ErrorsSent:      fmt.Sprintf("%d", stat.ErrorsSent),
+			}
+		}
+	}
+
+	uptimeStats, err := s.statsCollector.GetUptimeStats()
+	if err == nil && uptimeStats.Secs > 0 {
+		vitals.Uptime = &UptimeVitals{
+			Secs: fmt.Sprintf("%d", uptimeStats.Secs),
+		}
+	}
+}
+
 func (s concreteService) getDiskStats() (diskStats DiskVitals, err error) {
 	disks := map[string]string{
 		"/": "system",
@@ -116,6 +160,14 @@ func (s concreteService) addDiskStats(diskStats DiskVitals, path, name string) (
 	return
 }
 
+func createCPUVitals(cpuStats boshstats.CPUStats) CPUVitals {
+	return CPUVitals{
+		User: cpuStats.UserPercent().FormatFractionOf100(1),
+		Sys:  cpuStats.SysPercent().FormatFractionOf100(1),
+		Wait: cpuStats.WaitPercent().FormatFractionOf100(1),
+	}
+}
+
 func createMemVitals(memUsage boshstats.Usage) MemoryVitals {
 	return MemoryVitals{
 		Percent: memUsage.Percent().FormatFractionOf100(0),
@@ -1,6 +1,8 @@
 package vitals_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -117,5 +119,72 @@ func init() {
 			_, err := service.Get()
 			Expect(err).To(HaveOccurred())
 		})
+		It("leaves out extended vitals that are not available", func() {
+			statsCollector, service := buildVitalsService()
+			statsCollector.PerCPUStatsErr = errors.New("fake-per-cpu-err")
+			statsCollector.NetworkStatsErr = errors.New("fake-network-err")
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			boshassert.LacksJSONKey(GinkgoT(), vitals, "cpus")
+			boshassert.LacksJSONKey(GinkgoT(), vitals, "disk_io")
+			boshassert.LacksJSONKey(GinkgoT(), vitals, "network")
+			boshassert.LacksJSONKey(GinkgoT(), vitals, "uptime")
+		})
+		It("getting extended vitals", func() {
+			statsCollector, service := buildVitalsService()
+			statsCollector.PerCPUStats = []boshstats.CPUStats{
+				{User: 20, Sys: 5, Wait: 2, Total: 100},
+				{User: 40, Sys: 10, Wait: 0, Total: 100},
+			}
+			statsCollector.DiskIOStats = []boshstats.DiskIOStats{
+				{Device: "sda", ReadOps: 10, WriteOps: 20, ReadBytes: 1024, WriteBytes: 2048},
+			}
+			statsCollector.NetworkStats = []boshstats.NetworkStats{
+				{
+					Interface:       "eth0",
+					BytesReceived:   100,
+					BytesSent:       200,
+					PacketsReceived: 3,
+					PacketsSent:     4,
+					ErrorsReceived:  1,
+					ErrorsSent:      2,
+				},
+			}
+			statsCollector.UptimeStats = boshstats.UptimeStats{Secs: 3600}
+
+			vitals, err := service.Get()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(vitals.CPUs).To(Equal([]CPUVitals{
+				{User: "20.0", Sys: "5.0", Wait: "2.0"},
+				{User: "40.0", Sys: "10.0", Wait: "0.0"},
+			}))
+
+			boshassert.MatchesJSONMap(GinkgoT(), vitals.DiskIO, map[string]interface{}{
+				"sda": map[string]string{
+					"read_ops":    "10",
+					"write_ops":   "20",
+					"read_bytes":  "1024",
+					"write_bytes": "2048",
+				},
+			})
+
+			boshassert.MatchesJSONMap(GinkgoT(), vitals.Network, map[string]interface{}{
+				"eth0": map[string]string{
+					"bytes_received":   "100",
+					"bytes_sent":       "200",
+					"packets_received": "3",
+					"packets_sent":     "4",
+					"errors_received":  "1",
+					"errors_sent":      "2",
+				},
+			})
+
+			boshassert.MatchesJSONMap(GinkgoT(), vitals.Uptime, map[string]interface{}{
+				"secs": "3600",
+			})
+		})
 	})
 }
@@ -0,0 +1,59 @@
+package vitals
+
+import (
+	"encoding/json"
+	"strconv"
+
+	bosherr "bosh/errors"
+)
+
+// Values converts vitals JSON into numeric values keyed by
+// dot separated paths, e.g. "disk.system.percent" or "load.0"
+func (vitals Vitals) Values() (map[string]float64, error) {
+	bytes, err := json.Marshal(vitals)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling vitals")
+	}
+
+	var vitalsJSON interface{}
+
+	err = json.Unmarshal(bytes, &vitalsJSON)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling vitals")
+	}
+
+	values := map[string]float64{}
+	flattenVitalsValue("", vitalsJSON, values)
+
+	return values, nil
+}
+
+func flattenVitalsValue(path string, value interface{}, values map[string]float64) {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		for key, nestedValue := range typedValue {
+			flattenVitalsValue(joinMetricPath(path, key), nestedValue, values)
+		}
+
+	case []interface{}:
+		for i, nestedValue := range typedValue {
+			flattenVitalsValue(joinMetricPath(path, strconv.Itoa(i)), nestedValue, values)
+		}
+
+	case string:
+		number, err := strconv.ParseFloat(typedValue, 64)
+		if err == nil {
+			values[path] = number
+		}
+
+	case float64:
+		values[path] = typedValue
+	}
+}
+
+func joinMetricPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
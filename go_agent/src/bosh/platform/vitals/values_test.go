@@ -0,0 +1,47 @@
+package vitals_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh/platform/vitals"
+)
+
+func init() {
+	Describe("Vitals", func() {
+		Describe("Values", func() {
+			It("returns numeric values keyed by vitals JSON path", func() {
+				vitals := Vitals{
+					CPU:  CPUVitals{User: "1.5", Sys: "2.0", Wait: "0.5"},
+					Disk: DiskVitals{"persistent": SpecificDiskVitals{Percent: "91", InodePercent: "3"}},
+					Load: []string{"0.50", "0.25", "0.10"},
+					Mem:  MemoryVitals{Percent: "70", Kb: "700"},
+					Swap: MemoryVitals{Percent: "10", Kb: "100"},
+				}
+
+				values, err := vitals.Values()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).To(Equal(map[string]float64{
+					"cpu.user":                      1.5,
+					"cpu.sys":                       2.0,
+					"cpu.wait":                      0.5,
+					"disk.persistent.percent":       91,
+					"disk.persistent.inode_percent": 3,
+					"load.0":                        0.5,
+					"load.1":                        0.25,
+					"load.2":                        0.1,
+					"mem.percent":                   70,
+					"mem.kb":                        700,
+					"swap.percent":                  10,
+					"swap.kb":                       100,
+				}))
+			})
+
+			It("skips values that are not numbers", func() {
+				values, err := Vitals{Mem: MemoryVitals{Percent: "n/a"}}.Values()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(values).ToNot(HaveKey("mem.percent"))
+			})
+		})
+	})
+}
@@ -6,6 +6,12 @@ type Vitals struct {
 	Load []string     `json:"load,omitempty"`
 	Mem  MemoryVitals `json:"mem"`
 	Swap MemoryVitals `json:"swap"`
+
+	// Extended vitals are omitted when the platform cannot provide them
+	CPUs    []CPUVitals   `json:"cpus,omitempty"`
+	DiskIO  DiskIOVitals  `json:"disk_io,omitempty"`
+	Network NetworkVitals `json:"network,omitempty"`
+	Uptime  *UptimeVitals `json:"uptime,omitempty"`
 }
 
 type CPUVitals struct {
@@ -21,6 +27,30 @@ type SpecificDiskVitals struct {
 	Percent      string `json:"percent,omitempty"`
 }
 
+type DiskIOVitals map[string]SpecificDiskIOVitals
+
+type SpecificDiskIOVitals struct {
+	ReadBytes  string `json:"read_bytes"`
+	ReadOps    string `json:"read_ops"`
+	WriteBytes string `json:"write_bytes"`
+	WriteOps   string `json:"write_ops"`
+}
+
+type NetworkVitals map[string]SpecificNetworkVitals
+
+type SpecificNetworkVitals struct {
+	BytesReceived   string `json:"bytes_received"`
+	BytesSent       string `json:"bytes_sent"`
+	ErrorsReceived  string `json:"errors_received"`
+	ErrorsSent      string `json:"errors_sent"`
+	PacketsReceived string `json:"packets_received"`
+	PacketsSent     string `json:"packets_sent"`
+}
+
+type UptimeVitals struct {
+	Secs string `json:"secs"`
+}
+
 type MemoryVitals struct {
 	Kb      string `json:"kb,omitempty"`
 	Percent string `json:"percent,omitempty"`
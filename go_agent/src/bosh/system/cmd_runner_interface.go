@@ -1,6 +1,7 @@
 package system
 
 import (
+	"io"
 	"time"
 )
 
@@ -9,6 +10,11 @@ type Command struct {
 	Args       []string
 	Env        map[string]string
 	WorkingDir string
+
+	// When set output is streamed to writers
+	// instead of being included in the Result
+	Stdout io.Writer
+	Stderr io.Writer
 }
 
 type Process interface {
@@ -34,8 +34,14 @@ func newExecProcess(cmd *exec.Cmd, logger boshlog.Logger) *execProcess {
 }
 
 func (p *execProcess) Start() error {
-	p.cmd.Stdout = p.stdoutWriter
-	p.cmd.Stderr = p.stderrWriter
+	// Output might already be streamed somewhere else
+	if p.cmd.Stdout == nil {
+		p.cmd.Stdout = p.stdoutWriter
+	}
+
+	if p.cmd.Stderr == nil {
+		p.cmd.Stderr = p.stderrWriter
+	}
 
 	cmdString := strings.Join(p.cmd.Args, " ")
 	p.logger.Debug(execProcessLogTag, "Running command: %s", cmdString)
@@ -253,6 +259,14 @@ func (r execCmdRunner) buildComplexCommand(cmd Command) *exec.Cmd {
 
 	execCmd.Dir = cmd.WorkingDir
 
+	if cmd.Stdout != nil {
+		execCmd.Stdout = cmd.Stdout
+	}
+
+	if cmd.Stderr != nil {
+		execCmd.Stderr = cmd.Stderr
+	}
+
 	env := os.Environ()
 	for name, value := range cmd.Env {
 		env = append(env, fmt.Sprintf("%s=%s", name, value))
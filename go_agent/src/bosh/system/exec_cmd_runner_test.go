@@ -1,6 +1,7 @@
 package system_test
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"regexp"
@@ -105,6 +106,28 @@ func init() {
 				Expect(result.Stdout).To(ContainSubstring("PATH="))
 			})
 
+			It("streams stdout and stderr to writers when they are set", func() {
+				stdout := bytes.NewBufferString("")
+				stderr := bytes.NewBufferString("")
+
+				cmd := Command{
+					Name:   "bash",
+					Args:   []string{"-c", "echo stdout >&1; echo stderr >&2"},
+					Stdout: stdout,
+					Stderr: stderr,
+				}
+				process, err := runner.RunComplexCommandAsync(cmd)
+				Expect(err).ToNot(HaveOccurred())
+
+				result := <-process.Wait()
+				Expect(result.Error).ToNot(HaveOccurred())
+				Expect(result.Stdout).To(BeEmpty())
+				Expect(result.Stderr).To(BeEmpty())
+
+				Expect(stdout.String()).To(Equal("stdout\n"))
+				Expect(stderr.String()).To(Equal("stderr\n"))
+			})
+
 			It("changes working dir", func() {
 				cmd := Command{Name: "bash", Args: []string{"-c", "echo $PWD"}, WorkingDir: "/tmp"}
 				process, err := runner.RunComplexCommandAsync(cmd)
@@ -2,6 +2,7 @@ package fakes
 
 import (
 	"fmt"
+	"io"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,10 @@ type FakeProcess struct {
 	TerminatedNicelyCallBack       func(*FakeProcess)
 	TerminateNicelyKillGracePeriod time.Duration
 	TerminateNicelyErr             error
+
+	// Set from command that started the process
+	stdoutWriter io.Writer
+	stderrWriter io.Writer
 }
 
 func (p *FakeProcess) Wait() <-chan boshsys.Result {
@@ -56,7 +61,32 @@ func (p *FakeProcess) Wait() <-chan boshsys.Result {
 	if p.TerminatedNicelyCallBack == nil {
 		p.WaitCh <- p.WaitResult
 	}
-	return p.WaitCh
+
+	if p.stdoutWriter == nil && p.stderrWriter == nil {
+		return p.WaitCh
+	}
+
+	// Output is streamed instead of being included in result
+	// like it would be with a real process
+	streamedCh := make(chan boshsys.Result, 1)
+
+	go func() {
+		result := <-p.WaitCh
+
+		if p.stdoutWriter != nil {
+			p.stdoutWriter.Write([]byte(result.Stdout))
+			result.Stdout = ""
+		}
+
+		if p.stderrWriter != nil {
+			p.stderrWriter.Write([]byte(result.Stderr))
+			result.Stderr = ""
+		}
+
+		streamedCh <- result
+	}()
+
+	return streamedCh
 }
 
 func (p *FakeProcess) TerminateNicely(killGracePeriod time.Duration) error {
@@ -99,7 +129,27 @@ func (r *FakeCmdRunner) RunComplexCommandAsync(cmd boshsys.Command) (boshsys.Pro
 		panic(fmt.Sprintf("Failed to find process for %s", fullCmd))
 	}
 
-	return results[0], nil
+	// Last process is returned for all subsequent runs
+	process := results[0]
+	if len(results) > 1 {
+		r.processes[fullCmd] = results[1:]
+	}
+
+	process.stdoutWriter = cmd.Stdout
+	process.stderrWriter = cmd.Stderr
+
+	return process, nil
+}
+
+// RunComplexCommandsCount can be used while commands are being run concurrently
+func (r *FakeCmdRunner) RunComplexCommandsCount() int {
+	r.commandResultsLock.Lock()
+	defer r.commandResultsLock.Unlock()
+
+	r.processesLock.Lock()
+	defer r.processesLock.Unlock()
+
+	return len(r.RunComplexCommands)
 }
 
 func (r *FakeCmdRunner) RunCommand(cmdName string, args ...string) (string, string, int, error) {
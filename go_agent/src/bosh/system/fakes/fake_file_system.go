@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	gouuid "github.com/nu7hatch/gouuid"
 
@@ -31,6 +35,7 @@ type FakeFileSystem struct {
 
 	ReadFileError    error
 	WriteToFileError error
+	CreateFileError  error
 	SymlinkError     error
 
 	MkdirAllError       error
@@ -41,6 +46,10 @@ type FakeFileSystem struct {
 
 	CopyFileError error
 
+	StatErr error
+
+	ChtimesErr error
+
 	RenameError    error
 	RenameOldPaths []string
 	RenameNewPaths []string
@@ -58,6 +67,8 @@ type FakeFileSystem struct {
 
 	GlobErr  error
 	globsMap map[string][][]string
+
+	WalkErr error
 }
 
 type FakeFileStats struct {
@@ -66,6 +77,7 @@ type FakeFileStats struct {
 	Content       []byte
 	SymlinkTarget string
 	FileType      FakeFileType
+	ModTime       time.Time
 }
 
 func (stats FakeFileStats) StringContents() string {
@@ -172,6 +184,37 @@ func (fs *FakeFileSystem) WriteFile(path string, content []byte) (err error) {
 	return nil
 }
 
+func (fs *FakeFileSystem) CreateFile(path string) (io.WriteCloser, error) {
+	fs.filesLock.Lock()
+	defer fs.filesLock.Unlock()
+
+	if fs.CreateFileError != nil {
+		return nil, fs.CreateFileError
+	}
+
+	stats := fs.getOrCreateFile(path)
+	stats.FileType = FakeFileTypeFile
+	stats.Content = []byte{}
+
+	return fakeFile{fs: fs, stats: stats}, nil
+}
+
+// fakeFile appends written content to the file stats
+type fakeFile struct {
+	fs    *FakeFileSystem
+	stats *FakeFileStats
+}
+
+func (f fakeFile) Write(p []byte) (int, error) {
+	f.fs.filesLock.Lock()
+	defer f.fs.filesLock.Unlock()
+
+	f.stats.Content = append(f.stats.Content, p...)
+	return len(p), nil
+}
+
+func (f fakeFile) Close() error { return nil }
+
 func (fs *FakeFileSystem) ConvergeFileContents(path string, content []byte) (bool, error) {
 	fs.filesLock.Lock()
 	defer fs.filesLock.Unlock()
@@ -211,10 +254,50 @@ func (fs *FakeFileSystem) ReadFile(path string) ([]byte, error) {
 	return nil, errors.New("File not found")
 }
 
+func (fs *FakeFileSystem) OpenFile(path string) (io.ReadCloser, error) {
+	content, err := fs.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ioutil.NopCloser(bytes.NewReader(content)), nil
+}
+
 func (fs *FakeFileSystem) FileExists(path string) bool {
 	return fs.GetFileTestStat(path) != nil
 }
 
+func (fs *FakeFileSystem) Stat(path string) (os.FileInfo, error) {
+	if fs.StatErr != nil {
+		return nil, fs.StatErr
+	}
+
+	stats := fs.GetFileTestStat(path)
+	if stats == nil {
+		return nil, errors.New("File not found")
+	}
+
+	return newFakeFileInfo(path, stats), nil
+}
+
+func (fs *FakeFileSystem) Chtimes(path string, atime, mtime time.Time) error {
+	fs.filesLock.Lock()
+	defer fs.filesLock.Unlock()
+
+	if fs.ChtimesErr != nil {
+		return fs.ChtimesErr
+	}
+
+	stats := fs.files[path]
+	if stats == nil {
+		return errors.New("File not found")
+	}
+
+	stats.ModTime = mtime
+
+	return nil
+}
+
 func (fs *FakeFileSystem) Rename(oldPath, newPath string) error {
 	fs.filesLock.Lock()
 	defer fs.filesLock.Unlock()
@@ -239,6 +322,7 @@ func (fs *FakeFileSystem) Rename(oldPath, newPath string) error {
 	newStats.Content = stats.Content
 	newStats.FileMode = stats.FileMode
 	newStats.FileType = stats.FileType
+	newStats.ModTime = stats.ModTime
 
 	// Ignore error from RemoveAll
 	fs.removeAll(oldPath)
@@ -391,6 +475,58 @@ func (fs *FakeFileSystem) SetGlob(pattern string, matches ...[]string) {
 	fs.globsMap[pattern] = matches
 }
 
+func (fs *FakeFileSystem) Walk(root string, walkFunc filepath.WalkFunc) error {
+	if fs.WalkErr != nil {
+		return fs.WalkErr
+	}
+
+	fs.filesLock.Lock()
+
+	var paths []string
+	for path := range fs.files {
+		if path == root || strings.HasPrefix(path, root+"/") {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	infos := map[string]os.FileInfo{}
+	for _, path := range paths {
+		infos[path] = newFakeFileInfo(path, fs.files[path])
+	}
+
+	fs.filesLock.Unlock()
+
+	if len(paths) == 0 {
+		return walkFunc(root, nil, errors.New("File not found"))
+	}
+
+	var skippedDirs []string
+
+	for _, path := range paths {
+		skipped := false
+		for _, dir := range skippedDirs {
+			if strings.HasPrefix(path, dir+"/") {
+				skipped = true
+			}
+		}
+
+		if skipped {
+			continue
+		}
+
+		err := walkFunc(path, infos[path], nil)
+		if err == filepath.SkipDir && infos[path].IsDir() {
+			skippedDirs = append(skippedDirs, path)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (fs *FakeFileSystem) getOrCreateFile(path string) *FakeFileStats {
 	stats := fs.files[path]
 	if stats == nil {
@@ -399,3 +535,35 @@ func (fs *FakeFileSystem) getOrCreateFile(path string) *FakeFileStats {
 	}
 	return stats
 }
+
+type fakeFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func newFakeFileInfo(path string, stats *FakeFileStats) fakeFileInfo {
+	mode := stats.FileMode
+
+	switch stats.FileType {
+	case FakeFileTypeDir:
+		mode |= os.ModeDir
+	case FakeFileTypeSymlink:
+		mode |= os.ModeSymlink
+	}
+
+	return fakeFileInfo{
+		name:    filepath.Base(path),
+		size:    int64(len(stats.Content)),
+		mode:    mode,
+		modTime: stats.ModTime,
+	}
+}
+
+func (fi fakeFileInfo) Name() string       { return fi.name }
+func (fi fakeFileInfo) Size() int64        { return fi.size }
+func (fi fakeFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi fakeFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fakeFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi fakeFileInfo) Sys() interface{}   { return nil }
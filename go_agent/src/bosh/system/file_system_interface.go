@@ -1,7 +1,10 @@
 package system
 
 import (
+	"io"
 	"os"
+	"path/filepath"
+	"time"
 )
 
 type FileSystem interface {
@@ -19,10 +22,23 @@ type FileSystem interface {
 	WriteFile(path string, content []byte) (err error)
 	ConvergeFileContents(path string, content []byte) (written bool, err error)
 
+	// CreateFile truncates or creates file (and its dir) for incremental writing
+	// e.g. to stream command output without keeping it in memory
+	CreateFile(path string) (file io.WriteCloser, err error)
+
 	ReadFileString(path string) (content string, err error)
 	ReadFile(path string) (content []byte, err error)
 
+	// OpenFile opens file for incremental reading
+	// e.g. to hash file contents without keeping them in memory
+	OpenFile(path string) (file io.ReadCloser, err error)
+
 	FileExists(path string) bool
+	Stat(path string) (info os.FileInfo, err error)
+
+	// Chtimes changes access and modification times of a file
+	// e.g. to keep track of recently used files across restarts
+	Chtimes(path string, atime, mtime time.Time) (err error)
 
 	Rename(oldPath, newPath string) (err error)
 
@@ -39,4 +55,8 @@ type FileSystem interface {
 	TempDir(prefix string) (path string, err error)
 
 	Glob(pattern string) (matches []string, err error)
+
+	// Walk calls walkFunc for root and every file or dir under it
+	// in lexical order; symlinks are not followed
+	Walk(root string, walkFunc filepath.WalkFunc) (err error)
 }
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	bosherr "bosh/errors"
 	boshlog "bosh/logger"
@@ -102,6 +103,20 @@ func (fs osFileSystem) WriteFile(path string, content []byte) (err error) {
 	return
 }
 
+func (fs osFileSystem) CreateFile(path string) (io.WriteCloser, error) {
+	err := fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating dir to create file")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating file %s", path)
+	}
+
+	return file, nil
+}
+
 func (fs osFileSystem) ConvergeFileContents(path string, content []byte) (written bool, err error) {
 	if fs.filesAreIdentical(content, path) {
 		return
@@ -162,6 +177,17 @@ func (fs osFileSystem) ReadFile(path string) (content []byte, err error) {
 	return
 }
 
+func (fs osFileSystem) OpenFile(path string) (io.ReadCloser, error) {
+	fs.logger.Debug(fs.logTag, "Opening file %s", path)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Opening file %s", path)
+	}
+
+	return file, nil
+}
+
 func (fs osFileSystem) FileExists(path string) bool {
 	fs.logger.Debug(fs.logTag, "Checking if file exists %s", path)
 
@@ -172,6 +198,16 @@ func (fs osFileSystem) FileExists(path string) bool {
 	return true
 }
 
+func (fs osFileSystem) Stat(path string) (info os.FileInfo, err error) {
+	fs.logger.Debug(fs.logTag, "Stat %s", path)
+	return os.Stat(path)
+}
+
+func (fs osFileSystem) Chtimes(path string, atime, mtime time.Time) (err error) {
+	fs.logger.Debug(fs.logTag, "Changing times of %s", path)
+	return os.Chtimes(path, atime, mtime)
+}
+
 func (fs osFileSystem) Rename(oldPath, newPath string) (err error) {
 	fs.logger.Debug(fs.logTag, "Renaming %s to %s", oldPath, newPath)
 
@@ -257,6 +293,11 @@ func (fs osFileSystem) Glob(pattern string) (matches []string, err error) {
 	return filepath.Glob(pattern)
 }
 
+func (fs osFileSystem) Walk(root string, walkFunc filepath.WalkFunc) (err error) {
+	fs.logger.Debug(fs.logTag, "Walk '%s'", root)
+	return filepath.Walk(root, walkFunc)
+}
+
 func (fs osFileSystem) filesAreIdentical(newContent []byte, filePath string) bool {
 	existingStat, err := os.Stat(filePath)
 	if err != nil || int64(len(newContent)) != existingStat.Size() {
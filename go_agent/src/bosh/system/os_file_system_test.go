@@ -3,6 +3,7 @@ package system_test
 import (
 	"bytes"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
@@ -170,6 +171,27 @@ func init() {
 			})
 		})
 
+		It("create file", func() {
+			osFs, _ := createOsFs()
+			testPath := filepath.Join(os.TempDir(), "CreateFileTestDir", "CreateFileTestFile")
+
+			osFs.WriteFileString(testPath, "initial write")
+			defer os.RemoveAll(filepath.Dir(testPath))
+
+			file, err := osFs.CreateFile(testPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			file.Write([]byte("first "))
+			file.Write([]byte("second"))
+
+			err = file.Close()
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := osFs.ReadFileString(testPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(Equal("first second"))
+		})
+
 		It("read file", func() {
 			osFs, _ := createOsFs()
 			testPath := filepath.Join(os.TempDir(), "ReadFileTestFile")
@@ -182,6 +204,23 @@ func init() {
 			Expect("some contents").To(Equal(string(content)))
 		})
 
+		It("open file", func() {
+			osFs, _ := createOsFs()
+			testPath := filepath.Join(os.TempDir(), "OpenFileTestFile")
+
+			osFs.WriteFileString(testPath, "some contents")
+			defer os.Remove(testPath)
+
+			file, err := osFs.OpenFile(testPath)
+			Expect(err).ToNot(HaveOccurred())
+
+			defer file.Close()
+
+			content, err := ioutil.ReadAll(file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("some contents"))
+		})
+
 		It("file exists", func() {
 			osFs, _ := createOsFs()
 			testPath := filepath.Join(os.TempDir(), "FileExistsTestFile")
@@ -393,5 +432,33 @@ func init() {
 			_, err = os.Stat(dstFile.Name())
 			Expect(os.IsNotExist(err)).To(BeTrue())
 		})
+
+		It("walk", func() {
+			osFs, _ := createOsFs()
+			tmpDir, err := osFs.TempDir("WalkTestDir")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(tmpDir)
+
+			err = osFs.WriteFileString(filepath.Join(tmpDir, "b", "c"), "c")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = osFs.WriteFileString(filepath.Join(tmpDir, "a"), "a")
+			Expect(err).ToNot(HaveOccurred())
+
+			var walkedPaths []string
+
+			err = osFs.Walk(tmpDir, func(path string, info os.FileInfo, err error) error {
+				walkedPaths = append(walkedPaths, path)
+				return err
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(walkedPaths).To(Equal([]string{
+				tmpDir,
+				filepath.Join(tmpDir, "a"),
+				filepath.Join(tmpDir, "b"),
+				filepath.Join(tmpDir, "b", "c"),
+			}))
+		})
 	})
 }